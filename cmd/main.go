@@ -10,8 +10,12 @@ import (
 	"github.com/plasmatrip/avito_merch/internal/api/handlers"
 	"github.com/plasmatrip/avito_merch/internal/config"
 	"github.com/plasmatrip/avito_merch/internal/logger"
+	"github.com/plasmatrip/avito_merch/internal/model"
 	"github.com/plasmatrip/avito_merch/internal/router"
+	"github.com/plasmatrip/avito_merch/internal/scheduler"
+	"github.com/plasmatrip/avito_merch/internal/storage"
 	"github.com/plasmatrip/avito_merch/internal/storage/db"
+	"github.com/plasmatrip/avito_merch/internal/storage/memory"
 )
 
 func main() {
@@ -32,18 +36,20 @@ func main() {
 	}
 	defer log.Close()
 
-	// инициализируем подключение к БД
-	db, err := db.NewRepository(ctx, cfg.Database, *log)
+	// инициализируем хранилище
+	stor, closeStor, err := newStorage(ctx, *cfg, *log)
 	if err != nil {
-		log.Sugar.Infow("database connection error: ", err)
+		log.Sugar.Infow("storage initialization error: ", err)
 		os.Exit(1)
 	}
-	defer db.Close()
+	defer closeStor()
 
-	//пингуем базу
-	if err := db.Ping(ctx); err != nil {
-		log.Sugar.Infow("database connection error: ", err)
-		os.Exit(1)
+	// назначаем роль администратора учетным записям из конфигурации
+	grantAdmins(ctx, stor, cfg.AdminLogins, *log)
+
+	// запускаем ежемесячное начисление монет
+	if cfg.MonthlyAllowance > 0 {
+		go scheduler.NewAllowance(stor, *log, cfg.MonthlyAllowance, cfg.AllowanceInterval).Run(ctx)
 	}
 
 	// запускаем веб-сервер
@@ -55,7 +61,7 @@ func main() {
 		Handler: func(next http.Handler) http.Handler {
 			log.Sugar.Infow("The Avito merch store is running. ", "Server address", cfg.Host)
 			return next
-		}(router.NewRouter(*cfg, *log, *db, handlers.NewHandlers(*cfg, *log, *db))),
+		}(router.NewRouter(*cfg, *log, stor, handlers.NewHandlers(*cfg, *log, stor))),
 	}
 
 	go server.ListenAndServe()
@@ -69,3 +75,40 @@ func main() {
 
 	os.Exit(0)
 }
+
+// newStorage создает хранилище в соответствии с выбранным драйвером
+func newStorage(ctx context.Context, cfg config.Config, log logger.Logger) (storage.Repository, func(), error) {
+	if cfg.StorageDriver == config.StorageDriverMemory {
+		log.Sugar.Infow("using in-memory storage, data will be lost on shutdown")
+		stor := memory.NewRepository(log)
+		stor.Bonus = cfg.BonusPolicy()
+		stor.Limits = cfg.TransferLimits()
+		return stor, stor.Close, nil
+	}
+
+	// инициализируем подключение к БД
+	stor, err := db.NewRepository(ctx, cfg.Database, log)
+	if err != nil {
+		return nil, nil, err
+	}
+	stor.Bonus = cfg.BonusPolicy()
+	stor.Limits = cfg.TransferLimits()
+
+	//пингуем базу
+	if err := stor.Ping(ctx); err != nil {
+		stor.Close()
+		return nil, nil, err
+	}
+
+	return stor, stor.Close, nil
+}
+
+// grantAdmins назначает роль администратора существующим пользователям,
+// учетные записи при этом не создаются
+func grantAdmins(ctx context.Context, stor storage.Repository, logins []string, log logger.Logger) {
+	for _, login := range logins {
+		if err := stor.SetUserRole(ctx, login, model.RoleAdmin); err != nil {
+			log.Sugar.Infow("failed to grant admin role", "login", login, "error: ", err)
+		}
+	}
+}
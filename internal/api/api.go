@@ -4,7 +4,46 @@ import "net/http"
 
 type API interface {
 	Info(w http.ResponseWriter, r *http.Request)
+	Transactions(w http.ResponseWriter, r *http.Request)
+	Leaderboard(w http.ResponseWriter, r *http.Request)
+	SetLeaderboardVisibility(w http.ResponseWriter, r *http.Request)
 	Buy(w http.ResponseWriter, r *http.Request)
+	Gift(w http.ResponseWriter, r *http.Request)
+	TransferItems(w http.ResponseWriter, r *http.Request)
+	ItemTransfers(w http.ResponseWriter, r *http.Request)
+	Purchases(w http.ResponseWriter, r *http.Request)
+	Refund(w http.ResponseWriter, r *http.Request)
+	PlaceOrder(w http.ResponseWriter, r *http.Request)
+	Cart(w http.ResponseWriter, r *http.Request)
+	SetCartItem(w http.ResponseWriter, r *http.Request)
+	RemoveCartItem(w http.ResponseWriter, r *http.Request)
+	ClearCart(w http.ResponseWriter, r *http.Request)
+	Checkout(w http.ResponseWriter, r *http.Request)
+	Wishlist(w http.ResponseWriter, r *http.Request)
+	AddWishlistItem(w http.ResponseWriter, r *http.Request)
+	RemoveWishlistItem(w http.ResponseWriter, r *http.Request)
+	Notifications(w http.ResponseWriter, r *http.Request)
+	ReadNotifications(w http.ResponseWriter, r *http.Request)
+	Merch(w http.ResponseWriter, r *http.Request)
+	MerchItem(w http.ResponseWriter, r *http.Request)
+	MerchPrices(w http.ResponseWriter, r *http.Request)
+	CreateMerch(w http.ResponseWriter, r *http.Request)
+	UpdateMerch(w http.ResponseWriter, r *http.Request)
+	UpdateMerchPrice(w http.ResponseWriter, r *http.Request)
+	RetireMerch(w http.ResponseWriter, r *http.Request)
+	SetStock(w http.ResponseWriter, r *http.Request)
+	Restock(w http.ResponseWriter, r *http.Request)
+	SetUserRole(w http.ResponseWriter, r *http.Request)
+	GrantCoins(w http.ResponseWriter, r *http.Request)
+	SetTransferLimits(w http.ResponseWriter, r *http.Request)
+	ResetTransferLimits(w http.ResponseWriter, r *http.Request)
+	CreatePromoCode(w http.ResponseWriter, r *http.Request)
+	PromoCodes(w http.ResponseWriter, r *http.Request)
 	SendCoin(w http.ResponseWriter, r *http.Request)
+	SendCoinBatch(w http.ResponseWriter, r *http.Request)
+	CreateCoinRequest(w http.ResponseWriter, r *http.Request)
+	CoinRequests(w http.ResponseWriter, r *http.Request)
+	ApproveCoinRequest(w http.ResponseWriter, r *http.Request)
+	DeclineCoinRequest(w http.ResponseWriter, r *http.Request)
 	Auth(w http.ResponseWriter, r *http.Request)
 }
@@ -0,0 +1,338 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/plasmatrip/avito_merch/internal/apperr"
+	"github.com/plasmatrip/avito_merch/internal/model"
+)
+
+// ограничения на данные каталога и начислений
+const (
+	maxMerchNameLength   = 64 // как в таблице merch
+	maxCategoryLength    = 64 // как в таблицах merch и promo_codes
+	maxGrantReasonLength = 255
+	maxGrantPeriodLength = 64 - len(adminPeriodPrefix) // как в таблице grants, с учетом префикса
+)
+
+// adminPeriodPrefix отделяет периоды начислений администратора от периодов
+// ежемесячного начисления и бонусов при регистрации
+const adminPeriodPrefix = "admin-"
+
+func (h *Handlers) CreateMerch(w http.ResponseWriter, r *http.Request) {
+	var req model.MerchRequest
+
+	if err := jsoniter.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", err)
+		SendErrors(w, apperr.ErrBadJSON)
+		return
+	}
+
+	if err := validateMerchName(req.Name); err != nil {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", err)
+		SendErrors(w, err)
+		return
+	}
+
+	if req.Price == nil || *req.Price <= 0 {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", apperr.ErrPriceIsLessThanOrEqualToZero)
+		SendErrors(w, apperr.ErrPriceIsLessThanOrEqualToZero)
+		return
+	}
+
+	if req.Stock != nil && (*req.Stock < 0 || *req.Stock > model.MaxStock) {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", apperr.ErrInvalidStock)
+		SendErrors(w, apperr.ErrInvalidStock)
+		return
+	}
+
+	if req.Category != nil && utf8.RuneCountInString(*req.Category) > maxCategoryLength {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", apperr.ErrInvalidCategory)
+		SendErrors(w, apperr.ErrInvalidCategory)
+		return
+	}
+
+	item := model.MerchItem{
+		Name:      req.Name,
+		Price:     *req.Price,
+		Available: true,
+		Stock:     req.Stock,
+	}
+	if req.Description != nil {
+		item.Description = *req.Description
+	}
+	if req.Available != nil {
+		item.Available = *req.Available
+	}
+	if req.Category != nil {
+		item.Category = *req.Category
+	}
+
+	created, err := h.Stor.CreateMerch(r.Context(), item)
+	if err != nil {
+		SendErrors(w, err)
+		h.Logger.Sugar.Infow("internal error", "error: ", err)
+		return
+	}
+
+	h.Logger.Sugar.Infow("merch created", "admin", adminLogin(r), "item", created.Name, "price", created.Price)
+	h.sendJSON(w, http.StatusCreated, created)
+}
+
+func (h *Handlers) UpdateMerch(w http.ResponseWriter, r *http.Request) {
+	var req model.MerchRequest
+
+	if err := jsoniter.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", err)
+		SendErrors(w, apperr.ErrBadJSON)
+		return
+	}
+
+	if req.Price == nil && req.Description == nil && req.Available == nil && req.Stock == nil && req.Category == nil {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", apperr.ErrBadJSON)
+		SendErrors(w, apperr.ErrBadJSON)
+		return
+	}
+
+	if req.Price != nil && *req.Price <= 0 {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", apperr.ErrPriceIsLessThanOrEqualToZero)
+		SendErrors(w, apperr.ErrPriceIsLessThanOrEqualToZero)
+		return
+	}
+
+	if req.Stock != nil && (*req.Stock < 0 || *req.Stock > model.MaxStock) {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", apperr.ErrInvalidStock)
+		SendErrors(w, apperr.ErrInvalidStock)
+		return
+	}
+
+	if req.Category != nil && utf8.RuneCountInString(*req.Category) > maxCategoryLength {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", apperr.ErrInvalidCategory)
+		SendErrors(w, apperr.ErrInvalidCategory)
+		return
+	}
+
+	h.updateMerch(w, r, req)
+}
+
+func (h *Handlers) UpdateMerchPrice(w http.ResponseWriter, r *http.Request) {
+	var req model.PriceRequest
+
+	if err := jsoniter.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", err)
+		SendErrors(w, apperr.ErrBadJSON)
+		return
+	}
+
+	if req.Price <= 0 {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", apperr.ErrPriceIsLessThanOrEqualToZero)
+		SendErrors(w, apperr.ErrPriceIsLessThanOrEqualToZero)
+		return
+	}
+
+	h.updateMerch(w, r, model.MerchRequest{Price: &req.Price})
+}
+
+// SetStock устанавливает остаток товара, null снимает ограничение
+func (h *Handlers) SetStock(w http.ResponseWriter, r *http.Request) {
+	var req model.StockRequest
+
+	if err := jsoniter.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", err)
+		SendErrors(w, apperr.ErrBadJSON)
+		return
+	}
+
+	if req.Stock != nil && (*req.Stock < 0 || *req.Stock > model.MaxStock) {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", apperr.ErrInvalidStock)
+		SendErrors(w, apperr.ErrInvalidStock)
+		return
+	}
+
+	name := r.PathValue("name")
+	if len(name) == 0 {
+		h.Logger.Sugar.Infoln("Merch name is empty")
+		SendErrors(w, apperr.ErrMecrhNameIsEmpty)
+		return
+	}
+
+	item, err := h.Stor.SetStock(r.Context(), name, req.Stock)
+	if err != nil {
+		SendErrors(w, err)
+		h.Logger.Sugar.Infow("internal error", "error: ", err)
+		return
+	}
+
+	h.Logger.Sugar.Infow("merch stock set", "admin", adminLogin(r), "item", item.Name, "stock", item.Stock)
+	h.sendJSON(w, http.StatusOK, item)
+}
+
+// Restock пополняет остаток ограниченного товара
+func (h *Handlers) Restock(w http.ResponseWriter, r *http.Request) {
+	var req model.RestockRequest
+
+	if err := jsoniter.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", err)
+		SendErrors(w, apperr.ErrBadJSON)
+		return
+	}
+
+	if req.Quantity <= 0 || req.Quantity > model.MaxStock {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", apperr.ErrInvalidStock)
+		SendErrors(w, apperr.ErrInvalidStock)
+		return
+	}
+
+	name := r.PathValue("name")
+	if len(name) == 0 {
+		h.Logger.Sugar.Infoln("Merch name is empty")
+		SendErrors(w, apperr.ErrMecrhNameIsEmpty)
+		return
+	}
+
+	item, err := h.Stor.Restock(r.Context(), name, req.Quantity)
+	if err != nil {
+		SendErrors(w, err)
+		h.Logger.Sugar.Infow("internal error", "error: ", err)
+		return
+	}
+
+	h.Logger.Sugar.Infow("merch restocked", "admin", adminLogin(r), "item", item.Name, "quantity", req.Quantity, "stock", item.Stock)
+	h.sendJSON(w, http.StatusOK, item)
+}
+
+// RetireMerch снимает товар с продажи, купленный ранее мерч остается в инвентаре
+func (h *Handlers) RetireMerch(w http.ResponseWriter, r *http.Request) {
+	available := false
+	h.updateMerch(w, r, model.MerchRequest{Available: &available})
+}
+
+// GrantCoins начисляет монеты перечисленным пользователям или всем сразу
+func (h *Handlers) GrantCoins(w http.ResponseWriter, r *http.Request) {
+	var req model.GrantRequest
+
+	if err := jsoniter.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", err)
+		SendErrors(w, apperr.ErrBadJSON)
+		return
+	}
+
+	if req.All == (len(req.Users) > 0) {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", apperr.ErrInvalidGrantRecipients)
+		SendErrors(w, apperr.ErrInvalidGrantRecipients)
+		return
+	}
+
+	if req.Amount <= 0 {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", apperr.ErrAmonutIsLessThanOrEqualToZero)
+		SendErrors(w, apperr.ErrAmonutIsLessThanOrEqualToZero)
+		return
+	}
+
+	if len(req.Reason) == 0 || utf8.RuneCountInString(req.Reason) > maxGrantReasonLength || utf8.RuneCountInString(req.Period) > maxGrantPeriodLength {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", apperr.ErrInvalidGrantReason)
+		SendErrors(w, apperr.ErrInvalidGrantReason)
+		return
+	}
+
+	if req.Period != "" {
+		req.Period = adminPeriodPrefix + req.Period
+	}
+
+	granted, err := h.Stor.GrantCoins(r.Context(), req)
+	if err != nil {
+		SendErrors(w, err)
+		h.Logger.Sugar.Infow("internal error", "error: ", err)
+		return
+	}
+
+	h.Logger.Sugar.Infow("coins granted", "admin", adminLogin(r), "amount", req.Amount,
+		"reason", req.Reason, "period", req.Period, "granted", granted)
+	h.sendJSON(w, http.StatusOK, model.GrantResponse{Granted: granted})
+}
+
+// SetTransferLimits устанавливает индивидуальные лимиты переводов пользователя, null - лимит по умолчанию, 0 - без ограничения
+func (h *Handlers) SetTransferLimits(w http.ResponseWriter, r *http.Request) {
+	var req model.TransferLimitsRequest
+
+	if err := jsoniter.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", err)
+		SendErrors(w, apperr.ErrBadJSON)
+		return
+	}
+
+	if (req.PerTransfer != nil && *req.PerTransfer < 0) || (req.Daily != nil && *req.Daily < 0) {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", apperr.ErrInvalidTransferLimit)
+		SendErrors(w, apperr.ErrInvalidTransferLimit)
+		return
+	}
+
+	login := r.PathValue("login")
+	limits, err := h.Stor.SetTransferLimits(r.Context(), login, req)
+	if err != nil {
+		SendErrors(w, err)
+		h.Logger.Sugar.Infow("internal error", "error: ", err)
+		return
+	}
+
+	h.Logger.Sugar.Infow("transfer limits set", "admin", adminLogin(r), "user", login,
+		"perTransfer", limits.PerTransfer, "daily", limits.Daily)
+	h.sendJSON(w, http.StatusOK, limits)
+}
+
+// ResetTransferLimits возвращает пользователю лимиты переводов по умолчанию
+func (h *Handlers) ResetTransferLimits(w http.ResponseWriter, r *http.Request) {
+	login := r.PathValue("login")
+	limits, err := h.Stor.ResetTransferLimits(r.Context(), login)
+	if err != nil {
+		SendErrors(w, err)
+		h.Logger.Sugar.Infow("internal error", "error: ", err)
+		return
+	}
+
+	h.Logger.Sugar.Infow("transfer limits reset", "admin", adminLogin(r), "user", login)
+	h.sendJSON(w, http.StatusOK, limits)
+}
+
+// updateMerch изменяет товар, название которого передано в пути запроса
+func (h *Handlers) updateMerch(w http.ResponseWriter, r *http.Request, req model.MerchRequest) {
+	name := r.PathValue("name")
+	if len(name) == 0 {
+		h.Logger.Sugar.Infoln("Merch name is empty")
+		SendErrors(w, apperr.ErrMecrhNameIsEmpty)
+		return
+	}
+
+	updated, err := h.Stor.UpdateMerch(r.Context(), name, req)
+	if err != nil {
+		SendErrors(w, err)
+		h.Logger.Sugar.Infow("internal error", "error: ", err)
+		return
+	}
+
+	h.Logger.Sugar.Infow("merch updated", "admin", adminLogin(r), "item", updated.Name,
+		"price", updated.Price, "available", updated.Available)
+	h.sendJSON(w, http.StatusOK, updated)
+}
+
+// validateMerchName проверяет, что по названию мерча можно обратиться в пути запроса
+func validateMerchName(name string) error {
+	if len(name) == 0 {
+		return apperr.ErrMecrhNameIsEmpty
+	}
+	if utf8.RuneCountInString(name) > maxMerchNameLength || strings.ContainsAny(name, "/?#") || strings.TrimSpace(name) != name {
+		return apperr.ErrInvalidMerchName
+	}
+	return nil
+}
+
+// adminLogin возвращает логин администратора для журнала изменений каталога
+func adminLogin(r *http.Request) string {
+	if claims, ok := r.Context().Value(model.ValidLogin{}).(*model.Claims); ok {
+		return claims.Subject
+	}
+	return ""
+}
@@ -8,11 +8,9 @@ import (
 
 	"github.com/gofrs/uuid"
 	"github.com/golang-jwt/jwt"
-	"github.com/jackc/pgx/v5/pgconn"
 	jsoniter "github.com/json-iterator/go"
 	"github.com/plasmatrip/avito_merch/internal/apperr"
 	"github.com/plasmatrip/avito_merch/internal/model"
-	"github.com/rgurov/pgerrors"
 )
 
 func (h *Handlers) Auth(w http.ResponseWriter, r *http.Request) {
@@ -32,20 +30,20 @@ func (h *Handlers) Auth(w http.ResponseWriter, r *http.Request) {
 
 	id, err := h.Stor.UserAuth(r.Context(), req)
 	if err != nil {
-		if pgErr, ok := err.(*pgconn.PgError); ok {
-			if pgErr.Code == pgerrors.UniqueViolation {
-				h.Logger.Sugar.Infow("authentication error", "error: ", err)
-				SendErrors(w, apperr.ErrAuthenticationError)
-				return
-			}
-		}
+		h.Logger.Sugar.Infow("authentication error", "error: ", err)
+		SendErrors(w, err)
+		return
+	}
 
-		h.Logger.Sugar.Infow("internal error", "error: ", err)
+	// роль берется из учетной записи, новый пользователь всегда получает роль user
+	role, err := h.Stor.UserRole(r.Context(), id)
+	if err != nil {
+		h.Logger.Sugar.Infow("error getting user role", "error: ", err)
 		SendErrors(w, err)
 		return
 	}
 
-	token, err := h.Token(id, req)
+	token, err := h.Token(id, req.UserName, role)
 	if err != nil {
 		h.Logger.Sugar.Infow("error generating JWT", "error: ", err)
 		SendErrors(w, err)
@@ -62,13 +60,14 @@ func (h *Handlers) Auth(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
-func (h *Handlers) Token(id uuid.UUID, lr model.AuthRequest) (string, error) {
+func (h *Handlers) Token(id uuid.UUID, login, role string) (string, error) {
 	claims := model.Claims{
 		StandardClaims: jwt.StandardClaims{
 			ExpiresAt: time.Now().Add(time.Hour * 72).Unix(),
-			Subject:   lr.UserName,
+			Subject:   login,
 		},
 		UserdID: id,
+		Role:    role,
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
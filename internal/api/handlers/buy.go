@@ -2,11 +2,16 @@ package handlers
 
 import (
 	"net/http"
+	"unicode/utf8"
 
+	jsoniter "github.com/json-iterator/go"
 	"github.com/plasmatrip/avito_merch/internal/apperr"
 	"github.com/plasmatrip/avito_merch/internal/model"
 )
 
+// ограничение на длину поздравления к подарку, как в таблице purchases
+const maxGiftMessageLength = 255
+
 func (h *Handlers) Buy(w http.ResponseWriter, r *http.Request) {
 	userID := r.Context().Value(model.ValidLogin{}).(*model.Claims).UserdID
 
@@ -17,7 +22,49 @@ func (h *Handlers) Buy(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := h.Stor.BuyItem(r.Context(), userID, item)
+	// необязательный промокод передается параметром запроса
+	promo := r.URL.Query().Get("promo")
+
+	err := h.Stor.BuyItem(r.Context(), userID, item, promo)
+	if err != nil {
+		SendErrors(w, err)
+		h.Logger.Sugar.Infow("internal error", "error: ", err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// Gift покупает мерч за счет пользователя и передает его в инвентарь другого пользователя
+func (h *Handlers) Gift(w http.ResponseWriter, r *http.Request) {
+	var req model.GiftRequest
+
+	if err := jsoniter.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", err)
+		SendErrors(w, apperr.ErrBadJSON)
+		return
+	}
+
+	if len(req.ToUser) == 0 {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", apperr.ErrRecipientNotFound)
+		SendErrors(w, apperr.ErrRecipientNotFound)
+		return
+	}
+
+	if len(req.Item) == 0 {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", apperr.ErrMecrhNameIsEmpty)
+		SendErrors(w, apperr.ErrMecrhNameIsEmpty)
+		return
+	}
+
+	if utf8.RuneCountInString(req.Message) > maxGiftMessageLength {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", apperr.ErrMessageTooLong)
+		SendErrors(w, apperr.ErrMessageTooLong)
+		return
+	}
+
+	userID := r.Context().Value(model.ValidLogin{}).(*model.Claims).UserdID
+
+	err := h.Stor.GiftItem(r.Context(), userID, req)
 	if err != nil {
 		SendErrors(w, err)
 		h.Logger.Sugar.Infow("internal error", "error: ", err)
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/plasmatrip/avito_merch/internal/apperr"
+	"github.com/plasmatrip/avito_merch/internal/model"
+)
+
+func (h *Handlers) Cart(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.ValidLogin{}).(*model.Claims).UserdID
+
+	cart, err := h.Stor.Cart(r.Context(), userID)
+	if err != nil {
+		SendErrors(w, err)
+		h.Logger.Sugar.Infow("internal error", "error: ", err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, cart)
+}
+
+// SetCartItem кладет мерч в корзину или изменяет его количество
+func (h *Handlers) SetCartItem(w http.ResponseWriter, r *http.Request) {
+	var req model.CartRequest
+
+	if err := jsoniter.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", err)
+		SendErrors(w, apperr.ErrBadJSON)
+		return
+	}
+
+	if len(req.Name) == 0 {
+		h.Logger.Sugar.Infoln("Merch name is empty")
+		SendErrors(w, apperr.ErrMecrhNameIsEmpty)
+		return
+	}
+
+	if req.Quantity <= 0 || req.Quantity > maxOrderQuantity {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", apperr.ErrInvalidQuantity)
+		SendErrors(w, apperr.ErrInvalidQuantity)
+		return
+	}
+
+	userID := r.Context().Value(model.ValidLogin{}).(*model.Claims).UserdID
+
+	cart, err := h.Stor.SetCartItem(r.Context(), userID, req.Name, req.Quantity)
+	if err != nil {
+		SendErrors(w, err)
+		h.Logger.Sugar.Infow("internal error", "error: ", err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, cart)
+}
+
+// RemoveCartItem убирает мерч из корзины
+func (h *Handlers) RemoveCartItem(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.ValidLogin{}).(*model.Claims).UserdID
+
+	name := r.PathValue("name")
+	if len(name) == 0 {
+		h.Logger.Sugar.Infoln("Merch name is empty")
+		SendErrors(w, apperr.ErrMecrhNameIsEmpty)
+		return
+	}
+
+	cart, err := h.Stor.RemoveCartItem(r.Context(), userID, name)
+	if err != nil {
+		SendErrors(w, err)
+		h.Logger.Sugar.Infow("internal error", "error: ", err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, cart)
+}
+
+// ClearCart очищает корзину
+func (h *Handlers) ClearCart(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.ValidLogin{}).(*model.Claims).UserdID
+
+	err := h.Stor.ClearCart(r.Context(), userID)
+	if err != nil {
+		SendErrors(w, err)
+		h.Logger.Sugar.Infow("internal error", "error: ", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Checkout оформляет заказ из корзины, тело запроса необязательно
+func (h *Handlers) Checkout(w http.ResponseWriter, r *http.Request) {
+	var req model.CheckoutRequest
+
+	if err := jsoniter.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", err)
+		SendErrors(w, apperr.ErrBadJSON)
+		return
+	}
+
+	userID := r.Context().Value(model.ValidLogin{}).(*model.Claims).UserdID
+
+	order, err := h.Stor.Checkout(r.Context(), userID, req.AcceptPriceChanges, req.Promo)
+	if err != nil {
+		SendErrors(w, err)
+		h.Logger.Sugar.Infow("internal error", "error: ", err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusCreated, order)
+}
+
+func (h *Handlers) sendJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := jsoniter.NewEncoder(w).Encode(v); err != nil {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", err)
+	}
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/gofrs/uuid"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/plasmatrip/avito_merch/internal/apperr"
+	"github.com/plasmatrip/avito_merch/internal/model"
+)
+
+// CreateCoinRequest запрашивает монеты у другого пользователя
+func (h *Handlers) CreateCoinRequest(w http.ResponseWriter, r *http.Request) {
+	var req model.NewCoinRequest
+
+	if err := jsoniter.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", err)
+		SendErrors(w, apperr.ErrBadJSON)
+		return
+	}
+
+	if len(req.FromUser) == 0 {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", apperr.ErrRecipientNotFound)
+		SendErrors(w, apperr.ErrRecipientNotFound)
+		return
+	}
+
+	if req.Amount <= 0 {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", apperr.ErrAmonutIsLessThanOrEqualToZero)
+		SendErrors(w, apperr.ErrAmonutIsLessThanOrEqualToZero)
+		return
+	}
+
+	if utf8.RuneCountInString(req.Message) > maxTransferMessageLength {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", apperr.ErrMessageTooLong)
+		SendErrors(w, apperr.ErrMessageTooLong)
+		return
+	}
+
+	userID := r.Context().Value(model.ValidLogin{}).(*model.Claims).UserdID
+
+	request, err := h.Stor.CreateCoinRequest(r.Context(), userID, req, h.Config.CoinRequestTTL)
+	if err != nil {
+		SendErrors(w, err)
+		h.Logger.Sugar.Infow("internal error", "error: ", err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusCreated, request)
+}
+
+// CoinRequests возвращает ожидающие решения запросы монет у пользователя
+func (h *Handlers) CoinRequests(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.ValidLogin{}).(*model.Claims).UserdID
+
+	requests, err := h.Stor.CoinRequests(r.Context(), userID)
+	if err != nil {
+		SendErrors(w, err)
+		h.Logger.Sugar.Infow("internal error", "error: ", err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, requests)
+}
+
+// ApproveCoinRequest одобряет запрос монет, монеты переводятся запросившему пользователю
+func (h *Handlers) ApproveCoinRequest(w http.ResponseWriter, r *http.Request) {
+	h.resolveCoinRequest(w, r, h.Stor.ApproveCoinRequest)
+}
+
+// DeclineCoinRequest отклоняет запрос монет
+func (h *Handlers) DeclineCoinRequest(w http.ResponseWriter, r *http.Request) {
+	h.resolveCoinRequest(w, r, h.Stor.DeclineCoinRequest)
+}
+
+// resolveCoinRequest принимает решение по запросу монет, id которого передан в пути запроса
+func (h *Handlers) resolveCoinRequest(w http.ResponseWriter, r *http.Request,
+	resolve func(ctx context.Context, userID, requestID uuid.UUID) (model.CoinRequest, error)) {
+	userID := r.Context().Value(model.ValidLogin{}).(*model.Claims).UserdID
+
+	requestID, err := uuid.FromString(r.PathValue("id"))
+	if err != nil {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", err)
+		SendErrors(w, apperr.ErrCoinRequestNotFound)
+		return
+	}
+
+	request, err := resolve(r.Context(), userID, requestID)
+	if err != nil {
+		SendErrors(w, err)
+		h.Logger.Sugar.Infow("internal error", "error: ", err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, request)
+}
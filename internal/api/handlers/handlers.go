@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	jsoniter "github.com/json-iterator/go"
@@ -26,6 +27,16 @@ func NewHandlers(cfg config.Config, l logger.Logger, db storage.Repository) *Han
 }
 
 func SendErrors(w http.ResponseWriter, err error) {
+	errResponse := model.ErrorResponse{}
+
+	// при превышении лимита сообщаем, сколько еще можно перевести
+	var limitErr *apperr.TransferLimitError
+	if errors.As(err, &limitErr) {
+		errResponse.Remaining = &limitErr.Remaining
+	}
+
+	err = knownError(err)
+
 	msg, ok := apperr.ErrorMessages[err]
 	if !ok {
 		msg = "internal error"
@@ -37,6 +48,21 @@ func SendErrors(w http.ResponseWriter, err error) {
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
-	errResponse := model.ErrorResponse{Errors: msg}
+	errResponse.Errors = msg
 	jsoniter.NewEncoder(w).Encode(errResponse)
 }
+
+// knownError возвращает прикладную ошибку из apperr, если она содержится в цепочке err
+func knownError(err error) error {
+	if _, ok := apperr.ErrorStatuses[err]; ok {
+		return err
+	}
+
+	for known := range apperr.ErrorStatuses {
+		if errors.Is(err, known) {
+			return known
+		}
+	}
+
+	return err
+}
@@ -5,96 +5,49 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
-	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gofrs/uuid"
-	"github.com/jackc/pgx/v5"
+	"github.com/golang-jwt/jwt"
 	jsoniter "github.com/json-iterator/go"
 	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
-	"github.com/testcontainers/testcontainers-go"
-	"github.com/testcontainers/testcontainers-go/modules/postgres"
-	"github.com/testcontainers/testcontainers-go/wait"
 
 	"github.com/plasmatrip/avito_merch/internal/api/handlers"
+	"github.com/plasmatrip/avito_merch/internal/apperr"
+	"github.com/plasmatrip/avito_merch/internal/config"
 	"github.com/plasmatrip/avito_merch/internal/logger"
 	"github.com/plasmatrip/avito_merch/internal/model"
-	"github.com/plasmatrip/avito_merch/internal/storage/db"
+	"github.com/plasmatrip/avito_merch/internal/storage/memory"
 )
 
+// товар из каталога по умолчанию
 const (
-	itemName  = "phone"
+	itemName  = "pen"
 	itemPrice = 10
 )
 
 type HandlersTestSuite struct {
 	suite.Suite
-	db          *db.PostgresDB
-	pgContainer *postgres.PostgresContainer
-	handlers    *handlers.Handlers
+	db       *memory.MemoryDB
+	handlers *handlers.Handlers
 }
 
 // настройка окрежения тестов
 func (suite *HandlersTestSuite) SetupSuite() {
-	ctx := context.Background()
-
 	logger, err := logger.NewLogger(logger.LogLevelDebug)
 	if err != nil {
 		suite.T().Fatal(err)
 	}
 
-	// запускаем контейнер
-	pgContainer, err := postgres.Run(ctx,
-		"postgres:17.2",
-		postgres.WithInitScripts(filepath.Join("..", "..", "storage", "db", "init_test", "init_test_db.sh")),
-		postgres.WithDatabase("postgres"),
-		postgres.WithUsername("postgres"),
-		postgres.WithPassword("password"),
-		testcontainers.WithWaitStrategy(
-			wait.ForLog("database system is ready to accept connections").
-				WithOccurrence(2).WithStartupTimeout(5*time.Second)),
-	)
-	if err != nil {
-		suite.T().Fatal(err)
-	}
-	suite.pgContainer = pgContainer
-
-	suite.T().Cleanup(func() {
-		if err := pgContainer.Terminate(ctx); err != nil {
-			suite.T().Fatalf("failed to terminate pgContainer: %s", err)
-		}
-	})
-
-	connStr, err := pgContainer.ConnectionString(ctx, "sslmode=disable")
-	assert.NoError(suite.T(), err, "failed to get connection string")
-
-	stor, err := db.NewRepository(ctx, connStr, *logger)
-	assert.NoError(suite.T(), err, "failed to create repository")
-
-	suite.db = stor
+	suite.db = memory.NewRepository(*logger)
 
 	suite.handlers = &handlers.Handlers{
-		Stor:   stor,
+		Stor:   suite.db,
 		Logger: *logger,
 	}
-
-	ct, err := suite.db.DB.Exec(ctx, "INSERT INTO merch (id, name, price) VALUES (gen_random_uuid (), @name, @price)", pgx.NamedArgs{
-		"name":  itemName,
-		"price": itemPrice,
-	})
-	require.NoError(suite.T(), err, "failed to insert item")
-	require.Equal(suite.T(), int64(1), ct.RowsAffected(), "failed to insert item. expected 1 row, got %d", ct.RowsAffected())
-}
-
-// удаляем контейнер
-func (suite *HandlersTestSuite) TearDownAll(t *testing.T) {
-	if err := testcontainers.TerminateContainer(suite.pgContainer); err != nil {
-		t.Fatalf("failed to terminate container: %s", err)
-	}
-	t.Log("TearDownAll")
 }
 
 // запускаем тест
@@ -198,6 +151,21 @@ func (suite *HandlersTestSuite) TestSendCoin() {
 		}
 	})
 
+	suite.T().Run("message too long", func(t *testing.T) {
+		sc := model.SendCoinRequest{ToUser: toUser, Amount: 10, Message: strings.Repeat("я", 256)}
+		jsonData, _ := jsoniter.Marshal(sc)
+		req := httptest.NewRequest(http.MethodPost, "/api/sendCoin", bytes.NewReader(jsonData))
+		ctxWV := context.WithValue(req.Context(), model.ValidLogin{}, &model.Claims{UserdID: fromUserID})
+		req = req.WithContext(ctxWV)
+		w := httptest.NewRecorder()
+
+		suite.handlers.SendCoin(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
 	suite.T().Run("invaliud JSON", func(t *testing.T) {
 		badData := []byte("{}")
 		req := httptest.NewRequest(http.MethodPost, "/api/sendCoin", bytes.NewReader(badData))
@@ -259,18 +227,58 @@ func (suite *HandlersTestSuite) TestBuy() {
 	})
 }
 
+// тест на покупку мерча в подарок
+func (suite *HandlersTestSuite) TestGift() {
+	ctx := context.Background()
+
+	fromID, err := suite.db.UserAuth(ctx, model.AuthRequest{UserName: "kirill", Password: "kirill"})
+	assert.NoError(suite.T(), err, "an error occurred during user authorization")
+	toID, err := suite.db.UserAuth(ctx, model.AuthRequest{UserName: "daria", Password: "daria"})
+	assert.NoError(suite.T(), err, "an error occurred during user authorization")
+
+	send := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/gift", bytes.NewReader([]byte(body)))
+		req = req.WithContext(context.WithValue(req.Context(), model.ValidLogin{}, &model.Claims{UserdID: fromID}))
+		w := httptest.NewRecorder()
+		suite.handlers.Gift(w, req)
+		return w
+	}
+
+	suite.T().Run("gift sent", func(t *testing.T) {
+		w := send(`{"toUser":"daria","item":"` + itemName + `","message":"thank you"}`)
+		assert.Equal(t, http.StatusOK, w.Code, "unexpected status of a gift")
+
+		info, err := suite.db.Info(ctx, toID)
+		assert.NoError(t, err, "an error occurred while getting information about a user")
+		assert.Equal(t, []model.Inventory{{Type: itemName, Quantity: 1}}, info.Inventory, "gift must be in the recipient's inventory")
+	})
+
+	suite.T().Run("invalid gifts", func(t *testing.T) {
+		for _, body := range []string{
+			`{"item":"` + itemName + `"}`,
+			`{"toUser":"daria"}`,
+			`{"toUser":"kirill","item":"` + itemName + `"}`,
+			`{"toUser":"daria","item":"` + itemName + `","message":"` + strings.Repeat("a", 256) + `"}`,
+			`{"toUser":`,
+		} {
+			w := send(body)
+			assert.Equal(t, http.StatusBadRequest, w.Code, "unexpected status for %s", body)
+		}
+	})
+}
+
 func (suite *HandlersTestSuite) TestInfo() {
 	ctx := context.Background()
 
 	user1 := "boris"
 
 	user2 := "kevin"
-	user2Amount := 10
+	user2Amount := int64(10)
 
 	user3 := "ivan"
-	user3Amount := 25
+	user3Amount := int64(25)
 
-	user1InitialAmount := 1000
+	user1InitialAmount := int64(1000)
 
 	user1ID, err := suite.db.UserAuth(ctx, model.AuthRequest{
 		UserName: user1,
@@ -293,7 +301,7 @@ func (suite *HandlersTestSuite) TestInfo() {
 	assert.NoError(suite.T(), err, "an error occurred during user authorization")
 	assert.NotNil(suite.T(), user3ID, "non-zero id was returned")
 
-	err = suite.db.BuyItem(ctx, user1ID, itemName)
+	err = suite.db.BuyItem(ctx, user1ID, itemName, "")
 	assert.NoError(suite.T(), err, "an error occurred when buying an existing item")
 
 	err = suite.db.SendCoin(ctx, user1ID, model.SendCoinRequest{
@@ -375,3 +383,879 @@ func (suite *HandlersTestSuite) TestInfo() {
 		assert.Equal(t, expected, got, "info response does not match expected")
 	})
 }
+
+// тест на историю операций
+func (suite *HandlersTestSuite) TestTransactions() {
+	ctx := context.Background()
+
+	userID, err := suite.db.UserAuth(ctx, model.AuthRequest{
+		UserName: "olga",
+		Password: "olga",
+	})
+	assert.NoError(suite.T(), err, "an error occurred during user authorization")
+
+	for range 3 {
+		assert.NoError(suite.T(), suite.db.BuyItem(ctx, userID, itemName, ""), "an error occurred while buying an item")
+	}
+
+	get := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/transactions"+query, nil)
+		ctx := context.WithValue(req.Context(), model.ValidLogin{}, &model.Claims{UserdID: userID})
+		req = req.WithContext(ctx)
+		w := httptest.NewRecorder()
+
+		suite.handlers.Transactions(w, req)
+		return w
+	}
+
+	suite.T().Run("history paginated by cursor", func(t *testing.T) {
+		var entries []model.HistoryEntry
+		query := "?limit=2"
+		for {
+			w := get(query)
+			if !assert.Equal(t, http.StatusOK, w.Code, "unexpected status") {
+				return
+			}
+
+			var got model.HistoryResponse
+			if err := jsoniter.NewDecoder(w.Body).Decode(&got); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			entries = append(entries, got.Transactions...)
+
+			if got.NextCursor == "" {
+				break
+			}
+			query = "?limit=2&cursor=" + got.NextCursor
+		}
+
+		assert.Len(t, entries, 4, "unexpected number of entries")
+		assert.Equal(t, int64(1000-3*itemPrice), entries[0].BalanceAfter, "unexpected balance after the last purchase")
+		assert.Equal(t, model.OperationBonus, entries[3].Type, "the first entry must be the bonus")
+	})
+
+	suite.T().Run("invalid query parameters", func(t *testing.T) {
+		for _, query := range []string{"?limit=0", "?limit=abc", "?limit=1000", "?cursor=!!!", "?direction=up", "?from=yesterday"} {
+			w := get(query)
+			assert.Equal(t, http.StatusBadRequest, w.Code, "unexpected status for %s", query)
+		}
+	})
+}
+
+// тест на каталог мерча
+func (suite *HandlersTestSuite) TestMerch() {
+	suite.T().Run("catalog with etag", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/merch?sort=price&maxPrice=10", nil)
+		w := httptest.NewRecorder()
+
+		suite.handlers.Merch(w, req)
+		assert.Equal(t, http.StatusOK, w.Code, "unexpected status")
+
+		var got []model.MerchItem
+		if err := jsoniter.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		for _, item := range got {
+			assert.LessOrEqual(t, item.Price, int64(10), "price above the range")
+		}
+
+		etag := w.Header().Get("ETag")
+		assert.NotEmpty(t, etag, "etag must be set")
+
+		req = httptest.NewRequest(http.MethodGet, "/api/merch?sort=price&maxPrice=10", nil)
+		req.Header.Set("If-None-Match", etag)
+		w = httptest.NewRecorder()
+
+		suite.handlers.Merch(w, req)
+		assert.Equal(t, http.StatusNotModified, w.Code, "unexpected status for a matching etag")
+		assert.Empty(t, w.Body.Bytes(), "not modified response must be empty")
+	})
+
+	suite.T().Run("invalid query parameters", func(t *testing.T) {
+		for _, query := range []string{"?sort=date", "?order=up", "?minPrice=-1", "?maxPrice=abc", "?minPrice=20&maxPrice=10"} {
+			req := httptest.NewRequest(http.MethodGet, "/api/merch"+query, nil)
+			w := httptest.NewRecorder()
+
+			suite.handlers.Merch(w, req)
+			assert.Equal(t, http.StatusBadRequest, w.Code, "unexpected status for %s", query)
+		}
+	})
+
+	suite.T().Run("item", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/merch/"+itemName, nil)
+		req.SetPathValue("name", itemName)
+		w := httptest.NewRecorder()
+
+		suite.handlers.MerchItem(w, req)
+		assert.Equal(t, http.StatusOK, w.Code, "unexpected status")
+
+		var got model.MerchItem
+		if err := jsoniter.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		assert.Equal(t, int64(itemPrice), got.Price, "unexpected item price")
+	})
+
+	suite.T().Run("item not found", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/merch/hummer", nil)
+		req.SetPathValue("name", "hummer")
+		w := httptest.NewRecorder()
+
+		suite.handlers.MerchItem(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "unexpected status")
+		assert.Contains(t, w.Body.String(), apperr.ErrItemNotFound.Error(), "unexpected error message")
+	})
+}
+
+// тест на управление каталогом
+func (suite *HandlersTestSuite) TestAdminMerch() {
+	send := func(method, path, name, body string, handler http.HandlerFunc) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		if name != "" {
+			req.SetPathValue("name", name)
+		}
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w
+	}
+
+	suite.T().Run("item created, repriced and retired", func(t *testing.T) {
+		w := send(http.MethodPost, "/api/admin/merch", "", `{"name":"mug","price":40,"description":"Big mug"}`, suite.handlers.CreateMerch)
+		assert.Equal(t, http.StatusCreated, w.Code, "unexpected status of item creation")
+
+		w = send(http.MethodPut, "/api/admin/merch/mug/price", "mug", `{"price":45}`, suite.handlers.UpdateMerchPrice)
+		assert.Equal(t, http.StatusOK, w.Code, "unexpected status of item repricing")
+
+		var got model.MerchItem
+		if err := jsoniter.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		assert.Equal(t, model.MerchItem{Name: "mug", Price: 45, Description: "Big mug", Available: true}, got, "unexpected repriced item")
+
+		w = send(http.MethodDelete, "/api/admin/merch/mug", "mug", "", suite.handlers.RetireMerch)
+		assert.Equal(t, http.StatusOK, w.Code, "unexpected status of item retirement")
+
+		item, err := suite.db.MerchItem(context.Background(), "mug")
+		assert.NoError(t, err, "retired item must stay in the catalog")
+		assert.False(t, item.Available, "retired item must not be available")
+	})
+
+	suite.T().Run("invalid requests", func(t *testing.T) {
+		for _, body := range []string{`{"name":"","price":10}`, `{"name":"a/b","price":10}`, `{"name":"cap"}`, `{"name":"cap","price":0}`, `{`} {
+			w := send(http.MethodPost, "/api/admin/merch", "", body, suite.handlers.CreateMerch)
+			assert.Equal(t, http.StatusBadRequest, w.Code, "unexpected status for %s", body)
+		}
+
+		w := send(http.MethodPost, "/api/admin/merch", "", `{"name":"pen","price":10}`, suite.handlers.CreateMerch)
+		assert.Equal(t, http.StatusConflict, w.Code, "unexpected status for a duplicate item")
+
+		w = send(http.MethodPut, "/api/admin/merch/pen", "pen", `{}`, suite.handlers.UpdateMerch)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "unexpected status for an empty update")
+
+		w = send(http.MethodPut, "/api/admin/merch/hummer/price", "hummer", `{"price":10}`, suite.handlers.UpdateMerchPrice)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "unexpected status for a non-existent item")
+	})
+
+	suite.T().Run("stock set and restocked", func(t *testing.T) {
+		w := send(http.MethodPost, "/api/admin/merch", "", `{"name":"poster","price":15,"stock":0}`, suite.handlers.CreateMerch)
+		assert.Equal(t, http.StatusCreated, w.Code, "unexpected status of item creation")
+
+		w = send(http.MethodPost, "/api/admin/merch/poster/restock", "poster", `{"quantity":5}`, suite.handlers.Restock)
+		assert.Equal(t, http.StatusOK, w.Code, "unexpected status of restocking")
+
+		var got model.MerchItem
+		if err := jsoniter.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if assert.NotNil(t, got.Stock, "stock must be limited") {
+			assert.Equal(t, 5, *got.Stock, "unexpected stock after restocking")
+		}
+
+		w = send(http.MethodPut, "/api/admin/merch/poster/stock", "poster", `{"stock":null}`, suite.handlers.SetStock)
+		assert.Equal(t, http.StatusOK, w.Code, "unexpected status of removing the stock limit")
+
+		w = send(http.MethodPost, "/api/admin/merch/poster/restock", "poster", `{"quantity":5}`, suite.handlers.Restock)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "unexpected status of restocking an unlimited item")
+
+		w = send(http.MethodPost, "/api/admin/merch/poster/restock", "poster", `{"quantity":0}`, suite.handlers.Restock)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "unexpected status of an empty restock")
+
+		w = send(http.MethodPut, "/api/admin/merch/poster/stock", "poster", `{"stock":-1}`, suite.handlers.SetStock)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "unexpected status of a negative stock")
+
+		w = send(http.MethodPut, "/api/admin/merch/poster/stock", "poster", `{"stock":2147483648}`, suite.handlers.SetStock)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "unexpected status of a stock over the maximum")
+
+		w = send(http.MethodPost, "/api/admin/merch/poster/restock", "poster", `{"quantity":2147483648}`, suite.handlers.Restock)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "unexpected status of a restock over the maximum")
+	})
+
+	suite.T().Run("coins granted", func(t *testing.T) {
+		_, err := suite.db.UserAuth(context.Background(), model.AuthRequest{UserName: "pavel", Password: "password"})
+		assert.NoError(t, err, "an error occurred during user registration")
+
+		w := send(http.MethodPost, "/api/admin/grants", "", `{"users":["pavel"],"amount":25,"reason":"hackathon winner"}`, suite.handlers.GrantCoins)
+		assert.Equal(t, http.StatusOK, w.Code, "unexpected status of coin grant")
+		assert.JSONEq(t, `{"granted":1}`, w.Body.String(), "unexpected grant response")
+
+		w = send(http.MethodPost, "/api/admin/grants", "", `{"users":["nobody"],"amount":25,"reason":"bonus"}`, suite.handlers.GrantCoins)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "unexpected status of a grant to a non-existent user")
+	})
+
+	suite.T().Run("admin periods do not collide with allowance periods", func(t *testing.T) {
+		granted, err := suite.db.GrantCoins(context.Background(), model.GrantRequest{Users: []string{"pavel"}, Amount: 10, Reason: "allowance", Period: "allowance-2026-10"})
+		assert.NoError(t, err, "an error occurred while granting coins")
+		assert.Equal(t, 1, granted, "unexpected number of allowance recipients")
+
+		body := `{"users":["pavel"],"amount":25,"reason":"bonus","period":"allowance-2026-10"}`
+		w := send(http.MethodPost, "/api/admin/grants", "", body, suite.handlers.GrantCoins)
+		assert.Equal(t, http.StatusOK, w.Code, "unexpected status of coin grant")
+		assert.JSONEq(t, `{"granted":1}`, w.Body.String(), "the admin grant must not be skipped")
+
+		w = send(http.MethodPost, "/api/admin/grants", "", body, suite.handlers.GrantCoins)
+		assert.JSONEq(t, `{"granted":0}`, w.Body.String(), "the admin grant must be granted once per period")
+	})
+
+	suite.T().Run("invalid grants", func(t *testing.T) {
+		for _, body := range []string{
+			`{"amount":25,"reason":"bonus"}`,
+			`{"users":["pavel"],"all":true,"amount":25,"reason":"bonus"}`,
+			`{"users":["pavel"],"amount":0,"reason":"bonus"}`,
+			`{"users":["pavel"],"amount":25}`,
+			`{"users":["pavel"],"amount":25,"reason":"bonus","period":"` + strings.Repeat("p", 59) + `"}`,
+			`{`,
+		} {
+			w := send(http.MethodPost, "/api/admin/grants", "", body, suite.handlers.GrantCoins)
+			assert.Equal(t, http.StatusBadRequest, w.Code, "unexpected status for %s", body)
+		}
+	})
+}
+
+// тест на роль в токене
+func (suite *HandlersTestSuite) TestTokenRole() {
+	// логин из ADMIN_LOGINS не дает роль администратора при входе
+	h := &handlers.Handlers{Stor: suite.db, Logger: suite.handlers.Logger, Config: config.Config{TokenSecret: "secret", AdminLogins: []string{"root"}}}
+
+	role := func(login string) string {
+		jsonData, _ := jsoniter.Marshal(model.AuthRequest{UserName: login, Password: login})
+		w := httptest.NewRecorder()
+		h.Auth(w, httptest.NewRequest(http.MethodPost, "/api/auth", bytes.NewReader(jsonData)))
+		assert.Equal(suite.T(), http.StatusOK, w.Code, "unexpected status of authentication")
+
+		var resp model.AuthResponse
+		assert.NoError(suite.T(), jsoniter.NewDecoder(w.Body).Decode(&resp), "failed to decode response")
+
+		claims := &model.Claims{}
+		_, err := jwt.ParseWithClaims(resp.Token, claims, func(*jwt.Token) (interface{}, error) {
+			return []byte("secret"), nil
+		})
+		assert.NoError(suite.T(), err, "an error occurred while parsing a token")
+		return claims.Role
+	}
+
+	setRole := func(login, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/admin/users/"+login+"/role", bytes.NewReader([]byte(body)))
+		req.SetPathValue("login", login)
+		w := httptest.NewRecorder()
+		h.SetUserRole(w, req)
+		return w
+	}
+
+	suite.T().Run("new account gets the user role", func(t *testing.T) {
+		assert.Equal(t, model.RoleUser, role("root"), "a new account must not get the admin role")
+		assert.Equal(t, model.RoleUser, role("root"), "the admin role must not be granted by login")
+	})
+
+	suite.T().Run("role granted and revoked by an admin", func(t *testing.T) {
+		w := setRole("root", `{"role":"admin"}`)
+		assert.Equal(t, http.StatusOK, w.Code, "unexpected status of granting a role")
+		assert.JSONEq(t, `{"login":"root","role":"admin"}`, w.Body.String(), "unexpected role")
+		assert.Equal(t, model.RoleAdmin, role("root"), "unexpected role after granting")
+
+		w = setRole("root", `{"role":"user"}`)
+		assert.Equal(t, http.StatusOK, w.Code, "unexpected status of revoking a role")
+		assert.Equal(t, model.RoleUser, role("root"), "unexpected role after revoking")
+	})
+
+	suite.T().Run("invalid role requests", func(t *testing.T) {
+		for _, body := range []string{`{"role":"owner"}`, `{}`, `{`} {
+			w := setRole("root", body)
+			assert.Equal(t, http.StatusBadRequest, w.Code, "unexpected status for %s", body)
+		}
+
+		w := setRole("nobody", `{"role":"admin"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "unexpected status for a non-existent user")
+	})
+}
+
+// тест на возврат покупки
+func (suite *HandlersTestSuite) TestRefund() {
+	ctx := context.Background()
+
+	userID, err := suite.db.UserAuth(ctx, model.AuthRequest{
+		UserName: "petr",
+		Password: "petr",
+	})
+	assert.NoError(suite.T(), err, "an error occurred during user authorization")
+	assert.NoError(suite.T(), suite.db.BuyItem(ctx, userID, itemName, ""), "an error occurred while buying an item")
+
+	page, err := suite.db.TransactionHistory(ctx, userID, model.HistoryFilter{Limit: 1})
+	assert.NoError(suite.T(), err, "an error occurred while getting transaction history")
+	purchaseID := page.Entries[0].OperationID
+
+	h := &handlers.Handlers{Stor: suite.db, Logger: suite.handlers.Logger, Config: config.Config{RefundWindow: time.Hour}}
+
+	refund := func(id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/purchases/"+id+"/refund", nil)
+		req = req.WithContext(context.WithValue(req.Context(), model.ValidLogin{}, &model.Claims{UserdID: userID}))
+		req.SetPathValue("id", id)
+		w := httptest.NewRecorder()
+
+		h.Refund(w, req)
+		return w
+	}
+
+	suite.T().Run("purchase refunded", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, refund(purchaseID.String()).Code, "unexpected status of a refund")
+		assert.Equal(t, http.StatusConflict, refund(purchaseID.String()).Code, "unexpected status of a repeated refund")
+	})
+
+	suite.T().Run("invalid purchase id", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, refund("abc").Code, "unexpected status for an invalid purchase id")
+	})
+}
+
+// тест на оформление заказа
+func (suite *HandlersTestSuite) TestPlaceOrder() {
+	ctx := context.Background()
+
+	userID, err := suite.db.UserAuth(ctx, model.AuthRequest{
+		UserName: "anna",
+		Password: "anna",
+	})
+	assert.NoError(suite.T(), err, "an error occurred during user authorization")
+
+	order := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/orders", bytes.NewReader([]byte(body)))
+		req = req.WithContext(context.WithValue(req.Context(), model.ValidLogin{}, &model.Claims{UserdID: userID}))
+		w := httptest.NewRecorder()
+
+		suite.handlers.PlaceOrder(w, req)
+		return w
+	}
+
+	suite.T().Run("order placed", func(t *testing.T) {
+		w := order(`{"items":[{"name":"pen","quantity":2},{"name":"pen","quantity":3}]}`)
+		assert.Equal(t, http.StatusCreated, w.Code, "unexpected status of an order")
+
+		var got model.Order
+		if err := jsoniter.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		assert.Equal(t, []model.OrderLine{{Name: itemName, Quantity: 5, Price: itemPrice, Total: 5 * itemPrice}}, got.Items, "duplicate items must be merged")
+		assert.Equal(t, int64(5*itemPrice), got.Total, "unexpected order total")
+	})
+
+	suite.T().Run("invalid orders", func(t *testing.T) {
+		for _, body := range []string{`{"items":[]}`, `{"items":[{"name":"pen","quantity":0}]}`, `{"items":[{"name":"","quantity":1}]}`, `{"items":[{"name":"pen","quantity":101}]}`, `{`} {
+			assert.Equal(t, http.StatusBadRequest, order(body).Code, "unexpected status for %s", body)
+		}
+	})
+}
+
+// тест на корзину
+func (suite *HandlersTestSuite) TestCart() {
+	ctx := context.Background()
+
+	userID, err := suite.db.UserAuth(ctx, model.AuthRequest{
+		UserName: "maria",
+		Password: "maria",
+	})
+	assert.NoError(suite.T(), err, "an error occurred during user authorization")
+
+	send := func(method, body string, handler http.HandlerFunc) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/cart", bytes.NewReader([]byte(body)))
+		req = req.WithContext(context.WithValue(req.Context(), model.ValidLogin{}, &model.Claims{UserdID: userID}))
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+		return w
+	}
+
+	suite.T().Run("cart checked out", func(t *testing.T) {
+		w := send(http.MethodPut, `{"name":"pen","quantity":2}`, suite.handlers.SetCartItem)
+		assert.Equal(t, http.StatusOK, w.Code, "unexpected status of putting an item in the cart")
+
+		w = send(http.MethodGet, "", suite.handlers.Cart)
+		assert.Equal(t, http.StatusOK, w.Code, "unexpected status of getting the cart")
+
+		var cart model.Cart
+		if err := jsoniter.NewDecoder(w.Body).Decode(&cart); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		assert.Equal(t, int64(2*itemPrice), cart.Total, "unexpected cart total")
+
+		w = send(http.MethodPost, "", suite.handlers.Checkout)
+		assert.Equal(t, http.StatusCreated, w.Code, "unexpected status of checkout without a body")
+
+		w = send(http.MethodPost, `{"acceptPriceChanges":true}`, suite.handlers.Checkout)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "unexpected status of an empty cart checkout")
+	})
+
+	suite.T().Run("invalid requests", func(t *testing.T) {
+		for _, body := range []string{`{"name":"pen","quantity":0}`, `{"name":"","quantity":1}`, `{`} {
+			assert.Equal(t, http.StatusBadRequest, send(http.MethodPut, body, suite.handlers.SetCartItem).Code, "unexpected status for %s", body)
+		}
+	})
+}
+
+// тест на запросы монет
+func (suite *HandlersTestSuite) TestCoinRequests() {
+	ctx := context.Background()
+
+	requesterID, err := suite.db.UserAuth(ctx, model.AuthRequest{UserName: "irina", Password: "irina"})
+	assert.NoError(suite.T(), err, "an error occurred during user authorization")
+	payerID, err := suite.db.UserAuth(ctx, model.AuthRequest{UserName: "oleg", Password: "oleg"})
+	assert.NoError(suite.T(), err, "an error occurred during user authorization")
+
+	h := &handlers.Handlers{Stor: suite.db, Logger: suite.handlers.Logger, Config: config.Config{CoinRequestTTL: time.Hour}}
+
+	send := func(userID uuid.UUID, method, id, body string, handler http.HandlerFunc) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/coinRequests", bytes.NewReader([]byte(body)))
+		req = req.WithContext(context.WithValue(req.Context(), model.ValidLogin{}, &model.Claims{UserdID: userID}))
+		if id != "" {
+			req.SetPathValue("id", id)
+		}
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w
+	}
+
+	suite.T().Run("request created and approved", func(t *testing.T) {
+		w := send(requesterID, http.MethodPost, "", `{"fromUser":"oleg","amount":15,"message":"for coffee"}`, h.CreateCoinRequest)
+		assert.Equal(t, http.StatusCreated, w.Code, "unexpected status of request creation")
+
+		w = send(payerID, http.MethodGet, "", "", h.CoinRequests)
+		assert.Equal(t, http.StatusOK, w.Code, "unexpected status of listing requests")
+
+		var requests []model.CoinRequest
+		if err := jsoniter.NewDecoder(w.Body).Decode(&requests); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if assert.Len(t, requests, 1, "unexpected number of pending requests") {
+			w = send(payerID, http.MethodPost, requests[0].ID.String(), "", h.ApproveCoinRequest)
+			assert.Equal(t, http.StatusOK, w.Code, "unexpected status of approval")
+
+			w = send(payerID, http.MethodPost, requests[0].ID.String(), "", h.DeclineCoinRequest)
+			assert.Equal(t, http.StatusConflict, w.Code, "unexpected status of declining an approved request")
+		}
+	})
+
+	suite.T().Run("invalid requests", func(t *testing.T) {
+		for _, body := range []string{`{"amount":15}`, `{"fromUser":"oleg","amount":0}`, `{"fromUser":"irina","amount":15}`, `{`} {
+			w := send(requesterID, http.MethodPost, "", body, h.CreateCoinRequest)
+			assert.Equal(t, http.StatusBadRequest, w.Code, "unexpected status for %s", body)
+		}
+
+		w := send(payerID, http.MethodPost, "abc", "", h.ApproveCoinRequest)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "unexpected status for an invalid request id")
+	})
+}
+
+// тест на пакетный перевод
+func (suite *HandlersTestSuite) TestSendCoinBatch() {
+	ctx := context.Background()
+
+	fromID, err := suite.db.UserAuth(ctx, model.AuthRequest{UserName: "lead", Password: "lead"})
+	assert.NoError(suite.T(), err, "an error occurred during user authorization")
+	for _, login := range []string{"dev1", "dev2"} {
+		_, err := suite.db.UserAuth(ctx, model.AuthRequest{UserName: login, Password: login})
+		assert.NoError(suite.T(), err, "an error occurred during user authorization")
+	}
+
+	send := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/sendCoin/batch", bytes.NewReader([]byte(body)))
+		req = req.WithContext(context.WithValue(req.Context(), model.ValidLogin{}, &model.Claims{UserdID: fromID}))
+		w := httptest.NewRecorder()
+		suite.handlers.SendCoinBatch(w, req)
+		return w
+	}
+
+	suite.T().Run("batch sent successfully", func(t *testing.T) {
+		w := send(`{"transfers":[{"toUser":"dev1","amount":10},{"toUser":"dev2","amount":20,"message":"thanks"}]}`)
+		assert.Equal(t, http.StatusOK, w.Code, "unexpected status of a batch transfer")
+	})
+
+	suite.T().Run("per-recipient results on validation failure", func(t *testing.T) {
+		w := send(`{"transfers":[{"toUser":"dev1","amount":10},{"toUser":"nobody","amount":10},{"toUser":"dev2","amount":0}]}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "unexpected status of an invalid batch")
+
+		var resp model.BatchErrorResponse
+		if err := jsoniter.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		assert.Equal(t, []model.BatchTransferResult{
+			{ToUser: "dev1", Amount: 10},
+			{ToUser: "nobody", Amount: 10},
+			{ToUser: "dev2", Amount: 0, Error: apperr.ErrAmonutIsLessThanOrEqualToZero.Error()},
+		}, resp.Results, "amounts are checked before recipients")
+
+		w = send(`{"transfers":[{"toUser":"dev1","amount":10},{"toUser":"nobody","amount":10}]}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "unexpected status of an invalid batch")
+
+		resp = model.BatchErrorResponse{}
+		if err := jsoniter.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		assert.Equal(t, apperr.ErrBatchValidationFailed.Error(), resp.Errors, "unexpected error message")
+		assert.Equal(t, []model.BatchTransferResult{
+			{ToUser: "dev1", Amount: 10},
+			{ToUser: "nobody", Amount: 10, Error: apperr.ErrRecipientNotFound.Error()},
+		}, resp.Results, "unexpected per-recipient results")
+	})
+
+	suite.T().Run("empty batch", func(t *testing.T) {
+		w := send(`{"transfers":[]}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "unexpected status of an empty batch")
+	})
+}
+
+// тест на лимиты переводов
+func (suite *HandlersTestSuite) TestTransferLimits() {
+	ctx := context.Background()
+
+	fromID, err := suite.db.UserAuth(ctx, model.AuthRequest{UserName: "sergey", Password: "sergey"})
+	assert.NoError(suite.T(), err, "an error occurred during user authorization")
+	_, err = suite.db.UserAuth(ctx, model.AuthRequest{UserName: "elena", Password: "elena"})
+	assert.NoError(suite.T(), err, "an error occurred during user authorization")
+
+	setLimits := func(login, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/admin/users/"+login+"/limits", bytes.NewReader([]byte(body)))
+		req.SetPathValue("login", login)
+		w := httptest.NewRecorder()
+		suite.handlers.SetTransferLimits(w, req)
+		return w
+	}
+
+	suite.T().Run("limit exceeded with remaining allowance", func(t *testing.T) {
+		w := setLimits("sergey", `{"perTransfer":30}`)
+		assert.Equal(t, http.StatusOK, w.Code, "unexpected status of setting limits")
+		assert.JSONEq(t, `{"perTransfer":30,"daily":0}`, w.Body.String(), "unexpected limits")
+
+		jsonData, _ := jsoniter.Marshal(model.SendCoinRequest{ToUser: "elena", Amount: 40})
+		req := httptest.NewRequest(http.MethodPost, "/api/sendCoin", bytes.NewReader(jsonData))
+		req = req.WithContext(context.WithValue(req.Context(), model.ValidLogin{}, &model.Claims{UserdID: fromID}))
+		w = httptest.NewRecorder()
+
+		suite.handlers.SendCoin(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code, "unexpected status when the limit is exceeded")
+		assert.JSONEq(t, `{"errors":"transfer limit exceeded","remaining":30}`, w.Body.String(), "unexpected error body")
+	})
+
+	suite.T().Run("invalid limits", func(t *testing.T) {
+		w := setLimits("sergey", `{"daily":-1}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "unexpected status of a negative limit")
+
+		w = setLimits("nobody", `{"daily":10}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "unexpected status of limits of a non-existent user")
+	})
+}
+
+// тест на рейтинг пользователей
+func (suite *HandlersTestSuite) TestLeaderboard() {
+	ctx := context.Background()
+
+	fromID, err := suite.db.UserAuth(ctx, model.AuthRequest{UserName: "nikita", Password: "nikita"})
+	assert.NoError(suite.T(), err, "an error occurred during user authorization")
+	toID, err := suite.db.UserAuth(ctx, model.AuthRequest{UserName: "vera", Password: "vera"})
+	assert.NoError(suite.T(), err, "an error occurred during user authorization")
+
+	assert.NoError(suite.T(), suite.db.SendCoin(ctx, fromID, model.SendCoinRequest{ToUser: "vera", Amount: 70}))
+
+	get := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/leaderboard"+query, nil)
+		req = req.WithContext(context.WithValue(req.Context(), model.ValidLogin{}, &model.Claims{UserdID: fromID}))
+		w := httptest.NewRecorder()
+		suite.handlers.Leaderboard(w, req)
+		return w
+	}
+
+	receives := func(t *testing.T, login string) bool {
+		w := get("?window=all&limit=100")
+		if !assert.Equal(t, http.StatusOK, w.Code, "unexpected status") {
+			return false
+		}
+
+		var board model.Leaderboard
+		if err := jsoniter.NewDecoder(w.Body).Decode(&board); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		assert.Equal(t, model.LeaderboardAll, board.Window, "unexpected window")
+
+		for _, e := range board.Receivers {
+			if e.Login == login {
+				return true
+			}
+		}
+		return false
+	}
+
+	suite.T().Run("receiver in the leaderboard", func(t *testing.T) {
+		assert.True(t, receives(t, "vera"), "receiver must be in the leaderboard")
+	})
+
+	suite.T().Run("opt-out", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, "/api/leaderboard/visibility", bytes.NewReader([]byte(`{"hidden":true}`)))
+		req = req.WithContext(context.WithValue(req.Context(), model.ValidLogin{}, &model.Claims{UserdID: toID}))
+		w := httptest.NewRecorder()
+		suite.handlers.SetLeaderboardVisibility(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code, "unexpected status of hiding a user")
+		assert.False(t, receives(t, "vera"), "hidden user must be excluded")
+	})
+
+	suite.T().Run("invalid query parameters", func(t *testing.T) {
+		for _, query := range []string{"?window=year", "?limit=0", "?limit=abc", "?limit=1000"} {
+			w := get(query)
+			assert.Equal(t, http.StatusBadRequest, w.Code, "unexpected status for %s", query)
+		}
+	})
+}
+
+// тест на передачу мерча другому пользователю
+func (suite *HandlersTestSuite) TestTransferItems() {
+	ctx := context.Background()
+
+	fromID, err := suite.db.UserAuth(ctx, model.AuthRequest{UserName: "timur", Password: "timur"})
+	assert.NoError(suite.T(), err, "an error occurred during user authorization")
+	_, err = suite.db.UserAuth(ctx, model.AuthRequest{UserName: "alina", Password: "alina"})
+	assert.NoError(suite.T(), err, "an error occurred during user authorization")
+
+	assert.NoError(suite.T(), suite.db.BuyItem(ctx, fromID, itemName, ""), "an error occurred while buying an item")
+
+	send := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/inventory/transfers", bytes.NewReader([]byte(body)))
+		req = req.WithContext(context.WithValue(req.Context(), model.ValidLogin{}, &model.Claims{UserdID: fromID}))
+		w := httptest.NewRecorder()
+		suite.handlers.TransferItems(w, req)
+		return w
+	}
+
+	suite.T().Run("items handed over", func(t *testing.T) {
+		w := send(`{"toUser":"alina","item":"` + itemName + `","quantity":1}`)
+		assert.Equal(t, http.StatusCreated, w.Code, "unexpected status of a handover")
+
+		req := httptest.NewRequest(http.MethodGet, "/api/inventory/transfers", nil)
+		req = req.WithContext(context.WithValue(req.Context(), model.ValidLogin{}, &model.Claims{UserdID: fromID}))
+		w = httptest.NewRecorder()
+		suite.handlers.ItemTransfers(w, req)
+		assert.Equal(t, http.StatusOK, w.Code, "unexpected status of the handover history")
+
+		var transfers []model.ItemTransfer
+		if err := jsoniter.NewDecoder(w.Body).Decode(&transfers); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if assert.Len(t, transfers, 1, "unexpected number of handovers") {
+			assert.Equal(t, "alina", transfers[0].ToUser, "unexpected new owner")
+		}
+	})
+
+	suite.T().Run("invalid handovers", func(t *testing.T) {
+		for _, body := range []string{
+			`{"toUser":"alina","item":"` + itemName + `","quantity":1}`,
+			`{"toUser":"alina","item":"` + itemName + `","quantity":0}`,
+			`{"item":"` + itemName + `","quantity":1}`,
+			`{"toUser":"alina","quantity":1}`,
+			`{"toUser":`,
+		} {
+			w := send(body)
+			assert.Equal(t, http.StatusBadRequest, w.Code, "unexpected status for %s", body)
+		}
+	})
+}
+
+// тест на список желаний
+func (suite *HandlersTestSuite) TestWishlist() {
+	ctx := context.Background()
+
+	userID, err := suite.db.UserAuth(ctx, model.AuthRequest{UserName: "roman", Password: "roman"})
+	assert.NoError(suite.T(), err, "an error occurred during user authorization")
+
+	send := func(method, path, name, body string, handler http.HandlerFunc) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		req = req.WithContext(context.WithValue(req.Context(), model.ValidLogin{}, &model.Claims{UserdID: userID}))
+		if name != "" {
+			req.SetPathValue("name", name)
+		}
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w
+	}
+
+	decode := func(t *testing.T, w *httptest.ResponseRecorder) model.Wishlist {
+		var wishlist model.Wishlist
+		if err := jsoniter.NewDecoder(w.Body).Decode(&wishlist); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return wishlist
+	}
+
+	suite.T().Run("item added", func(t *testing.T) {
+		w := send(http.MethodPost, "/api/wishlist", "", `{"name":"`+itemName+`"}`, suite.handlers.AddWishlistItem)
+		assert.Equal(t, http.StatusOK, w.Code, "unexpected status of adding an item")
+
+		w = send(http.MethodGet, "/api/wishlist", "", "", suite.handlers.Wishlist)
+		assert.Equal(t, http.StatusOK, w.Code, "unexpected status of the wishlist")
+		wishlist := decode(t, w)
+		if assert.Len(t, wishlist.Items, 1, "unexpected number of items") {
+			assert.Equal(t, itemName, wishlist.Items[0].Name, "unexpected item")
+		}
+	})
+
+	suite.T().Run("item removed", func(t *testing.T) {
+		w := send(http.MethodDelete, "/api/wishlist/"+itemName, itemName, "", suite.handlers.RemoveWishlistItem)
+		assert.Equal(t, http.StatusOK, w.Code, "unexpected status of removing an item")
+		assert.Empty(t, decode(t, w).Items, "wishlist must be empty")
+	})
+
+	suite.T().Run("invalid items", func(t *testing.T) {
+		for _, body := range []string{`{"name":""}`, `{"name":"hummer"}`, `{"name":`} {
+			w := send(http.MethodPost, "/api/wishlist", "", body, suite.handlers.AddWishlistItem)
+			assert.Equal(t, http.StatusBadRequest, w.Code, "unexpected status for %s", body)
+		}
+	})
+
+	suite.T().Run("notifications", func(t *testing.T) {
+		w := send(http.MethodGet, "/api/notifications", "", "", suite.handlers.Notifications)
+		assert.Equal(t, http.StatusOK, w.Code, "unexpected status of notifications")
+		assert.JSONEq(t, `[]`, w.Body.String(), "unexpected notifications")
+
+		w = send(http.MethodPost, "/api/notifications/read", "", "", suite.handlers.ReadNotifications)
+		assert.Equal(t, http.StatusNoContent, w.Code, "unexpected status of reading notifications")
+	})
+}
+
+// тест на промокоды
+func (suite *HandlersTestSuite) TestPromoCodes() {
+	ctx := context.Background()
+
+	userID, err := suite.db.UserAuth(ctx, model.AuthRequest{UserName: "egor", Password: "egor"})
+	assert.NoError(suite.T(), err, "an error occurred during user authorization")
+
+	send := func(method, path, body string, handler http.HandlerFunc) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		req = req.WithContext(context.WithValue(req.Context(), model.ValidLogin{}, &model.Claims{UserdID: userID}))
+		req.SetPathValue("item", itemName)
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w
+	}
+
+	suite.T().Run("promo code created", func(t *testing.T) {
+		w := send(http.MethodPost, "/api/admin/promo", `{"code":"SPRING","type":"percent","value":10,"item":"`+itemName+`"}`, suite.handlers.CreatePromoCode)
+		assert.Equal(t, http.StatusCreated, w.Code, "unexpected status of promo code creation")
+
+		w = send(http.MethodPost, "/api/admin/promo", `{"code":"SPRING","type":"fixed","value":1}`, suite.handlers.CreatePromoCode)
+		assert.Equal(t, http.StatusConflict, w.Code, "unexpected status for a duplicate promo code")
+	})
+
+	suite.T().Run("invalid promo codes", func(t *testing.T) {
+		for _, body := range []string{
+			`{"code":"","type":"fixed","value":1}`,
+			`{"code":"BAD","type":"bogus","value":1}`,
+			`{"code":"BAD","type":"percent","value":100}`,
+			`{"code":"BAD","type":"fixed","value":0}`,
+			`{"code":"BAD","type":"fixed","value":1,"item":"pen","category":"stationery"}`,
+			`{"code":"BAD","type":"fixed","value":1,"validFrom":"2026-02-01T00:00:00Z","validTo":"2026-01-01T00:00:00Z"}`,
+			`{`,
+		} {
+			w := send(http.MethodPost, "/api/admin/promo", body, suite.handlers.CreatePromoCode)
+			assert.Equal(t, http.StatusBadRequest, w.Code, "unexpected status for %s", body)
+		}
+	})
+
+	suite.T().Run("discounted purchase and order", func(t *testing.T) {
+		w := send(http.MethodPost, "/api/buy/"+itemName+"?promo=SPRING", "", suite.handlers.Buy)
+		assert.Equal(t, http.StatusOK, w.Code, "unexpected status of a purchase with a promo code")
+
+		w = send(http.MethodPost, "/api/orders", `{"items":[{"name":"`+itemName+`","quantity":2}],"promo":"SPRING"}`, suite.handlers.PlaceOrder)
+		assert.Equal(t, http.StatusCreated, w.Code, "unexpected status of an order with a promo code")
+
+		var order model.Order
+		if err := jsoniter.NewDecoder(w.Body).Decode(&order); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		assert.Equal(t, int64(2*(itemPrice-1)), order.Total, "unexpected discounted order total")
+
+		info, err := suite.db.Info(ctx, userID)
+		assert.NoError(t, err, "an error occurred while getting information about a user")
+		assert.Equal(t, int64(1000-3*(itemPrice-1)), info.Coins, "unexpected balance after discounted purchases")
+
+		w = send(http.MethodPost, "/api/buy/"+itemName+"?promo=WINTER", "", suite.handlers.Buy)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "unexpected status for an unknown promo code")
+	})
+
+	suite.T().Run("promo codes listed", func(t *testing.T) {
+		w := send(http.MethodGet, "/api/admin/promo", "", suite.handlers.PromoCodes)
+		assert.Equal(t, http.StatusOK, w.Code, "unexpected status of promo codes")
+
+		var promos []model.PromoCode
+		if err := jsoniter.NewDecoder(w.Body).Decode(&promos); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if assert.Len(t, promos, 1, "unexpected number of promo codes") {
+			assert.Equal(t, 2, promos[0].Used, "unexpected number of uses")
+		}
+	})
+}
+
+// тест на историю покупок и цен
+func (suite *HandlersTestSuite) TestPurchases() {
+	ctx := context.Background()
+
+	userID, err := suite.db.UserAuth(ctx, model.AuthRequest{UserName: "zhanna", Password: "zhanna"})
+	assert.NoError(suite.T(), err, "an error occurred during user authorization")
+	assert.NoError(suite.T(), suite.db.BuyItem(ctx, userID, itemName, ""), "an error occurred while buying an item")
+
+	suite.T().Run("purchases with the paid price", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/purchases", nil)
+		req = req.WithContext(context.WithValue(req.Context(), model.ValidLogin{}, &model.Claims{UserdID: userID}))
+		w := httptest.NewRecorder()
+
+		suite.handlers.Purchases(w, req)
+		assert.Equal(t, http.StatusOK, w.Code, "unexpected status of purchases")
+
+		var purchases []model.Purchase
+		if err := jsoniter.NewDecoder(w.Body).Decode(&purchases); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if assert.Len(t, purchases, 1, "unexpected number of purchases") {
+			assert.Equal(t, itemName, purchases[0].Item, "unexpected item")
+			assert.Equal(t, int64(itemPrice), purchases[0].PricePaid, "unexpected paid price")
+		}
+	})
+
+	suite.T().Run("price history", func(t *testing.T) {
+		prices := func(name string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodGet, "/api/merch/"+name+"/prices", nil)
+			req.SetPathValue("name", name)
+			w := httptest.NewRecorder()
+			suite.handlers.MerchPrices(w, req)
+			return w
+		}
+
+		w := prices(itemName)
+		assert.Equal(t, http.StatusOK, w.Code, "unexpected status of the price history")
+
+		var got []model.PricePoint
+		if err := jsoniter.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if assert.NotEmpty(t, got, "price history must not be empty") {
+			assert.Equal(t, int64(itemPrice), got[len(got)-1].Price, "unexpected current price")
+		}
+
+		assert.Equal(t, http.StatusBadRequest, prices("hummer").Code, "unexpected status for a non-existent item")
+	})
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+
+	jsoniter "github.com/json-iterator/go"
+
+	"github.com/plasmatrip/avito_merch/internal/apperr"
+	"github.com/plasmatrip/avito_merch/internal/model"
+)
+
+// TransferItems передает купленный мерч из инвентаря пользователя другому пользователю
+func (h *Handlers) TransferItems(w http.ResponseWriter, r *http.Request) {
+	var req model.ItemTransferRequest
+
+	if err := jsoniter.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", err)
+		SendErrors(w, apperr.ErrBadJSON)
+		return
+	}
+
+	if len(req.ToUser) == 0 {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", apperr.ErrRecipientNotFound)
+		SendErrors(w, apperr.ErrRecipientNotFound)
+		return
+	}
+
+	if len(req.Item) == 0 {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", apperr.ErrMecrhNameIsEmpty)
+		SendErrors(w, apperr.ErrMecrhNameIsEmpty)
+		return
+	}
+
+	if req.Quantity <= 0 || req.Quantity > maxOrderQuantity {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", apperr.ErrInvalidQuantity)
+		SendErrors(w, apperr.ErrInvalidQuantity)
+		return
+	}
+
+	userID := r.Context().Value(model.ValidLogin{}).(*model.Claims).UserdID
+
+	transfer, err := h.Stor.TransferItems(r.Context(), userID, req)
+	if err != nil {
+		SendErrors(w, err)
+		h.Logger.Sugar.Infow("internal error", "error: ", err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusCreated, transfer)
+}
+
+// ItemTransfers возвращает историю передач мерча пользователя
+func (h *Handlers) ItemTransfers(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.ValidLogin{}).(*model.Claims).UserdID
+
+	transfers, err := h.Stor.ItemTransfers(r.Context(), userID)
+	if err != nil {
+		SendErrors(w, err)
+		h.Logger.Sugar.Infow("internal error", "error: ", err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, transfers)
+}
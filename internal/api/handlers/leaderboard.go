@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+
+	"github.com/plasmatrip/avito_merch/internal/apperr"
+	"github.com/plasmatrip/avito_merch/internal/model"
+)
+
+// размер рейтинга
+const (
+	defaultLeaderboardLimit = 10
+	maxLeaderboardLimit     = 100
+)
+
+// продолжительность периодов рейтинга, отсчитывается от текущего момента
+var leaderboardWindows = map[string]time.Duration{
+	model.LeaderboardWeek:  7 * 24 * time.Hour,
+	model.LeaderboardMonth: 30 * 24 * time.Hour,
+	model.LeaderboardAll:   0,
+}
+
+// Leaderboard возвращает рейтинги пользователей по полученным, отправленным и потраченным на мерч монетам
+func (h *Handlers) Leaderboard(w http.ResponseWriter, r *http.Request) {
+	window, filter, err := parseLeaderboardFilter(r.URL.Query(), time.Now())
+	if err != nil {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", err)
+		SendErrors(w, apperr.ErrInvalidQueryParameter)
+		return
+	}
+
+	board, err := h.Stor.Leaderboard(r.Context(), filter)
+	if err != nil {
+		SendErrors(w, err)
+		h.Logger.Sugar.Infow("internal error", "error: ", err)
+		return
+	}
+	board.Window = window
+
+	h.sendJSON(w, http.StatusOK, board)
+}
+
+// SetLeaderboardVisibility скрывает пользователя из рейтинга или возвращает его в рейтинг
+func (h *Handlers) SetLeaderboardVisibility(w http.ResponseWriter, r *http.Request) {
+	var req model.LeaderboardVisibilityRequest
+
+	if err := jsoniter.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", err)
+		SendErrors(w, apperr.ErrBadJSON)
+		return
+	}
+
+	userID := r.Context().Value(model.ValidLogin{}).(*model.Claims).UserdID
+
+	if err := h.Stor.SetLeaderboardHidden(r.Context(), userID, req.Hidden); err != nil {
+		SendErrors(w, err)
+		h.Logger.Sugar.Infow("internal error", "error: ", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseLeaderboardFilter разбирает параметры запроса рейтинга: window (week, month или all) и limit
+func parseLeaderboardFilter(q url.Values, now time.Time) (string, model.LeaderboardFilter, error) {
+	filter := model.LeaderboardFilter{Limit: defaultLeaderboardLimit}
+
+	window := q.Get("window")
+	if window == "" {
+		window = model.LeaderboardWeek
+	}
+
+	d, ok := leaderboardWindows[window]
+	if !ok {
+		return window, filter, apperr.ErrInvalidQueryParameter
+	}
+	if d > 0 {
+		filter.Since = now.Add(-d)
+	}
+
+	if v := q.Get("limit"); v != "" {
+		var err error
+		filter.Limit, err = strconv.Atoi(v)
+		if err != nil {
+			return window, filter, err
+		}
+		if filter.Limit <= 0 || filter.Limit > maxLeaderboardLimit {
+			return window, filter, apperr.ErrInvalidQueryParameter
+		}
+	}
+
+	return window, filter, nil
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/plasmatrip/avito_merch/internal/apperr"
+	"github.com/plasmatrip/avito_merch/internal/model"
+)
+
+func (h *Handlers) Merch(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseMerchFilter(r.URL.Query())
+	if err != nil {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", err)
+		SendErrors(w, apperr.ErrInvalidQueryParameter)
+		return
+	}
+
+	items, err := h.Stor.Merch(r.Context(), filter)
+	if err != nil {
+		SendErrors(w, err)
+		h.Logger.Sugar.Infow("internal error", "error: ", err)
+		return
+	}
+
+	h.sendWithETag(w, r, items)
+}
+
+func (h *Handlers) MerchItem(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if len(name) == 0 {
+		h.Logger.Sugar.Infoln("Merch name is empty")
+		SendErrors(w, apperr.ErrMecrhNameIsEmpty)
+		return
+	}
+
+	item, err := h.Stor.MerchItem(r.Context(), name)
+	if err != nil {
+		SendErrors(w, err)
+		h.Logger.Sugar.Infow("internal error", "error: ", err)
+		return
+	}
+
+	h.sendWithETag(w, r, item)
+}
+
+// MerchPrices возвращает историю цен товара каталога от старых цен к новым
+func (h *Handlers) MerchPrices(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if len(name) == 0 {
+		h.Logger.Sugar.Infoln("Merch name is empty")
+		SendErrors(w, apperr.ErrMecrhNameIsEmpty)
+		return
+	}
+
+	prices, err := h.Stor.PriceHistory(r.Context(), name)
+	if err != nil {
+		SendErrors(w, err)
+		h.Logger.Sugar.Infow("internal error", "error: ", err)
+		return
+	}
+
+	h.sendWithETag(w, r, prices)
+}
+
+// sendWithETag отправляет ответ с заголовком ETag, если клиент прислал
+// совпадающий If-None-Match, отвечает 304 без тела
+func (h *Handlers) sendWithETag(w http.ResponseWriter, r *http.Request, v any) {
+	var body bytes.Buffer
+	if err := jsoniter.NewEncoder(&body).Encode(v); err != nil {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", err)
+		SendErrors(w, err)
+		return
+	}
+
+	hash := sha256.Sum256(body.Bytes())
+	etag := `"` + hex.EncodeToString(hash[:]) + `"`
+
+	w.Header().Set("ETag", etag)
+	if matchETag(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(body.Bytes())
+}
+
+// matchETag проверяет, содержит ли значение If-None-Match указанный ETag
+func matchETag(header, etag string) bool {
+	for _, v := range strings.Split(header, ",") {
+		v = strings.TrimPrefix(strings.TrimSpace(v), "W/")
+		if v == "*" || v == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// parseMerchFilter разбирает параметры запроса каталога:
+// sort (name или price), order (asc или desc), minPrice и maxPrice
+func parseMerchFilter(q url.Values) (model.MerchFilter, error) {
+	filter := model.MerchFilter{
+		Sort: model.MerchSortName,
+	}
+
+	var err error
+
+	switch v := q.Get("sort"); v {
+	case "":
+	case model.MerchSortName, model.MerchSortPrice:
+		filter.Sort = v
+	default:
+		return filter, apperr.ErrInvalidQueryParameter
+	}
+
+	switch q.Get("order") {
+	case "", "asc":
+	case "desc":
+		filter.Desc = true
+	default:
+		return filter, apperr.ErrInvalidQueryParameter
+	}
+
+	if v := q.Get("minPrice"); v != "" {
+		filter.MinPrice, err = strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return filter, err
+		}
+		if filter.MinPrice < 0 {
+			return filter, apperr.ErrInvalidQueryParameter
+		}
+	}
+
+	if v := q.Get("maxPrice"); v != "" {
+		filter.MaxPrice, err = strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return filter, err
+		}
+		if filter.MaxPrice <= 0 || filter.MaxPrice < filter.MinPrice {
+			return filter, apperr.ErrInvalidQueryParameter
+		}
+	}
+
+	return filter, nil
+}
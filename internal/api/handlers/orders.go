@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/plasmatrip/avito_merch/internal/apperr"
+	"github.com/plasmatrip/avito_merch/internal/model"
+)
+
+// максимальное количество единиц одной позиции в заказе
+const maxOrderQuantity = 100
+
+func (h *Handlers) PlaceOrder(w http.ResponseWriter, r *http.Request) {
+	var req model.OrderRequest
+
+	if err := jsoniter.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", err)
+		SendErrors(w, apperr.ErrBadJSON)
+		return
+	}
+
+	items, err := mergeOrderItems(req.Items)
+	if err != nil {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", err)
+		SendErrors(w, err)
+		return
+	}
+
+	userID := r.Context().Value(model.ValidLogin{}).(*model.Claims).UserdID
+
+	order, err := h.Stor.PlaceOrder(r.Context(), userID, items, req.Promo)
+	if err != nil {
+		SendErrors(w, err)
+		h.Logger.Sugar.Infow("internal error", "error: ", err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusCreated, order)
+}
+
+// mergeOrderItems проверяет позиции заказа и объединяет позиции с одинаковым названием
+func mergeOrderItems(items []model.OrderItem) ([]model.OrderItem, error) {
+	if len(items) == 0 {
+		return nil, apperr.ErrEmptyOrder
+	}
+
+	merged := make([]model.OrderItem, 0, len(items))
+	index := make(map[string]int, len(items))
+	for _, item := range items {
+		if len(item.Name) == 0 {
+			return nil, apperr.ErrMecrhNameIsEmpty
+		}
+
+		if item.Quantity <= 0 || item.Quantity > maxOrderQuantity {
+			return nil, apperr.ErrInvalidQuantity
+		}
+
+		i, ok := index[item.Name]
+		if !ok {
+			index[item.Name] = len(merged)
+			merged = append(merged, item)
+			continue
+		}
+
+		merged[i].Quantity += item.Quantity
+		if merged[i].Quantity > maxOrderQuantity {
+			return nil, apperr.ErrInvalidQuantity
+		}
+	}
+
+	return merged, nil
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/plasmatrip/avito_merch/internal/apperr"
+	"github.com/plasmatrip/avito_merch/internal/model"
+)
+
+// ограничение на длину промокода, как в таблице promo_codes
+const maxPromoCodeLength = 32
+
+// CreatePromoCode добавляет промокод на скидку
+func (h *Handlers) CreatePromoCode(w http.ResponseWriter, r *http.Request) {
+	var req model.PromoCode
+
+	if err := jsoniter.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", err)
+		SendErrors(w, apperr.ErrBadJSON)
+		return
+	}
+
+	if err := validatePromoCode(req); err != nil {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", err)
+		SendErrors(w, err)
+		return
+	}
+
+	created, err := h.Stor.CreatePromoCode(r.Context(), req)
+	if err != nil {
+		SendErrors(w, err)
+		h.Logger.Sugar.Infow("internal error", "error: ", err)
+		return
+	}
+
+	h.Logger.Sugar.Infow("promo code created", "admin", adminLogin(r), "code", created.Code,
+		"type", created.Type, "value", created.Value)
+	h.sendJSON(w, http.StatusCreated, created)
+}
+
+// PromoCodes возвращает промокоды с количеством использований
+func (h *Handlers) PromoCodes(w http.ResponseWriter, r *http.Request) {
+	promos, err := h.Stor.PromoCodes(r.Context())
+	if err != nil {
+		SendErrors(w, err)
+		h.Logger.Sugar.Infow("internal error", "error: ", err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, promos)
+}
+
+// validatePromoCode проверяет условия промокода: скидка в процентах меньше 100,
+// промокод ограничен мерчем или категорией, но не обоими сразу
+func validatePromoCode(promo model.PromoCode) error {
+	switch {
+	case len(promo.Code) == 0 || utf8.RuneCountInString(promo.Code) > maxPromoCodeLength || strings.TrimSpace(promo.Code) != promo.Code:
+		return apperr.ErrInvalidPromo
+	case promo.Type != model.PromoPercent && promo.Type != model.PromoFixed:
+		return apperr.ErrInvalidPromo
+	case promo.Value <= 0 || (promo.Type == model.PromoPercent && promo.Value >= 100):
+		return apperr.ErrInvalidPromo
+	case promo.Item != "" && promo.Category != "":
+		return apperr.ErrInvalidPromo
+	case utf8.RuneCountInString(promo.Category) > maxCategoryLength:
+		return apperr.ErrInvalidCategory
+	case promo.PerUserLimit < 0 || promo.TotalLimit < 0:
+		return apperr.ErrInvalidPromo
+	case !promo.ValidFrom.IsZero() && !promo.ValidTo.IsZero() && !promo.ValidFrom.Before(promo.ValidTo):
+		return apperr.ErrInvalidPromo
+	}
+	return nil
+}
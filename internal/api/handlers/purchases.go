@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/plasmatrip/avito_merch/internal/model"
+)
+
+// количество последних покупок в ответе
+const purchasesLimit = 100
+
+// Purchases возвращает последние покупки пользователя с уплаченной ценой
+func (h *Handlers) Purchases(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.ValidLogin{}).(*model.Claims).UserdID
+
+	purchases, err := h.Stor.Purchases(r.Context(), userID, purchasesLimit)
+	if err != nil {
+		SendErrors(w, err)
+		h.Logger.Sugar.Infow("internal error", "error: ", err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, purchases)
+}
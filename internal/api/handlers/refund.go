@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gofrs/uuid"
+	"github.com/plasmatrip/avito_merch/internal/apperr"
+	"github.com/plasmatrip/avito_merch/internal/model"
+)
+
+func (h *Handlers) Refund(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.ValidLogin{}).(*model.Claims).UserdID
+
+	purchaseID, err := uuid.FromString(r.PathValue("id"))
+	if err != nil {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", err)
+		SendErrors(w, apperr.ErrPurchaseNotFound)
+		return
+	}
+
+	err = h.Stor.RefundPurchase(r.Context(), userID, purchaseID, h.Config.RefundWindow)
+	if err != nil {
+		SendErrors(w, err)
+		h.Logger.Sugar.Infow("internal error", "error: ", err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"net/http"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/plasmatrip/avito_merch/internal/apperr"
+	"github.com/plasmatrip/avito_merch/internal/model"
+)
+
+// SetUserRole назначает роль пользователю, новая роль попадает в токен при следующем входе
+func (h *Handlers) SetUserRole(w http.ResponseWriter, r *http.Request) {
+	var req model.RoleRequest
+
+	if err := jsoniter.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", err)
+		SendErrors(w, apperr.ErrBadJSON)
+		return
+	}
+
+	if req.Role != model.RoleUser && req.Role != model.RoleAdmin {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", apperr.ErrInvalidRole)
+		SendErrors(w, apperr.ErrInvalidRole)
+		return
+	}
+
+	login := r.PathValue("login")
+	if err := h.Stor.SetUserRole(r.Context(), login, req.Role); err != nil {
+		SendErrors(w, err)
+		h.Logger.Sugar.Infow("internal error", "error: ", err)
+		return
+	}
+
+	h.Logger.Sugar.Infow("user role set", "admin", adminLogin(r), "user", login, "role", req.Role)
+	h.sendJSON(w, http.StatusOK, model.UserRole{Login: login, Role: req.Role})
+}
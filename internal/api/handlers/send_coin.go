@@ -1,13 +1,18 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"unicode/utf8"
 
 	jsoniter "github.com/json-iterator/go"
 	"github.com/plasmatrip/avito_merch/internal/apperr"
 	"github.com/plasmatrip/avito_merch/internal/model"
 )
 
+// ограничение на длину сообщения перевода, как в таблице transactions
+const maxTransferMessageLength = 255
+
 func (h *Handlers) SendCoin(w http.ResponseWriter, r *http.Request) {
 	var sc model.SendCoinRequest
 
@@ -29,6 +34,12 @@ func (h *Handlers) SendCoin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if utf8.RuneCountInString(sc.Message) > maxTransferMessageLength {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", apperr.ErrMessageTooLong)
+		SendErrors(w, apperr.ErrMessageTooLong)
+		return
+	}
+
 	userID := r.Context().Value(model.ValidLogin{}).(*model.Claims).UserdID
 
 	err := h.Stor.SendCoin(r.Context(), userID, sc)
@@ -39,3 +50,77 @@ func (h *Handlers) SendCoin(w http.ResponseWriter, r *http.Request) {
 	}
 	w.WriteHeader(http.StatusOK)
 }
+
+// максимальное количество переводов в пакете
+const maxBatchTransfers = 100
+
+// SendCoinBatch переводит монеты нескольким пользователям одной транзакцией
+func (h *Handlers) SendCoinBatch(w http.ResponseWriter, r *http.Request) {
+	var req model.BatchSendCoinRequest
+
+	if err := jsoniter.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", err)
+		SendErrors(w, apperr.ErrBadJSON)
+		return
+	}
+
+	if len(req.Transfers) == 0 || len(req.Transfers) > maxBatchTransfers {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", apperr.ErrEmptyBatch)
+		SendErrors(w, apperr.ErrEmptyBatch)
+		return
+	}
+
+	// проверяем каждый перевод, чтобы вернуть все ошибки сразу
+	errs := make([]error, len(req.Transfers))
+	failed := false
+	for i, t := range req.Transfers {
+		switch {
+		case len(t.ToUser) == 0:
+			errs[i] = apperr.ErrRecipientNotFound
+		case t.Amount <= 0:
+			errs[i] = apperr.ErrAmonutIsLessThanOrEqualToZero
+		case utf8.RuneCountInString(t.Message) > maxTransferMessageLength:
+			errs[i] = apperr.ErrMessageTooLong
+		}
+		failed = failed || errs[i] != nil
+	}
+
+	var err error
+	if failed {
+		err = &apperr.BatchValidationError{Errors: errs}
+	} else {
+		userID := r.Context().Value(model.ValidLogin{}).(*model.Claims).UserdID
+		err = h.Stor.SendCoinBatch(r.Context(), userID, req.Transfers)
+	}
+
+	var batchErr *apperr.BatchValidationError
+	if errors.As(err, &batchErr) {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", err)
+		h.sendBatchErrors(w, req.Transfers, batchErr)
+		return
+	}
+
+	if err != nil {
+		SendErrors(w, err)
+		h.Logger.Sugar.Infow("internal error", "error: ", err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// sendBatchErrors отправляет ошибку пакетного перевода с результатом проверки каждого перевода
+func (h *Handlers) sendBatchErrors(w http.ResponseWriter, transfers []model.SendCoinRequest, batchErr *apperr.BatchValidationError) {
+	resp := model.BatchErrorResponse{
+		Errors:  apperr.ErrBatchValidationFailed.Error(),
+		Results: make([]model.BatchTransferResult, len(transfers)),
+	}
+
+	for i, t := range transfers {
+		resp.Results[i] = model.BatchTransferResult{ToUser: t.ToUser, Amount: t.Amount}
+		if i < len(batchErr.Errors) && batchErr.Errors[i] != nil {
+			resp.Results[i].Error = apperr.ErrorMessages[knownError(batchErr.Errors[i])]
+		}
+	}
+
+	h.sendJSON(w, apperr.ErrorStatuses[apperr.ErrBatchValidationFailed], resp)
+}
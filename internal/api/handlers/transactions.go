@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/plasmatrip/avito_merch/internal/apperr"
+	"github.com/plasmatrip/avito_merch/internal/model"
+)
+
+// размер страницы истории операций
+const (
+	defaultHistoryLimit = 20
+	maxHistoryLimit     = 100
+)
+
+func (h *Handlers) Transactions(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.ValidLogin{}).(*model.Claims).UserdID
+
+	filter, err := parseHistoryFilter(r.URL.Query())
+	if err != nil {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", err)
+		SendErrors(w, apperr.ErrInvalidQueryParameter)
+		return
+	}
+
+	page, err := h.Stor.TransactionHistory(r.Context(), userID, filter)
+	if err != nil {
+		SendErrors(w, err)
+		h.Logger.Sugar.Infow("internal error", "error: ", err)
+		return
+	}
+
+	resp := model.HistoryResponse{Transactions: page.Entries}
+	if page.Next != 0 {
+		resp.NextCursor = encodeCursor(page.Next)
+	}
+
+	h.sendJSON(w, http.StatusOK, resp)
+}
+
+// parseHistoryFilter разбирает параметры запроса истории операций:
+// limit, cursor, direction (in или out), counterpart, from и to в формате RFC 3339
+func parseHistoryFilter(q url.Values) (model.HistoryFilter, error) {
+	filter := model.HistoryFilter{
+		Limit:       defaultHistoryLimit,
+		Direction:   q.Get("direction"),
+		Counterpart: q.Get("counterpart"),
+	}
+
+	var err error
+
+	if v := q.Get("limit"); v != "" {
+		filter.Limit, err = strconv.Atoi(v)
+		if err != nil {
+			return filter, err
+		}
+		if filter.Limit <= 0 || filter.Limit > maxHistoryLimit {
+			return filter, apperr.ErrInvalidQueryParameter
+		}
+	}
+
+	if v := q.Get("cursor"); v != "" {
+		filter.Before, err = decodeCursor(v)
+		if err != nil {
+			return filter, err
+		}
+	}
+
+	if filter.Direction != "" && filter.Direction != model.DirectionIn && filter.Direction != model.DirectionOut {
+		return filter, apperr.ErrInvalidQueryParameter
+	}
+
+	if v := q.Get("from"); v != "" {
+		filter.From, err = time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, err
+		}
+	}
+
+	if v := q.Get("to"); v != "" {
+		filter.To, err = time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, err
+		}
+	}
+
+	return filter, nil
+}
+
+// encodeCursor кодирует порядковый номер записи в непрозрачный курсор
+func encodeCursor(seq int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(seq, 10)))
+}
+
+// decodeCursor извлекает порядковый номер записи из курсора
+func decodeCursor(cursor string) (int64, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+
+	seq, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if seq <= 0 {
+		return 0, apperr.ErrInvalidQueryParameter
+	}
+
+	return seq, nil
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"net/http"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/plasmatrip/avito_merch/internal/apperr"
+	"github.com/plasmatrip/avito_merch/internal/model"
+)
+
+// количество последних уведомлений в ответе
+const notificationsLimit = 100
+
+// Wishlist возвращает список желаний с недостающими монетами
+func (h *Handlers) Wishlist(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.ValidLogin{}).(*model.Claims).UserdID
+
+	wishlist, err := h.Stor.Wishlist(r.Context(), userID)
+	if err != nil {
+		SendErrors(w, err)
+		h.Logger.Sugar.Infow("internal error", "error: ", err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, wishlist)
+}
+
+// AddWishlistItem добавляет мерч в список желаний
+func (h *Handlers) AddWishlistItem(w http.ResponseWriter, r *http.Request) {
+	var req model.WishlistRequest
+
+	if err := jsoniter.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Logger.Sugar.Infow("error in request handler", "error: ", err)
+		SendErrors(w, apperr.ErrBadJSON)
+		return
+	}
+
+	if len(req.Name) == 0 {
+		h.Logger.Sugar.Infoln("Merch name is empty")
+		SendErrors(w, apperr.ErrMecrhNameIsEmpty)
+		return
+	}
+
+	userID := r.Context().Value(model.ValidLogin{}).(*model.Claims).UserdID
+
+	wishlist, err := h.Stor.AddWishlistItem(r.Context(), userID, req.Name)
+	if err != nil {
+		SendErrors(w, err)
+		h.Logger.Sugar.Infow("internal error", "error: ", err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, wishlist)
+}
+
+// RemoveWishlistItem убирает мерч из списка желаний
+func (h *Handlers) RemoveWishlistItem(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.ValidLogin{}).(*model.Claims).UserdID
+
+	name := r.PathValue("name")
+	if len(name) == 0 {
+		h.Logger.Sugar.Infoln("Merch name is empty")
+		SendErrors(w, apperr.ErrMecrhNameIsEmpty)
+		return
+	}
+
+	wishlist, err := h.Stor.RemoveWishlistItem(r.Context(), userID, name)
+	if err != nil {
+		SendErrors(w, err)
+		h.Logger.Sugar.Infow("internal error", "error: ", err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, wishlist)
+}
+
+// Notifications возвращает последние уведомления пользователя
+func (h *Handlers) Notifications(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.ValidLogin{}).(*model.Claims).UserdID
+
+	notifications, err := h.Stor.Notifications(r.Context(), userID, notificationsLimit)
+	if err != nil {
+		SendErrors(w, err)
+		h.Logger.Sugar.Infow("internal error", "error: ", err)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, notifications)
+}
+
+// ReadNotifications отмечает все уведомления пользователя прочитанными
+func (h *Handlers) ReadNotifications(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.ValidLogin{}).(*model.Claims).UserdID
+
+	err := h.Stor.ReadNotifications(r.Context(), userID)
+	if err != nil {
+		SendErrors(w, err)
+		h.Logger.Sugar.Infow("internal error", "error: ", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/plasmatrip/avito_merch/internal/api/handlers"
+	"github.com/plasmatrip/avito_merch/internal/apperr"
+	"github.com/plasmatrip/avito_merch/internal/logger"
+	"github.com/plasmatrip/avito_merch/internal/model"
+	"github.com/plasmatrip/avito_merch/internal/storage"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotentReplayedHeader  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	idempotencyRequestTimeout = 5 * time.Second
+)
+
+type idempotencyResponseWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *idempotencyResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *idempotencyResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// WithIdempotency сохраняет ответ на запрос с заголовком Idempotency-Key и возвращает его
+// при повторе запроса с тем же ключом в течение ttl, должен стоять после WithAuthentication
+func WithIdempotency(log logger.Logger, stor storage.Repository, ttl time.Duration) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if len(key) > maxIdempotencyKeyLength {
+				log.Sugar.Infow("invalid idempotency key", "length", len(key))
+				handlers.SendErrors(w, apperr.ErrInvalidIdempotencyKey)
+				return
+			}
+
+			userID := r.Context().Value(model.ValidLogin{}).(*model.Claims).UserdID
+
+			// запрос с тем же ключом должен совпадать по методу, пути с параметрами и телу
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				log.Sugar.Infow("error reading request body", "error", err)
+				handlers.SendErrors(w, apperr.ErrBadJSON)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			hash := sha256.Sum256(body)
+			request := r.Method + " " + r.URL.RequestURI() + " " + hex.EncodeToString(hash[:])
+			if len(request) > maxIdempotencyKeyLength {
+				request = request[len(request)-maxIdempotencyKeyLength:]
+			}
+
+			saved, err := stor.AcquireIdempotencyKey(r.Context(), userID, key, request, ttl)
+			if err != nil {
+				log.Sugar.Infow("idempotency key error", "key", key, "error", err)
+				handlers.SendErrors(w, err)
+				return
+			}
+
+			// запрос уже выполнен, повторяем сохраненный ответ
+			if saved != nil {
+				if saved.ContentType != "" {
+					w.Header().Set("Content-Type", saved.ContentType)
+				}
+				w.Header().Set(IdempotentReplayedHeader, "true")
+				w.WriteHeader(saved.Status)
+				w.Write(saved.Body)
+				return
+			}
+
+			iw := &idempotencyResponseWriter{ResponseWriter: w}
+			next.ServeHTTP(iw, r)
+
+			// ответ сохраняем даже если клиент уже отключился
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), idempotencyRequestTimeout)
+			defer cancel()
+
+			status := iw.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			// после внутренней ошибки запрос можно повторить с тем же ключом
+			if status >= http.StatusInternalServerError {
+				if err := stor.ReleaseIdempotencyKey(ctx, userID, key); err != nil {
+					log.Sugar.Infow("error releasing idempotency key", "key", key, "error", err)
+				}
+				return
+			}
+
+			err = stor.SaveIdempotentResponse(ctx, userID, key, model.IdempotentResponse{
+				Status:      status,
+				ContentType: w.Header().Get("Content-Type"),
+				Body:        iw.body.Bytes(),
+			})
+			if err != nil {
+				log.Sugar.Infow("error saving idempotent response", "key", key, "error", err)
+			}
+		}
+		return http.HandlerFunc(fn)
+	}
+}
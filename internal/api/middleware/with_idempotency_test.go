@@ -0,0 +1,90 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/plasmatrip/avito_merch/internal/api/middleware"
+	"github.com/plasmatrip/avito_merch/internal/logger"
+	"github.com/plasmatrip/avito_merch/internal/model"
+	"github.com/plasmatrip/avito_merch/internal/storage/memory"
+)
+
+func TestWithIdempotency(t *testing.T) {
+	log, err := logger.NewLogger(logger.LogLevelDebug)
+	require.NoError(t, err)
+
+	stor := memory.NewRepository(*log)
+	userID := uuid.Must(uuid.NewV4())
+
+	var calls atomic.Int64
+	started, release := make(chan struct{}), make(chan struct{})
+	handler := middleware.WithIdempotency(*log, stor, time.Hour)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.URL.Path == "/slow" {
+			close(started)
+			<-release
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"call":1}`))
+	}))
+
+	request := func(path, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req = req.WithContext(context.WithValue(req.Context(), model.ValidLogin{}, &model.Claims{UserdID: userID}))
+		if key != "" {
+			req.Header.Set(middleware.IdempotencyKeyHeader, key)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("response replayed", func(t *testing.T) {
+		first := request("/api/sendCoin", "retry", `{"toUser":"john","amount":10}`)
+		second := request("/api/sendCoin", "retry", `{"toUser":"john","amount":10}`)
+
+		assert.Equal(t, int64(1), calls.Load(), "handler must be called once")
+		assert.Equal(t, http.StatusCreated, second.Code, "unexpected status of a replayed response")
+		assert.Equal(t, first.Body.String(), second.Body.String(), "unexpected body of a replayed response")
+		assert.Equal(t, "application/json", second.Header().Get("Content-Type"), "unexpected content type of a replayed response")
+		assert.Equal(t, "true", second.Header().Get(middleware.IdempotentReplayedHeader), "replayed response must be marked")
+	})
+
+	t.Run("key reused with another body", func(t *testing.T) {
+		w := request("/api/sendCoin", "retry", `{"toUser":"john","amount":20}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code, "unexpected status when reusing a key")
+	})
+
+	t.Run("requests without key are not deduplicated", func(t *testing.T) {
+		calls.Store(0)
+		request("/api/sendCoin", "", `{}`)
+		request("/api/sendCoin", "", `{}`)
+		assert.Equal(t, int64(2), calls.Load(), "handler must be called for every request without a key")
+	})
+
+	t.Run("concurrent request conflicts", func(t *testing.T) {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			request("/slow", "slow", `{}`)
+		}()
+
+		<-started
+		w := request("/slow", "slow", `{}`)
+		assert.Equal(t, http.StatusConflict, w.Code, "concurrent request with the same key must conflict")
+
+		close(release)
+		<-done
+	})
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/plasmatrip/avito_merch/internal/api/handlers"
+	"github.com/plasmatrip/avito_merch/internal/apperr"
+	"github.com/plasmatrip/avito_merch/internal/logger"
+	"github.com/plasmatrip/avito_merch/internal/model"
+)
+
+// WithRole пропускает только запросы пользователей с указанной ролью, должен стоять после WithAuthentication
+func WithRole(log logger.Logger, role string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			claims := r.Context().Value(model.ValidLogin{}).(*model.Claims)
+
+			if claims.Role != role {
+				log.Sugar.Infow("access denied", "user", claims.Subject, "role", claims.Role)
+				handlers.SendErrors(w, apperr.ErrAccessDenied)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
@@ -0,0 +1,45 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/plasmatrip/avito_merch/internal/api/middleware"
+	"github.com/plasmatrip/avito_merch/internal/logger"
+	"github.com/plasmatrip/avito_merch/internal/model"
+)
+
+func TestWithRole(t *testing.T) {
+	log, err := logger.NewLogger(logger.LogLevelDebug)
+	require.NoError(t, err)
+
+	handler := middleware.WithRole(*log, model.RoleAdmin)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	request := func(role string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/merch", nil)
+		req = req.WithContext(context.WithValue(req.Context(), model.ValidLogin{}, &model.Claims{UserdID: uuid.Must(uuid.NewV4()), Role: role}))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("admin allowed", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, request(model.RoleAdmin).Code, "admin must be allowed")
+	})
+
+	t.Run("user denied", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, request(model.RoleUser).Code, "user must be denied")
+	})
+
+	t.Run("token without role denied", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, request("").Code, "token without role must be denied")
+	})
+}
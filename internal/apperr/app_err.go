@@ -2,6 +2,7 @@ package apperr
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 )
 
@@ -26,9 +27,49 @@ var (
 	ErrInvalidToken                  = errors.New("invalid token")
 	ErrInvalidAuthorizationHeader    = errors.New("invalid authorization header")
 	ErrMissingAuthorizationHeader    = errors.New("missing authorization header")
+	ErrRetriesExhausted              = errors.New("transaction conflict, try again later")
+	ErrInvalidIdempotencyKey         = errors.New("invalid idempotency key")
+	ErrIdempotencyKeyInProgress      = errors.New("request with this idempotency key is in progress")
+	ErrIdempotencyKeyReused          = errors.New("idempotency key was used with another request")
+	ErrInvalidQueryParameter         = errors.New("invalid query parameter")
+	ErrAccessDenied                  = errors.New("access denied")
+	ErrItemNotAvailable              = errors.New("item is not available")
+	ErrItemAlreadyExists             = errors.New("item already exists")
+	ErrInvalidMerchName              = errors.New("invalid merch name")
+	ErrPriceIsLessThanOrEqualToZero  = errors.New("price is less than or equal to zero")
+	ErrInvalidRole                   = errors.New("invalid role")
+	ErrOutOfStock                    = errors.New("item is out of stock")
+	ErrInvalidStock                  = errors.New("invalid stock quantity")
+	ErrStockIsUnlimited              = errors.New("item stock is unlimited")
+	ErrPurchaseNotFound              = errors.New("purchase not found")
+	ErrPurchaseAlreadyRefunded       = errors.New("purchase already refunded")
+	ErrRefundWindowExpired           = errors.New("refund window expired")
+	ErrEmptyOrder                    = errors.New("order is empty")
+	ErrInvalidQuantity               = errors.New("invalid quantity")
+	ErrCartEmpty                     = errors.New("cart is empty")
+	ErrCartPriceChanged              = errors.New("prices in the cart have changed")
+	ErrInvalidGrantRecipients        = errors.New("specify either users or all")
+	ErrInvalidGrantReason            = errors.New("grant reason is empty or too long")
+	ErrMessageTooLong                = errors.New("message is too long")
+	ErrCoinRequestNotFound           = errors.New("coin request not found")
+	ErrCoinRequestResolved           = errors.New("coin request already resolved")
+	ErrCoinRequestExpired            = errors.New("coin request expired")
+	ErrEmptyBatch                    = errors.New("batch is empty or too large")
+	ErrBatchValidationFailed         = errors.New("batch validation failed")
+	ErrTransferLimitExceeded         = errors.New("transfer limit exceeded")
+	ErrInvalidTransferLimit          = errors.New("invalid transfer limit")
+	ErrNotEnoughItems                = errors.New("not enough items in the inventory")
+	ErrPurchaseNotOwned              = errors.New("purchased item has been handed over")
+	ErrPromoNotFound                 = errors.New("promo code not found")
+	ErrPromoNotActive                = errors.New("promo code is not active")
+	ErrPromoLimitReached             = errors.New("promo code usage limit reached")
+	ErrPromoNotApplicable            = errors.New("promo code does not apply to the items")
+	ErrInvalidPromo                  = errors.New("invalid promo code")
+	ErrPromoAlreadyExists            = errors.New("promo code already exists")
+	ErrInvalidCategory               = errors.New("invalid merch category")
 
 	ErrorMessages = map[error]string{
-		ErrItemNotFound:                  ErrBadLogin.Error(),
+		ErrItemNotFound:                  ErrItemNotFound.Error(),
 		ErrInsufficientFunds:             ErrInsufficientFunds.Error(),
 		ErrAccountNotFound:               ErrAccountNotFound.Error(),
 		ErrMerchNotBought:                ErrMerchNotBought.Error(),
@@ -44,6 +85,47 @@ var (
 		ErrInvalidToken:                  ErrInvalidToken.Error(),
 		ErrInvalidAuthorizationHeader:    ErrInvalidAuthorizationHeader.Error(),
 		ErrMissingAuthorizationHeader:    ErrMissingAuthorizationHeader.Error(),
+		ErrRetriesExhausted:              ErrRetriesExhausted.Error(),
+		ErrInvalidIdempotencyKey:         ErrInvalidIdempotencyKey.Error(),
+		ErrIdempotencyKeyInProgress:      ErrIdempotencyKeyInProgress.Error(),
+		ErrIdempotencyKeyReused:          ErrIdempotencyKeyReused.Error(),
+		ErrInvalidQueryParameter:         ErrInvalidQueryParameter.Error(),
+		ErrAccessDenied:                  ErrAccessDenied.Error(),
+		ErrItemNotAvailable:              ErrItemNotAvailable.Error(),
+		ErrItemAlreadyExists:             ErrItemAlreadyExists.Error(),
+		ErrInvalidMerchName:              ErrInvalidMerchName.Error(),
+		ErrPriceIsLessThanOrEqualToZero:  ErrPriceIsLessThanOrEqualToZero.Error(),
+		ErrInvalidRole:                   ErrInvalidRole.Error(),
+		ErrUserNotFound:                  ErrUserNotFound.Error(),
+		ErrOutOfStock:                    ErrOutOfStock.Error(),
+		ErrInvalidStock:                  ErrInvalidStock.Error(),
+		ErrStockIsUnlimited:              ErrStockIsUnlimited.Error(),
+		ErrPurchaseNotFound:              ErrPurchaseNotFound.Error(),
+		ErrPurchaseAlreadyRefunded:       ErrPurchaseAlreadyRefunded.Error(),
+		ErrRefundWindowExpired:           ErrRefundWindowExpired.Error(),
+		ErrEmptyOrder:                    ErrEmptyOrder.Error(),
+		ErrInvalidQuantity:               ErrInvalidQuantity.Error(),
+		ErrCartEmpty:                     ErrCartEmpty.Error(),
+		ErrCartPriceChanged:              ErrCartPriceChanged.Error(),
+		ErrInvalidGrantRecipients:        ErrInvalidGrantRecipients.Error(),
+		ErrInvalidGrantReason:            ErrInvalidGrantReason.Error(),
+		ErrMessageTooLong:                ErrMessageTooLong.Error(),
+		ErrCoinRequestNotFound:           ErrCoinRequestNotFound.Error(),
+		ErrCoinRequestResolved:           ErrCoinRequestResolved.Error(),
+		ErrCoinRequestExpired:            ErrCoinRequestExpired.Error(),
+		ErrEmptyBatch:                    ErrEmptyBatch.Error(),
+		ErrBatchValidationFailed:         ErrBatchValidationFailed.Error(),
+		ErrTransferLimitExceeded:         ErrTransferLimitExceeded.Error(),
+		ErrInvalidTransferLimit:          ErrInvalidTransferLimit.Error(),
+		ErrNotEnoughItems:                ErrNotEnoughItems.Error(),
+		ErrPurchaseNotOwned:              ErrPurchaseNotOwned.Error(),
+		ErrPromoNotFound:                 ErrPromoNotFound.Error(),
+		ErrPromoNotActive:                ErrPromoNotActive.Error(),
+		ErrPromoLimitReached:             ErrPromoLimitReached.Error(),
+		ErrPromoNotApplicable:            ErrPromoNotApplicable.Error(),
+		ErrInvalidPromo:                  ErrInvalidPromo.Error(),
+		ErrPromoAlreadyExists:            ErrPromoAlreadyExists.Error(),
+		ErrInvalidCategory:               ErrInvalidCategory.Error(),
 	}
 
 	ErrorStatuses = map[error]int{
@@ -64,5 +146,93 @@ var (
 		ErrInvalidToken:                  http.StatusUnauthorized,
 		ErrInvalidAuthorizationHeader:    http.StatusUnauthorized,
 		ErrMissingAuthorizationHeader:    http.StatusUnauthorized,
+		ErrRetriesExhausted:              http.StatusServiceUnavailable,
+		ErrInvalidIdempotencyKey:         http.StatusBadRequest,
+		ErrIdempotencyKeyInProgress:      http.StatusConflict,
+		ErrIdempotencyKeyReused:          http.StatusUnprocessableEntity,
+		ErrInvalidQueryParameter:         http.StatusBadRequest,
+		ErrAccessDenied:                  http.StatusForbidden,
+		ErrItemNotAvailable:              http.StatusBadRequest,
+		ErrItemAlreadyExists:             http.StatusConflict,
+		ErrInvalidMerchName:              http.StatusBadRequest,
+		ErrPriceIsLessThanOrEqualToZero:  http.StatusBadRequest,
+		ErrInvalidRole:                   http.StatusBadRequest,
+		ErrUserNotFound:                  http.StatusBadRequest,
+		ErrOutOfStock:                    http.StatusConflict,
+		ErrInvalidStock:                  http.StatusBadRequest,
+		ErrStockIsUnlimited:              http.StatusBadRequest,
+		ErrPurchaseNotFound:              http.StatusBadRequest,
+		ErrPurchaseAlreadyRefunded:       http.StatusConflict,
+		ErrRefundWindowExpired:           http.StatusBadRequest,
+		ErrEmptyOrder:                    http.StatusBadRequest,
+		ErrInvalidQuantity:               http.StatusBadRequest,
+		ErrCartEmpty:                     http.StatusBadRequest,
+		ErrCartPriceChanged:              http.StatusConflict,
+		ErrInvalidGrantRecipients:        http.StatusBadRequest,
+		ErrInvalidGrantReason:            http.StatusBadRequest,
+		ErrMessageTooLong:                http.StatusBadRequest,
+		ErrCoinRequestNotFound:           http.StatusBadRequest,
+		ErrCoinRequestResolved:           http.StatusConflict,
+		ErrCoinRequestExpired:            http.StatusBadRequest,
+		ErrEmptyBatch:                    http.StatusBadRequest,
+		ErrBatchValidationFailed:         http.StatusBadRequest,
+		ErrTransferLimitExceeded:         http.StatusForbidden,
+		ErrInvalidTransferLimit:          http.StatusBadRequest,
+		ErrNotEnoughItems:                http.StatusBadRequest,
+		ErrPurchaseNotOwned:              http.StatusConflict,
+		ErrPromoNotFound:                 http.StatusBadRequest,
+		ErrPromoNotActive:                http.StatusBadRequest,
+		ErrPromoLimitReached:             http.StatusConflict,
+		ErrPromoNotApplicable:            http.StatusBadRequest,
+		ErrInvalidPromo:                  http.StatusBadRequest,
+		ErrPromoAlreadyExists:            http.StatusConflict,
+		ErrInvalidCategory:               http.StatusBadRequest,
 	}
 )
+
+// RetryError - транзакция не выполнена после исчерпания всех попыток повтора
+type RetryError struct {
+	Attempts int   // количество выполненных попыток
+	Err      error // ошибка последней попытки
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("transaction failed after %d attempts: %v", e.Attempts, e.Err)
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// Is позволяет проверять ошибку через errors.Is(err, ErrRetriesExhausted)
+func (e *RetryError) Is(target error) bool {
+	return target == ErrRetriesExhausted
+}
+
+// BatchValidationError - не прошли проверку отдельные переводы пакета
+type BatchValidationError struct {
+	Errors []error // ошибки переводов в порядке запроса, nil - перевод прошел проверку
+}
+
+func (e *BatchValidationError) Error() string {
+	return ErrBatchValidationFailed.Error()
+}
+
+// Is позволяет проверять ошибку через errors.Is(err, ErrBatchValidationFailed)
+func (e *BatchValidationError) Is(target error) bool {
+	return target == ErrBatchValidationFailed
+}
+
+// TransferLimitError - перевод превышает лимит отправителя
+type TransferLimitError struct {
+	Remaining int64 // сумма, которую еще можно перевести
+}
+
+func (e *TransferLimitError) Error() string {
+	return fmt.Sprintf("%v, remaining %d", ErrTransferLimitExceeded, e.Remaining)
+}
+
+// Is позволяет проверять ошибку через errors.Is(err, ErrTransferLimitExceeded)
+func (e *TransferLimitError) Is(target error) bool {
+	return target == ErrTransferLimitExceeded
+}
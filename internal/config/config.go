@@ -9,6 +9,9 @@ import (
 
 	"github.com/caarlos0/env"
 	"github.com/joho/godotenv"
+	jsoniter "github.com/json-iterator/go"
+
+	"github.com/plasmatrip/avito_merch/internal/model"
 )
 
 // константы таймаутов
@@ -18,14 +21,35 @@ const (
 	idleTimeout  = 60
 )
 
+// ограничение на название правила бонуса, название хранится в периоде начисления
+const maxBonusRuleNameLength = 56
+
+// драйверы хранилища
+const (
+	StorageDriverPostgres = "postgres"
+	StorageDriverMemory   = "memory"
+)
+
 type Config struct {
-	Host         string `env:"RUN_ADDRESS"`  //адрес веб-сервера
-	Database     string `env:"DATABASE_URI"` //DSN базы данных
-	LogLevel     string `env:"LOG_LEVEL"`    //уровень логирования
-	TokenSecret  string `env:"TOKEN_SECRET"` //секретный ключ для JWT
-	ReadTimeout  time.Duration
-	WriteTimeout time.Duration
-	IdleTimeout  time.Duration
+	Host               string        `env:"RUN_ADDRESS"`                              //адрес веб-сервера
+	Database           string        `env:"DATABASE_URI"`                             //DSN базы данных
+	LogLevel           string        `env:"LOG_LEVEL"`                                //уровень логирования
+	TokenSecret        string        `env:"TOKEN_SECRET"`                             //секретный ключ для JWT
+	StorageDriver      string        `env:"STORAGE_DRIVER" envDefault:"postgres"`     //драйвер хранилища: postgres или memory
+	IdempotencyTTL     time.Duration `env:"IDEMPOTENCY_TTL" envDefault:"24h"`         //время хранения ответов на запросы с Idempotency-Key
+	AdminLogins        []string      `env:"ADMIN_LOGINS" envSeparator:","`            //логины пользователей, которым при запуске назначается роль администратора
+	RefundWindow       time.Duration `env:"REFUND_WINDOW" envDefault:"336h"`          //срок, в течение которого покупку можно вернуть
+	MonthlyAllowance   int64         `env:"MONTHLY_ALLOWANCE" envDefault:"0"`         //ежемесячное начисление монет всем пользователям, 0 - отключено
+	AllowanceInterval  time.Duration `env:"ALLOWANCE_CHECK_INTERVAL" envDefault:"1h"` //интервал проверки ежемесячного начисления
+	CoinRequestTTL     time.Duration `env:"COIN_REQUEST_TTL" envDefault:"72h"`        //время ожидания решения по запросу монет
+	StartingBalance    int64         `env:"STARTING_BALANCE" envDefault:"1000"`       //начальный баланс нового пользователя
+	TransferMaxAmount  int64         `env:"TRANSFER_MAX_AMOUNT" envDefault:"0"`       //максимальная сумма одного перевода, 0 - без ограничения
+	TransferDailyLimit int64         `env:"TRANSFER_DAILY_LIMIT" envDefault:"0"`      //максимальная сумма переводов за последние 24 часа, 0 - без ограничения
+	WelcomeBonus       string        `env:"WELCOME_BONUS_RULES"`                      //правила бонусов при регистрации в формате JSON
+	WelcomeBonusRules  []model.BonusRule
+	ReadTimeout        time.Duration
+	WriteTimeout       time.Duration
+	IdleTimeout        time.Duration
 }
 
 func LoadConfig() (*Config, error) {
@@ -54,8 +78,34 @@ func LoadConfig() (*Config, error) {
 		return nil, errors.New("RUN_ADDRESS not found")
 	}
 
-	if _, exist := os.LookupEnv("DATABASE_URI"); !exist {
-		return nil, errors.New("DATABASE_URI not found")
+	switch cfg.StorageDriver {
+	case StorageDriverPostgres:
+		if _, exist := os.LookupEnv("DATABASE_URI"); !exist {
+			return nil, errors.New("DATABASE_URI not found")
+		}
+	case StorageDriverMemory:
+	default:
+		return nil, fmt.Errorf("unknown storage driver: %s", cfg.StorageDriver)
+	}
+
+	if cfg.MonthlyAllowance < 0 || cfg.AllowanceInterval <= 0 {
+		return nil, errors.New("invalid monthly allowance settings")
+	}
+
+	if cfg.CoinRequestTTL <= 0 {
+		return nil, errors.New("invalid coin request TTL")
+	}
+
+	if cfg.TransferMaxAmount < 0 || cfg.TransferDailyLimit < 0 {
+		return nil, errors.New("invalid transfer limits")
+	}
+
+	if cfg.StartingBalance < 0 {
+		return nil, errors.New("invalid starting balance")
+	}
+
+	if cfg.WelcomeBonusRules, err = parseBonusRules(cfg.WelcomeBonus); err != nil {
+		return nil, fmt.Errorf("invalid welcome bonus rules: %w", err)
 	}
 
 	if _, exist := os.LookupEnv("LOG_LEVEL"); !exist {
@@ -68,3 +118,54 @@ func LoadConfig() (*Config, error) {
 
 	return cfg, nil
 }
+
+// BonusPolicy возвращает начисления новому пользователю
+func (c Config) BonusPolicy() model.BonusPolicy {
+	return model.BonusPolicy{
+		StartingBalance: c.StartingBalance,
+		Rules:           c.WelcomeBonusRules,
+	}
+}
+
+// TransferLimits возвращает лимиты переводов по умолчанию
+func (c Config) TransferLimits() model.TransferLimits {
+	return model.TransferLimits{
+		PerTransfer: c.TransferMaxAmount,
+		Daily:       c.TransferDailyLimit,
+	}
+}
+
+// parseBonusRules разбирает правила бонусов при регистрации
+func parseBonusRules(data string) ([]model.BonusRule, error) {
+	if data == "" {
+		return nil, nil
+	}
+
+	// неизвестные условия не пропускаем, иначе правило применится ко всем пользователям
+	json := jsoniter.Config{DisallowUnknownFields: true}.Froze()
+
+	var rules []model.BonusRule
+	if err := json.UnmarshalFromString(data, &rules); err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]struct{}, len(rules))
+	for _, r := range rules {
+		if r.Name == "" || len(r.Name) > maxBonusRuleNameLength {
+			return nil, errors.New("rule name must be from 1 to 56 characters")
+		}
+		if _, ok := names[r.Name]; ok {
+			return nil, fmt.Errorf("duplicate rule %s", r.Name)
+		}
+		names[r.Name] = struct{}{}
+
+		if r.Amount <= 0 {
+			return nil, fmt.Errorf("rule %s: amount must be positive", r.Name)
+		}
+		if !r.From.IsZero() && !r.To.IsZero() && !r.From.Before(r.To) {
+			return nil, fmt.Errorf("rule %s: empty registration period", r.Name)
+		}
+	}
+
+	return rules, nil
+}
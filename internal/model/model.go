@@ -1,10 +1,44 @@
 package model
 
 import (
+	"math"
+	"time"
+
 	"github.com/gofrs/uuid"
 	"github.com/golang-jwt/jwt"
 )
 
+// операции журнала проводок
+const (
+	OperationBonus      = "bonus"      // начисление монет при регистрации
+	OperationTransfer   = "transfer"   // перевод монет между пользователями
+	OperationPurchase   = "purchase"   // покупка мерча
+	OperationAdjustment = "adjustment" // корректировка баланса при переносе истории в журнал
+	OperationRefund     = "refund"     // возврат покупки
+	OperationGrant      = "grant"      // начисление монет администратором или по расписанию
+	OperationWelcome    = "welcome"    // бонус при регистрации по правилам
+	OperationGift       = "gift"       // мерч, подаренный другим пользователем, в истории получателя
+)
+
+// виды счетов
+const (
+	AccountUser         = "user"          // счет пользователя
+	AccountIssuance     = "issuance"      // системный счет, источник всех монет
+	AccountStoreRevenue = "store_revenue" // системный счет, монеты, потраченные на мерч
+)
+
+// направления движения монет в истории операций
+const (
+	DirectionIn  = "in"  // зачисление
+	DirectionOut = "out" // списание
+)
+
+// поля сортировки каталога
+const (
+	MerchSortName  = "name"
+	MerchSortPrice = "price"
+)
+
 // AuthRequest - запрос на аутентификацию
 type AuthRequest struct {
 	UserName string `json:"username"`
@@ -18,18 +52,62 @@ type AuthResponse struct {
 
 // SendCoinrequest - отправка монеты
 type SendCoinRequest struct {
+	ToUser  string `json:"toUser"`
+	Amount  int64  `json:"amount"`
+	Message string `json:"message,omitempty"` // сообщение получателю
+}
+
+// GiftRequest - покупка мерча в подарок другому пользователю
+type GiftRequest struct {
+	ToUser  string `json:"toUser"`
+	Item    string `json:"item"`
+	Message string `json:"message,omitempty"` // поздравление получателю
+}
+
+// ItemTransferRequest - передача купленного мерча другому пользователю
+type ItemTransferRequest struct {
+	ToUser   string `json:"toUser"`
+	Item     string `json:"item"`
+	Quantity int    `json:"quantity"`
+}
+
+// ItemTransfer - передача мерча между пользователями
+type ItemTransfer struct {
+	ID       uuid.UUID `json:"id"`
+	Date     time.Time `json:"date"`
+	FromUser string    `json:"fromUser"`
+	ToUser   string    `json:"toUser"`
+	Item     string    `json:"item"`
+	Quantity int       `json:"quantity"`
+}
+
+// BatchSendCoinRequest - перевод монет нескольким пользователям одной транзакцией
+type BatchSendCoinRequest struct {
+	Transfers []SendCoinRequest `json:"transfers"`
+}
+
+// BatchTransferResult - результат проверки перевода из пакета
+type BatchTransferResult struct {
 	ToUser string `json:"toUser"`
-	Amount int    `json:"amount"`
+	Amount int64  `json:"amount"`
+	Error  string `json:"error,omitempty"`
+}
+
+// BatchErrorResponse - ошибка пакетного перевода с результатами проверки каждого перевода
+type BatchErrorResponse struct {
+	Errors  string                `json:"errors"`
+	Results []BatchTransferResult `json:"results"`
 }
 
 // ErrorResponse - возвращаемая ошибка
 type ErrorResponse struct {
-	Errors string `json:"errors"`
+	Errors    string `json:"errors"`
+	Remaining *int64 `json:"remaining,omitempty"` // доступная для перевода сумма при превышении лимита
 }
 
 // InfoResponse - информация о монетах, инвентаре и истории транзакций
 type InfoResponse struct {
-	Coins       int         `json:"coins"`
+	Coins       int64       `json:"coins"`
 	Inventory   []Inventory `json:"inventory"`
 	CoinHistory CoinHistory `json:"coinHistory"`
 }
@@ -48,30 +126,490 @@ type CoinHistory struct {
 
 // Received - полученная монета
 type Received struct {
-	FromUser string `json:"fromUser"`
-	Amount   int    `json:"amount"`
+	FromUser string   `json:"fromUser"`
+	Amount   int64    `json:"amount"`
+	Messages []string `json:"messages,omitempty"` // сообщения переводов, от старых к новым
 }
 
 // Sent - отправленная монета
 type Sent struct {
-	ToUser string `json:"toUser"`
-	Amount int    `json:"amount"`
+	ToUser   string   `json:"toUser"`
+	Amount   int64    `json:"amount"`
+	Messages []string `json:"messages,omitempty"` // сообщения переводов, от старых к новым
 }
 
 // Transaction - транзакция
 type Transaction struct {
-	Login  string `json:"login"`
-	Amount int    `json:"amount"`
-	Type   string `json:"type"`
+	Login    string   `json:"login"`
+	Amount   int64    `json:"amount"`
+	Type     string   `json:"type"`
+	Messages []string `json:"messages,omitempty"`
+}
+
+// MaxStock - наибольший остаток товара, как у столбца stock таблицы merch
+const MaxStock = math.MaxInt32
+
+// MerchItem - товар каталога
+type MerchItem struct {
+	Name        string `json:"name"`
+	Price       int64  `json:"price"`
+	Description string `json:"description"`
+	Available   bool   `json:"available"`
+	Stock       *int   `json:"stock"` // остаток, null - без ограничения
+	Category    string `json:"category"`
+}
+
+// MerchRequest - запрос на создание или изменение товара каталога,
+// незаполненные поля при изменении остаются прежними
+type MerchRequest struct {
+	Name        string  `json:"name"`
+	Price       *int64  `json:"price"`
+	Description *string `json:"description"`
+	Available   *bool   `json:"available"`
+	Stock       *int    `json:"stock"`
+	Category    *string `json:"category"`
+}
+
+// PriceRequest - запрос на изменение цены товара
+type PriceRequest struct {
+	Price int64 `json:"price"`
+}
+
+// StockRequest - запрос на установку остатка товара, null - без ограничения
+type StockRequest struct {
+	Stock *int `json:"stock"`
+}
+
+// RestockRequest - запрос на пополнение остатка товара
+type RestockRequest struct {
+	Quantity int `json:"quantity"`
+}
+
+// OrderItem - позиция заказа
+type OrderItem struct {
+	Name     string `json:"name"`
+	Quantity int    `json:"quantity"`
+}
+
+// OrderRequest - запрос на оформление заказа
+type OrderRequest struct {
+	Items []OrderItem `json:"items"`
+	Promo string      `json:"promo"` // необязательный промокод
+}
+
+// OrderLine - оплаченная позиция заказа
+type OrderLine struct {
+	Name     string `json:"name"`
+	Quantity int    `json:"quantity"`
+	Price    int64  `json:"price"`
+	Discount int64  `json:"discount,omitempty"` // скидка по промокоду с каждой единицы
+	Total    int64  `json:"total"`
+}
+
+// Order - оформленный заказ
+type Order struct {
+	ID    uuid.UUID   `json:"id"`
+	Date  time.Time   `json:"date"`
+	Items []OrderLine `json:"items"`
+	Promo string      `json:"promo,omitempty"`
+	Total int64       `json:"total"`
+}
+
+// CartItem - позиция корзины
+type CartItem struct {
+	Name         string `json:"name"`
+	Quantity     int    `json:"quantity"`
+	Price        int64  `json:"price"`        // текущая цена
+	AddedPrice   int64  `json:"addedPrice"`   // цена при добавлении в корзину
+	PriceChanged bool   `json:"priceChanged"` // цена изменилась после добавления в корзину
+	Available    bool   `json:"available"`
+	Total        int64  `json:"total"` // стоимость позиции по текущей цене
+}
+
+// Cart - корзина пользователя
+type Cart struct {
+	Items        []CartItem `json:"items"`
+	Total        int64      `json:"total"`
+	PriceChanged bool       `json:"priceChanged"`
+}
+
+// CartRequest - запрос на добавление мерча в корзину или изменение его количества
+type CartRequest struct {
+	Name     string `json:"name"`
+	Quantity int    `json:"quantity"`
+}
+
+// CheckoutRequest - запрос на оформление заказа из корзины
+type CheckoutRequest struct {
+	AcceptPriceChanges bool   `json:"acceptPriceChanges"` // оформить заказ по текущим ценам
+	Promo              string `json:"promo"`              // необязательный промокод
+}
+
+// GrantRequest - запрос на начисление монет
+type GrantRequest struct {
+	Users  []string `json:"users"`  // логины получателей
+	All    bool     `json:"all"`    // начислить всем пользователям
+	Amount int64    `json:"amount"` // количество монет каждому получателю
+	Reason string   `json:"reason"` // причина, отображается в истории операций
+	Period string   `json:"period"` // период, за который пользователь получает не больше одного начисления
+}
+
+// GrantResponse - результат начисления монет
+type GrantResponse struct {
+	Granted int `json:"granted"` // количество получателей
+}
+
+// MerchFilter - параметры выборки каталога
+type MerchFilter struct {
+	Sort     string // поле сортировки: MerchSortName или MerchSortPrice
+	Desc     bool   // сортировка по убыванию
+	MinPrice int64  // минимальная цена включительно, 0 - без ограничения
+	MaxPrice int64  // максимальная цена включительно, 0 - без ограничения
+}
+
+// HistoryFilter - параметры выборки истории операций
+type HistoryFilter struct {
+	Limit       int       // максимальное количество записей
+	Before      int64     // порядковый номер записи, с которой продолжается выборка, 0 - с последней
+	Direction   string    // направление движения монет, пусто - любое
+	Counterpart string    // логин контрагента или название мерча, пусто - любой
+	From        time.Time // начало периода включительно, нулевое значение - без ограничения
+	To          time.Time // конец периода не включительно, нулевое значение - без ограничения
+}
+
+// HistoryEntry - операция в истории пользователя
+type HistoryEntry struct {
+	ID           uuid.UUID `json:"id"`
+	OperationID  uuid.UUID `json:"operationId"`
+	Seq          int64     `json:"-"`
+	Date         time.Time `json:"date"`
+	Type         string    `json:"type"`
+	Direction    string    `json:"direction"`
+	Counterpart  string    `json:"counterpart,omitempty"`
+	Description  string    `json:"description,omitempty"` // причина начисления, сообщение перевода или поздравление к подарку
+	Item         string    `json:"item,omitempty"`        // подаренный мерч
+	Amount       int64     `json:"amount"`
+	BalanceAfter int64     `json:"balanceAfter"`
+}
+
+// HistoryPage - страница истории операций
+type HistoryPage struct {
+	Entries []HistoryEntry
+	Next    int64 // порядковый номер для следующей страницы, 0 - страниц больше нет
+}
+
+// HistoryResponse - ответ на запрос истории операций
+type HistoryResponse struct {
+	Transactions []HistoryEntry `json:"transactions"`
+	NextCursor   string         `json:"nextCursor,omitempty"`
+}
+
+// IdempotentResponse - сохраненный ответ на запрос с ключом идемпотентности
+type IdempotentResponse struct {
+	Status      int
+	ContentType string
+	Body        []byte
+}
+
+// роли пользователей
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// RoleRequest - запрос на изменение роли пользователя
+type RoleRequest struct {
+	Role string `json:"role"`
+}
+
+// UserRole - роль пользователя
+type UserRole struct {
+	Login string `json:"login"`
+	Role  string `json:"role"`
 }
 
 // Claims - токен+id пользователя
 type Claims struct {
 	jwt.StandardClaims
 	UserdID uuid.UUID
+	Role    string
 }
 
 // ValidLogin - пустая структура для передачи id пользователя
 // в контексте при успешной авторизации
 type ValidLogin struct {
 }
+
+// DefaultStartingBalance - начальный баланс нового пользователя по умолчанию
+const DefaultStartingBalance int64 = 1000
+
+// BonusRule - правило бонуса при регистрации, незаданные границы периода не ограничивают правило
+type BonusRule struct {
+	Name   string    `json:"name"`           // название правила, показывается в истории
+	Amount int64     `json:"amount"`         // количество монет
+	From   time.Time `json:"from,omitempty"` // начало периода регистрации
+	To     time.Time `json:"to,omitempty"`   // конец периода регистрации, не включительно
+}
+
+// Match проверяет, что правило применяется к пользователю, зарегистрированному в date
+func (r BonusRule) Match(date time.Time) bool {
+	switch {
+	case !r.From.IsZero() && date.Before(r.From):
+		return false
+	case !r.To.IsZero() && !date.Before(r.To):
+		return false
+	}
+	return true
+}
+
+// Period возвращает период начисления по правилу, чтобы правило применялось к пользователю не более одного раза
+func (r BonusRule) Period() string {
+	return "welcome-" + r.Name
+}
+
+// BonusPolicy - начисления новому пользователю при регистрации
+type BonusPolicy struct {
+	StartingBalance int64
+	Rules           []BonusRule
+}
+
+// Bonuses возвращает правила, применяемые к пользователю, зарегистрированному в date
+func (p BonusPolicy) Bonuses(date time.Time) []BonusRule {
+	var rules []BonusRule
+	for _, r := range p.Rules {
+		if r.Match(date) {
+			rules = append(rules, r)
+		}
+	}
+	return rules
+}
+
+// состояния запроса монет
+const (
+	CoinRequestPending  = "pending"  // ожидает решения плательщика
+	CoinRequestApproved = "approved" // одобрен, монеты переведены
+	CoinRequestDeclined = "declined" // отклонен плательщиком
+	CoinRequestExpired  = "expired"  // истек срок ожидания
+)
+
+// NewCoinRequest - запрос монет у другого пользователя
+type NewCoinRequest struct {
+	FromUser string `json:"fromUser"` // у кого запрашиваются монеты
+	Amount   int64  `json:"amount"`
+	Message  string `json:"message,omitempty"`
+}
+
+// CoinRequest - запрос монет
+type CoinRequest struct {
+	ID         uuid.UUID  `json:"id"`
+	Date       time.Time  `json:"date"`
+	Requester  string     `json:"requester"` // кто запросил монеты
+	Payer      string     `json:"payer"`     // у кого запрошены монеты
+	Amount     int64      `json:"amount"`
+	Message    string     `json:"message,omitempty"`
+	Status     string     `json:"status"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	ResolvedAt *time.Time `json:"resolvedAt,omitempty"`
+}
+
+// TransferLimitWindow - скользящее окно дневного лимита переводов
+const TransferLimitWindow = 24 * time.Hour
+
+// TransferLimits - лимиты переводов, 0 - без ограничения
+type TransferLimits struct {
+	PerTransfer int64 `json:"perTransfer"` // максимальная сумма одного перевода
+	Daily       int64 `json:"daily"`       // максимальная сумма переводов за последние 24 часа
+}
+
+// Remaining возвращает сумму, которую можно перевести после переводов на сумму sent за последние 24 часа,
+// -1 - без ограничения
+func (l TransferLimits) Remaining(sent int64) int64 {
+	remaining := int64(-1)
+	if l.Daily > 0 {
+		remaining = max(l.Daily-sent, 0)
+	}
+	if l.PerTransfer > 0 && (remaining < 0 || l.PerTransfer < remaining) {
+		remaining = l.PerTransfer
+	}
+	return remaining
+}
+
+// Allows проверяет, что перевод суммы amount не превышает лимиты после переводов на сумму sent за последние 24 часа
+func (l TransferLimits) Allows(sent, amount int64) bool {
+	remaining := l.Remaining(sent)
+	return remaining < 0 || amount <= remaining
+}
+
+// Override возвращает лимиты с учетом индивидуальных лимитов пользователя
+func (l TransferLimits) Override(req TransferLimitsRequest) TransferLimits {
+	if req.PerTransfer != nil {
+		l.PerTransfer = *req.PerTransfer
+	}
+	if req.Daily != nil {
+		l.Daily = *req.Daily
+	}
+	return l
+}
+
+// TransferLimitsRequest - индивидуальные лимиты переводов пользователя, null - лимит по умолчанию
+type TransferLimitsRequest struct {
+	PerTransfer *int64 `json:"perTransfer"`
+	Daily       *int64 `json:"daily"`
+}
+
+// периоды рейтинга
+const (
+	LeaderboardWeek  = "week"  // последние 7 дней
+	LeaderboardMonth = "month" // последние 30 дней
+	LeaderboardAll   = "all"   // все время
+)
+
+// LeaderboardFilter - параметры рейтинга
+type LeaderboardFilter struct {
+	Since time.Time // начало периода, нулевое значение - все время
+	Limit int       // количество пользователей в каждом рейтинге
+}
+
+// LeaderboardEntry - место пользователя в рейтинге
+type LeaderboardEntry struct {
+	Login  string `json:"login"`
+	Amount int64  `json:"amount"`
+}
+
+// Leaderboard - рейтинги пользователей по полученным, отправленным и потраченным на мерч монетам
+type Leaderboard struct {
+	Window     string             `json:"window"`
+	Receivers  []LeaderboardEntry `json:"receivers"`
+	Senders    []LeaderboardEntry `json:"senders"`
+	Purchasers []LeaderboardEntry `json:"purchasers"`
+}
+
+// LeaderboardVisibilityRequest - участие пользователя в рейтинге
+type LeaderboardVisibilityRequest struct {
+	Hidden bool `json:"hidden"`
+}
+
+// виды уведомлений
+const (
+	NotificationPriceDrop = "price_drop" // снижение цены мерча из списка желаний
+	NotificationRestock   = "restock"    // мерч из списка желаний снова можно купить
+)
+
+// WishlistRequest - добавление мерча в список желаний
+type WishlistRequest struct {
+	Name string `json:"name"`
+}
+
+// WishlistItem - мерч из списка желаний
+type WishlistItem struct {
+	Name      string `json:"name"`
+	Price     int64  `json:"price"`
+	Available bool   `json:"available"`
+	Stock     *int   `json:"stock"`   // остаток, null - без ограничения
+	Missing   int64  `json:"missing"` // сколько монет не хватает на покупку
+}
+
+// Wishlist - список желаний пользователя
+type Wishlist struct {
+	Balance int64          `json:"balance"`
+	Items   []WishlistItem `json:"items"`
+	Missing int64          `json:"missing"` // сколько монет не хватает на весь список
+}
+
+// NewWishlist считает, сколько монет не хватает на каждый мерч и на весь список при текущем балансе
+func NewWishlist(balance int64, items []WishlistItem) Wishlist {
+	w := Wishlist{Balance: balance, Items: items}
+
+	var total int64
+	for i := range w.Items {
+		w.Items[i].Missing = max(w.Items[i].Price-balance, 0)
+		total += w.Items[i].Price
+	}
+	w.Missing = max(total-balance, 0)
+
+	return w
+}
+
+// Notification - уведомление пользователя
+type Notification struct {
+	ID       uuid.UUID `json:"id"`
+	Date     time.Time `json:"date"`
+	Type     string    `json:"type"`
+	Item     string    `json:"item"`
+	OldPrice int64     `json:"oldPrice,omitempty"` // цена до снижения
+	Price    int64     `json:"price"`
+	Read     bool      `json:"read"`
+}
+
+// Purchase - покупка мерча в истории покупок пользователя
+type Purchase struct {
+	ID         uuid.UUID  `json:"id"`
+	Date       time.Time  `json:"date"`
+	Item       string     `json:"item"`
+	PricePaid  int64      `json:"pricePaid"`          // списанная цена с учетом скидки
+	Discount   int64      `json:"discount,omitempty"` // скидка по промокоду
+	Promo      string     `json:"promo,omitempty"`
+	OrderID    *uuid.UUID `json:"orderId,omitempty"`    // заказ, в котором куплен мерч
+	ToUser     string     `json:"toUser,omitempty"`     // получатель подарка
+	RefundedAt *time.Time `json:"refundedAt,omitempty"` // дата возврата
+}
+
+// PricePoint - цена мерча, действующая с даты Date
+type PricePoint struct {
+	Date  time.Time `json:"date"`
+	Price int64     `json:"price"`
+}
+
+// виды скидок по промокоду
+const (
+	PromoPercent = "percent" // процент от цены мерча
+	PromoFixed   = "fixed"   // фиксированное количество монет
+)
+
+// PromoCode - промокод на скидку с каждой единицы мерча, без мерча
+// и категории промокод действует на весь каталог
+type PromoCode struct {
+	Code         string    `json:"code"`
+	Type         string    `json:"type"`
+	Value        int64     `json:"value"`              // процент или количество монет
+	Item         string    `json:"item,omitempty"`     // промокод действует только на этот мерч
+	Category     string    `json:"category,omitempty"` // промокод действует только на мерч этой категории
+	ValidFrom    time.Time `json:"validFrom,omitempty"`
+	ValidTo      time.Time `json:"validTo,omitempty"` // не включительно
+	PerUserLimit int       `json:"perUserLimit"`      // использований одним пользователем, 0 - без ограничения
+	TotalLimit   int       `json:"totalLimit"`        // использований всеми пользователями, 0 - без ограничения
+	Used         int       `json:"used"`
+}
+
+// Active проверяет, что промокод действует в момент date
+func (p PromoCode) Active(date time.Time) bool {
+	switch {
+	case !p.ValidFrom.IsZero() && date.Before(p.ValidFrom):
+		return false
+	case !p.ValidTo.IsZero() && !date.Before(p.ValidTo):
+		return false
+	}
+	return true
+}
+
+// Applies проверяет, что промокод действует на мерч item категории category
+func (p PromoCode) Applies(item, category string) bool {
+	switch {
+	case p.Item != "":
+		return p.Item == item
+	case p.Category != "":
+		return p.Category == category
+	default:
+		return true
+	}
+}
+
+// Discount возвращает скидку с единицы мерча по цене price,
+// мерч со скидкой стоит не меньше одной монеты
+func (p PromoCode) Discount(price int64) int64 {
+	discount := p.Value
+	if p.Type == PromoPercent {
+		discount = price * p.Value / 100
+	}
+	return max(min(discount, price-1), 0)
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/plasmatrip/avito_merch/internal/api/middleware"
 	"github.com/plasmatrip/avito_merch/internal/config"
 	"github.com/plasmatrip/avito_merch/internal/logger"
+	"github.com/plasmatrip/avito_merch/internal/model"
 	"github.com/plasmatrip/avito_merch/internal/storage"
 )
 
@@ -19,10 +20,69 @@ func NewRouter(cfg config.Config, log logger.Logger, stor storage.Repository, ap
 
 	r.Post("/api/auth", api.Auth)
 	r.Route("/api", func(r chi.Router) {
-		r.Use(middleware.WithAuthentication(log, cfg.TokenSecret))
-		r.Get("/info", api.Info)
-		r.Post("/sendCoin", api.SendCoin)
-		r.Get("/buy/{item}", api.Buy)
+		// каталог мерча доступен без аутентификации
+		r.Get("/merch", api.Merch)
+		r.Get("/merch/{name}", api.MerchItem)
+		r.Get("/merch/{name}/prices", api.MerchPrices)
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.WithAuthentication(log, cfg.TokenSecret))
+
+			// изменяющие запросы можно безопасно повторять с заголовком Idempotency-Key
+			idempotent := middleware.WithIdempotency(log, stor, cfg.IdempotencyTTL)
+
+			r.Get("/info", api.Info)
+			r.Get("/transactions", api.Transactions)
+			r.Get("/leaderboard", api.Leaderboard)
+			r.Put("/leaderboard/visibility", api.SetLeaderboardVisibility)
+			r.With(idempotent).Post("/sendCoin", api.SendCoin)
+			r.With(idempotent).Post("/sendCoin/batch", api.SendCoinBatch)
+			r.With(idempotent).Get("/buy/{item}", api.Buy)
+			r.With(idempotent).Post("/gift", api.Gift)
+			r.With(idempotent).Post("/orders", api.PlaceOrder)
+
+			r.Get("/cart", api.Cart)
+			r.Put("/cart", api.SetCartItem)
+			r.Delete("/cart", api.ClearCart)
+			r.Delete("/cart/{name}", api.RemoveCartItem)
+			r.With(idempotent).Post("/cart/checkout", api.Checkout)
+
+			r.Get("/wishlist", api.Wishlist)
+			r.Post("/wishlist", api.AddWishlistItem)
+			r.Delete("/wishlist/{name}", api.RemoveWishlistItem)
+
+			r.Get("/notifications", api.Notifications)
+			r.Post("/notifications/read", api.ReadNotifications)
+
+			r.Get("/purchases", api.Purchases)
+			r.With(idempotent).Post("/purchases/{id}/refund", api.Refund)
+
+			r.Get("/inventory/transfers", api.ItemTransfers)
+			r.With(idempotent).Post("/inventory/transfers", api.TransferItems)
+
+			r.Get("/coinRequests", api.CoinRequests)
+			r.With(idempotent).Post("/coinRequests", api.CreateCoinRequest)
+			r.With(idempotent).Post("/coinRequests/{id}/approve", api.ApproveCoinRequest)
+			r.Post("/coinRequests/{id}/decline", api.DeclineCoinRequest)
+
+			// управление каталогом доступно только администраторам
+			r.Route("/admin", func(r chi.Router) {
+				r.Use(middleware.WithRole(log, model.RoleAdmin))
+
+				r.Post("/merch", api.CreateMerch)
+				r.Put("/merch/{name}", api.UpdateMerch)
+				r.Put("/merch/{name}/price", api.UpdateMerchPrice)
+				r.Delete("/merch/{name}", api.RetireMerch)
+				r.Put("/merch/{name}/stock", api.SetStock)
+				r.Post("/merch/{name}/restock", api.Restock)
+				r.Put("/users/{login}/role", api.SetUserRole)
+				r.With(idempotent).Post("/grants", api.GrantCoins)
+				r.Put("/users/{login}/limits", api.SetTransferLimits)
+				r.Delete("/users/{login}/limits", api.ResetTransferLimits)
+				r.Get("/promo", api.PromoCodes)
+				r.Post("/promo", api.CreatePromoCode)
+			})
+		})
 	})
 
 	return r
@@ -0,0 +1,80 @@
+// Package scheduler содержит фоновые задачи сервиса
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/plasmatrip/avito_merch/internal/logger"
+	"github.com/plasmatrip/avito_merch/internal/model"
+	"github.com/plasmatrip/avito_merch/internal/storage"
+)
+
+// причина ежемесячного начисления в истории операций
+const allowanceReason = "monthly allowance"
+
+// Allowance ежемесячно начисляет монеты всем пользователям. Начисление
+// привязано к календарному месяцу, поэтому перезапуск сервиса или несколько
+// экземпляров не приводят к повторному начислению
+type Allowance struct {
+	Stor     storage.Repository
+	Log      logger.Logger
+	Amount   int64
+	Interval time.Duration    // интервал проверки, пользователи, зарегистрированные в течение месяца, получают начисление при следующей проверке
+	Now      func() time.Time // источник текущего времени, по умолчанию time.Now
+}
+
+// NewAllowance создает задачу ежемесячного начисления
+func NewAllowance(stor storage.Repository, log logger.Logger, amount int64, interval time.Duration) *Allowance {
+	return &Allowance{
+		Stor:     stor,
+		Log:      log,
+		Amount:   amount,
+		Interval: interval,
+		Now:      time.Now,
+	}
+}
+
+// Run начисляет монеты сразу и затем с интервалом Interval до отмены ctx
+func (a *Allowance) Run(ctx context.Context) {
+	ticker := time.NewTicker(a.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := a.Grant(ctx); err != nil && ctx.Err() == nil {
+			a.Log.Sugar.Infow("monthly allowance error", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Grant начисляет монеты за текущий месяц пользователям, которые их еще не получили
+func (a *Allowance) Grant(ctx context.Context) error {
+	period := Period(a.Now())
+
+	granted, err := a.Stor.GrantCoins(ctx, model.GrantRequest{
+		All:    true,
+		Amount: a.Amount,
+		Reason: allowanceReason,
+		Period: period,
+	})
+	if err != nil {
+		return err
+	}
+
+	if granted > 0 {
+		a.Log.Sugar.Infow("monthly allowance granted", "period", period, "users", granted, "amount", a.Amount)
+	}
+
+	return nil
+}
+
+// Period возвращает период ежемесячного начисления для момента t
+func Period(t time.Time) string {
+	return "allowance-" + t.UTC().Format("2006-01")
+}
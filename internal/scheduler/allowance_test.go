@@ -0,0 +1,54 @@
+package scheduler_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/plasmatrip/avito_merch/internal/logger"
+	"github.com/plasmatrip/avito_merch/internal/model"
+	"github.com/plasmatrip/avito_merch/internal/scheduler"
+	"github.com/plasmatrip/avito_merch/internal/storage/memory"
+)
+
+func TestPeriod(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+
+	assert.Equal(t, "allowance-2025-02", scheduler.Period(time.Date(2025, 2, 28, 23, 0, 0, 0, time.UTC)), "unexpected period")
+	assert.Equal(t, "allowance-2025-02", scheduler.Period(time.Date(2025, 3, 1, 1, 0, 0, 0, moscow)), "period must be calculated in UTC")
+}
+
+func TestAllowanceGrant(t *testing.T) {
+	ctx := context.Background()
+
+	log, err := logger.NewLogger(logger.LogLevelInfo)
+	require.NoError(t, err)
+
+	stor := memory.NewRepository(*log)
+	userID, err := stor.UserAuth(ctx, model.AuthRequest{UserName: "ivan", Password: "password"})
+	require.NoError(t, err, "an error occurred during user registration")
+
+	coins := func() int64 {
+		info, err := stor.Info(ctx, userID)
+		require.NoError(t, err, "an error occurred while getting information about a user")
+		return info.Coins
+	}
+	initial := coins()
+
+	now := time.Date(2025, 2, 10, 12, 0, 0, 0, time.UTC)
+	allowance := scheduler.NewAllowance(stor, *log, 100, time.Hour)
+	allowance.Now = func() time.Time { return now }
+
+	// повторные проверки в течение месяца, например после перезапуска, ничего не начисляют
+	for range 3 {
+		require.NoError(t, allowance.Grant(ctx), "an error occurred while granting the allowance")
+	}
+	assert.Equal(t, initial+100, coins(), "allowance must be granted once a month")
+
+	now = now.AddDate(0, 1, 0)
+	require.NoError(t, allowance.Grant(ctx), "an error occurred while granting the allowance")
+	assert.Equal(t, initial+200, coins(), "allowance must be granted in the next month")
+}
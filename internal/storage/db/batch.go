@@ -0,0 +1,129 @@
+package db
+
+import (
+	"context"
+
+	"github.com/gofrs/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/plasmatrip/avito_merch/internal/apperr"
+	"github.com/plasmatrip/avito_merch/internal/model"
+	"github.com/plasmatrip/avito_merch/internal/storage/db/queries"
+)
+
+// SendCoinBatch переводит монеты нескольким пользователям в одной транзакции: выполняются все переводы или ни одного.
+// Если отдельные переводы не прошли проверку, возвращается *apperr.BatchValidationError
+func (r PostgresDB) SendCoinBatch(ctx context.Context, fromUser uuid.UUID, transfers []model.SendCoinRequest) error {
+	return r.inTx(ctx, func(tx pgx.Tx) error {
+		logins := make([]string, 0, len(transfers))
+		for _, t := range transfers {
+			logins = append(logins, t.ToUser)
+		}
+
+		// ищем получателей
+		rows, err := tx.Query(ctx, queries.SelectUsersByLogin, pgx.NamedArgs{
+			"logins": logins,
+		})
+		if err != nil {
+			return err
+		}
+
+		userIDs := make(map[string]uuid.UUID, len(transfers))
+		for rows.Next() {
+			var login string
+			var userID uuid.UUID
+			if err := rows.Scan(&login, &userID); err != nil {
+				rows.Close()
+				return err
+			}
+			userIDs[login] = userID
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		// блокируем счета отправителя и всех получателей в порядке user_id, чтобы встречные переводы не приводили к взаимоблокировке
+		lockIDs := make([]uuid.UUID, 0, len(userIDs)+1)
+		lockIDs = append(lockIDs, fromUser)
+		for _, userID := range userIDs {
+			lockIDs = append(lockIDs, userID)
+		}
+
+		rows, err = tx.Query(ctx, queries.SelectAccountsForUpdate, pgx.NamedArgs{
+			"user_ids": lockIDs,
+		})
+		if err != nil {
+			return err
+		}
+
+		accountIDs := make(map[uuid.UUID]uuid.UUID, len(lockIDs))
+		amounts := make(map[uuid.UUID]int64, len(lockIDs))
+		for rows.Next() {
+			var userID, accountID uuid.UUID
+			var amount int64
+			if err := rows.Scan(&userID, &accountID, &amount); err != nil {
+				rows.Close()
+				return err
+			}
+			accountIDs[userID] = accountID
+			amounts[userID] = amount
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		//проверяем наличие счета отправителя
+		userAmount, ok := amounts[fromUser]
+		if !ok {
+			return apperr.ErrSenderNotFound
+		}
+
+		// проверяем каждого получателя
+		errs := make([]error, len(transfers))
+		failed := false
+		var total int64
+		for i, t := range transfers {
+			toUser, ok := userIDs[t.ToUser]
+			switch {
+			case !ok || accountIDs[toUser] == uuid.Nil:
+				errs[i] = apperr.ErrRecipientNotFound
+			case toUser == fromUser:
+				errs[i] = apperr.ErrSenderAndRecipientAreTheSame
+			}
+			failed = failed || errs[i] != nil
+			total += t.Amount
+		}
+		if failed {
+			return &apperr.BatchValidationError{Errors: errs}
+		}
+
+		// проверяем баланс отправителя на всю сумму пакета
+		if userAmount < total {
+			return apperr.ErrInsufficientFunds
+		}
+
+		// проверяем лимиты для каждого перевода с учетом предыдущих переводов пакета
+		limits, sent, err := r.transferLimits(ctx, tx, fromUser)
+		if err != nil {
+			return err
+		}
+		batchSent := sent
+		for _, t := range transfers {
+			if !limits.Allows(batchSent, t.Amount) {
+				return &apperr.TransferLimitError{Remaining: limits.Remaining(sent)}
+			}
+			batchSent += t.Amount
+		}
+
+		for _, t := range transfers {
+			toUser := userIDs[t.ToUser]
+			if _, err := insertTransfer(ctx, tx, fromUser, toUser, accountIDs[fromUser], accountIDs[toUser], t); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
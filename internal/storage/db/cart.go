@@ -0,0 +1,149 @@
+package db
+
+import (
+	"context"
+
+	"github.com/gofrs/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/plasmatrip/avito_merch/internal/apperr"
+	"github.com/plasmatrip/avito_merch/internal/model"
+	"github.com/plasmatrip/avito_merch/internal/storage/db/queries"
+)
+
+// Cart возвращает корзину пользователя со стоимостью по текущим ценам
+func (r PostgresDB) Cart(ctx context.Context, userID uuid.UUID) (model.Cart, error) {
+	rows, err := r.DB.Query(ctx, queries.SelectCart, pgx.NamedArgs{
+		"user_id": userID,
+	})
+	if err != nil {
+		return model.Cart{}, err
+	}
+	defer rows.Close()
+
+	cart := model.Cart{Items: make([]model.CartItem, 0)}
+	for rows.Next() {
+		item := model.CartItem{}
+
+		err := rows.Scan(
+			&item.Name,
+			&item.Quantity,
+			&item.Price,
+			&item.AddedPrice,
+			&item.Available,
+		)
+		if err != nil {
+			return model.Cart{}, err
+		}
+
+		item.PriceChanged = item.Price != item.AddedPrice
+		item.Total = item.Price * int64(item.Quantity)
+
+		cart.Items = append(cart.Items, item)
+		cart.Total += item.Total
+		cart.PriceChanged = cart.PriceChanged || item.PriceChanged
+	}
+
+	return cart, rows.Err()
+}
+
+// SetCartItem кладет мерч в корзину или изменяет его количество, запоминая текущую цену
+func (r PostgresDB) SetCartItem(ctx context.Context, userID uuid.UUID, name string, quantity int) (model.Cart, error) {
+	item, err := r.MerchItem(ctx, name)
+	if err != nil {
+		return model.Cart{}, err
+	}
+
+	if !item.Available {
+		return model.Cart{}, apperr.ErrItemNotAvailable
+	}
+
+	_, err = r.DB.Exec(ctx, queries.UpsertCartItem, pgx.NamedArgs{
+		"user_id":  userID,
+		"name":     name,
+		"quantity": quantity,
+	})
+	if err != nil {
+		return model.Cart{}, err
+	}
+
+	return r.Cart(ctx, userID)
+}
+
+// RemoveCartItem убирает мерч из корзины
+func (r PostgresDB) RemoveCartItem(ctx context.Context, userID uuid.UUID, name string) (model.Cart, error) {
+	_, err := r.DB.Exec(ctx, queries.DeleteCartItem, pgx.NamedArgs{
+		"user_id": userID,
+		"name":    name,
+	})
+	if err != nil {
+		return model.Cart{}, err
+	}
+
+	return r.Cart(ctx, userID)
+}
+
+// ClearCart очищает корзину
+func (r PostgresDB) ClearCart(ctx context.Context, userID uuid.UUID) error {
+	_, err := r.DB.Exec(ctx, queries.ClearCart, pgx.NamedArgs{
+		"user_id": userID,
+	})
+	return err
+}
+
+// Checkout оформляет заказ из корзины и очищает ее. Если цена мерча изменилась
+// после добавления в корзину, заказ оформляется только с acceptPriceChanges,
+// promo - необязательный промокод
+func (r PostgresDB) Checkout(ctx context.Context, userID uuid.UUID, acceptPriceChanges bool, promo string) (model.Order, error) {
+	var order model.Order
+
+	err := r.inTx(ctx, func(tx pgx.Tx) error {
+		// блокируем корзину, чтобы ее нельзя было оформить дважды
+		rows, err := tx.Query(ctx, queries.SelectCartForUpdate, pgx.NamedArgs{
+			"user_id": userID,
+		})
+		if err != nil {
+			return err
+		}
+
+		var items []model.OrderItem
+		prices := make(map[string]int64)
+		for rows.Next() {
+			var item model.OrderItem
+			var price int64
+			if err := rows.Scan(&item.Name, &item.Quantity, &price); err != nil {
+				rows.Close()
+				return err
+			}
+			items = append(items, item)
+			prices[item.Name] = price
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		if len(items) == 0 {
+			return apperr.ErrCartEmpty
+		}
+
+		if acceptPriceChanges {
+			prices = nil
+		}
+
+		order, err = r.placeOrder(ctx, tx, userID, items, prices, promo)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, queries.ClearCart, pgx.NamedArgs{
+			"user_id": userID,
+		})
+		return err
+	})
+	if err != nil {
+		return model.Order{}, err
+	}
+
+	return order, nil
+}
@@ -0,0 +1,178 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/rgurov/pgerrors"
+
+	"github.com/plasmatrip/avito_merch/internal/apperr"
+	"github.com/plasmatrip/avito_merch/internal/model"
+	"github.com/plasmatrip/avito_merch/internal/storage/db/queries"
+)
+
+// CreateCoinRequest создает запрос монет пользователя userID у пользователя req.FromUser, действующий в течение ttl
+func (r PostgresDB) CreateCoinRequest(ctx context.Context, userID uuid.UUID, req model.NewCoinRequest, ttl time.Duration) (model.CoinRequest, error) {
+	var payerID uuid.UUID
+	err := r.DB.QueryRow(ctx, queries.SelectUserID, pgx.NamedArgs{
+		"login": req.FromUser,
+	}).Scan(&payerID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return model.CoinRequest{}, apperr.ErrUserNotFound
+		}
+		return model.CoinRequest{}, err
+	}
+
+	if payerID == userID {
+		return model.CoinRequest{}, apperr.ErrSenderAndRecipientAreTheSame
+	}
+
+	var message *string
+	if req.Message != "" {
+		message = &req.Message
+	}
+
+	date := time.Now()
+	request, err := scanCoinRequest(r.DB.QueryRow(ctx, queries.InsertCoinRequest, pgx.NamedArgs{
+		"date":         date,
+		"requester_id": userID,
+		"payer_id":     payerID,
+		"amount":       req.Amount,
+		"message":      message,
+		"expires_at":   date.Add(ttl),
+	}))
+	if err != nil {
+		if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == pgerrors.ForeignKeyViolation {
+			return model.CoinRequest{}, apperr.ErrAccountNotFound
+		}
+		return model.CoinRequest{}, err
+	}
+
+	return request, nil
+}
+
+// CoinRequests возвращает ожидающие решения запросы монет у пользователя, от старых к новым
+func (r PostgresDB) CoinRequests(ctx context.Context, userID uuid.UUID) ([]model.CoinRequest, error) {
+	rows, err := r.DB.Query(ctx, queries.SelectCoinRequests, pgx.NamedArgs{
+		"user_id": userID,
+		"now":     time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	requests := make([]model.CoinRequest, 0)
+	for rows.Next() {
+		request, err := scanCoinRequest(rows)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, request)
+	}
+
+	return requests, rows.Err()
+}
+
+// ApproveCoinRequest одобряет запрос монет и переводит монеты запросившему пользователю
+func (r PostgresDB) ApproveCoinRequest(ctx context.Context, userID, requestID uuid.UUID) (model.CoinRequest, error) {
+	return r.resolveCoinRequest(ctx, userID, requestID, model.CoinRequestApproved)
+}
+
+// DeclineCoinRequest отклоняет запрос монет
+func (r PostgresDB) DeclineCoinRequest(ctx context.Context, userID, requestID uuid.UUID) (model.CoinRequest, error) {
+	return r.resolveCoinRequest(ctx, userID, requestID, model.CoinRequestDeclined)
+}
+
+// resolveCoinRequest переводит ожидающий запрос монет в состояние status
+func (r PostgresDB) resolveCoinRequest(ctx context.Context, userID, requestID uuid.UUID, status string) (model.CoinRequest, error) {
+	var request model.CoinRequest
+
+	err := r.inTx(ctx, func(tx pgx.Tx) error {
+		// блокируем запрос, чтобы его нельзя было одобрить дважды
+		var err error
+		request, err = scanCoinRequest(tx.QueryRow(ctx, queries.SelectCoinRequestForUpdate, pgx.NamedArgs{
+			"id":      requestID,
+			"user_id": userID,
+		}))
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return apperr.ErrCoinRequestNotFound
+			}
+			return err
+		}
+
+		switch request.Status {
+		case model.CoinRequestPending:
+		case model.CoinRequestExpired:
+			return apperr.ErrCoinRequestExpired
+		default:
+			return apperr.ErrCoinRequestResolved
+		}
+
+		// одобренный запрос исполняется обычным переводом
+		var transactionID *uuid.UUID
+		if status == model.CoinRequestApproved {
+			id, err := r.transfer(ctx, tx, userID, model.SendCoinRequest{
+				ToUser:  request.Requester,
+				Amount:  request.Amount,
+				Message: request.Message,
+			})
+			if err != nil {
+				return err
+			}
+			transactionID = &id
+		}
+
+		resolvedAt := time.Now()
+		_, err = tx.Exec(ctx, queries.ResolveCoinRequest, pgx.NamedArgs{
+			"id":             requestID,
+			"status":         status,
+			"resolved_at":    resolvedAt,
+			"transaction_id": transactionID,
+		})
+		if err != nil {
+			return err
+		}
+
+		request.Status = status
+		request.ResolvedAt = &resolvedAt
+
+		return nil
+	})
+	if err != nil {
+		return model.CoinRequest{}, err
+	}
+
+	return request, nil
+}
+
+// scanCoinRequest читает запрос монет, ожидающий запрос с истекшим сроком считается просроченным
+func scanCoinRequest(row pgx.Row) (model.CoinRequest, error) {
+	var request model.CoinRequest
+
+	err := row.Scan(
+		&request.ID,
+		&request.Date,
+		&request.Requester,
+		&request.Payer,
+		&request.Amount,
+		&request.Message,
+		&request.Status,
+		&request.ExpiresAt,
+		&request.ResolvedAt,
+	)
+	if err != nil {
+		return model.CoinRequest{}, err
+	}
+
+	if request.Status == model.CoinRequestPending && !time.Now().Before(request.ExpiresAt) {
+		request.Status = model.CoinRequestExpired
+	}
+
+	return request, nil
+}
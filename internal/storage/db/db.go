@@ -15,7 +15,9 @@ import (
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	"github.com/golang-migrate/migrate/v4/source/iofs"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rgurov/pgerrors"
 
 	"github.com/plasmatrip/avito_merch/internal/apperr"
 	"github.com/plasmatrip/avito_merch/internal/logger"
@@ -27,14 +29,58 @@ import (
 type DB interface {
 	Ping(ctx context.Context) error
 	UserAuth(ctx context.Context, userLogin model.AuthRequest) (uuid.UUID, error)
-	BuyItem(ctx context.Context, userID uuid.UUID, item string) error
+	BuyItem(ctx context.Context, userID uuid.UUID, item, promo string) error
+	GiftItem(ctx context.Context, userID uuid.UUID, req model.GiftRequest) error
+	TransferItems(ctx context.Context, userID uuid.UUID, req model.ItemTransferRequest) (model.ItemTransfer, error)
+	ItemTransfers(ctx context.Context, userID uuid.UUID) ([]model.ItemTransfer, error)
+	PlaceOrder(ctx context.Context, userID uuid.UUID, items []model.OrderItem, promo string) (model.Order, error)
+	Cart(ctx context.Context, userID uuid.UUID) (model.Cart, error)
+	SetCartItem(ctx context.Context, userID uuid.UUID, name string, quantity int) (model.Cart, error)
+	RemoveCartItem(ctx context.Context, userID uuid.UUID, name string) (model.Cart, error)
+	ClearCart(ctx context.Context, userID uuid.UUID) error
+	Checkout(ctx context.Context, userID uuid.UUID, acceptPriceChanges bool, promo string) (model.Order, error)
+	Merch(ctx context.Context, filter model.MerchFilter) ([]model.MerchItem, error)
+	MerchItem(ctx context.Context, name string) (model.MerchItem, error)
+	CreateMerch(ctx context.Context, item model.MerchItem) (model.MerchItem, error)
+	UpdateMerch(ctx context.Context, name string, req model.MerchRequest) (model.MerchItem, error)
+	SetStock(ctx context.Context, name string, stock *int) (model.MerchItem, error)
+	Restock(ctx context.Context, name string, quantity int) (model.MerchItem, error)
+	CreatePromoCode(ctx context.Context, promo model.PromoCode) (model.PromoCode, error)
+	PromoCodes(ctx context.Context) ([]model.PromoCode, error)
+	PriceHistory(ctx context.Context, name string) ([]model.PricePoint, error)
+	Purchases(ctx context.Context, userID uuid.UUID, limit int) ([]model.Purchase, error)
+	RefundPurchase(ctx context.Context, userID, purchaseID uuid.UUID, window time.Duration) error
+	GrantCoins(ctx context.Context, grant model.GrantRequest) (int, error)
+	SetTransferLimits(ctx context.Context, login string, req model.TransferLimitsRequest) (model.TransferLimits, error)
+	ResetTransferLimits(ctx context.Context, login string) (model.TransferLimits, error)
 	SendCoin(ctx context.Context, fromUser uuid.UUID, userSendCoin model.SendCoinRequest) error
+	SendCoinBatch(ctx context.Context, fromUser uuid.UUID, transfers []model.SendCoinRequest) error
+	CreateCoinRequest(ctx context.Context, userID uuid.UUID, req model.NewCoinRequest, ttl time.Duration) (model.CoinRequest, error)
+	CoinRequests(ctx context.Context, userID uuid.UUID) ([]model.CoinRequest, error)
+	ApproveCoinRequest(ctx context.Context, userID, requestID uuid.UUID) (model.CoinRequest, error)
+	DeclineCoinRequest(ctx context.Context, userID, requestID uuid.UUID) (model.CoinRequest, error)
 	Info(ctx context.Context, userID uuid.UUID) (model.InfoResponse, error)
+	TransactionHistory(ctx context.Context, userID uuid.UUID, filter model.HistoryFilter) (model.HistoryPage, error)
+	Wishlist(ctx context.Context, userID uuid.UUID) (model.Wishlist, error)
+	AddWishlistItem(ctx context.Context, userID uuid.UUID, name string) (model.Wishlist, error)
+	RemoveWishlistItem(ctx context.Context, userID uuid.UUID, name string) (model.Wishlist, error)
+	Notifications(ctx context.Context, userID uuid.UUID, limit int) ([]model.Notification, error)
+	ReadNotifications(ctx context.Context, userID uuid.UUID) error
+	Leaderboard(ctx context.Context, filter model.LeaderboardFilter) (model.Leaderboard, error)
+	SetLeaderboardHidden(ctx context.Context, userID uuid.UUID, hidden bool) error
+	AcquireIdempotencyKey(ctx context.Context, userID uuid.UUID, key, request string, ttl time.Duration) (*model.IdempotentResponse, error)
+	SaveIdempotentResponse(ctx context.Context, userID uuid.UUID, key string, resp model.IdempotentResponse) error
+	ReleaseIdempotencyKey(ctx context.Context, userID uuid.UUID, key string) error
 }
 
 type PostgresDB struct {
-	DB  *pgxpool.Pool
-	Log logger.Logger
+	DB     *pgxpool.Pool
+	Log    logger.Logger
+	Bonus  model.BonusPolicy    // начисления новому пользователю
+	Limits model.TransferLimits // лимиты переводов по умолчанию
+
+	issuanceAccountID     uuid.UUID // системный счет выпуска монет
+	storeRevenueAccountID uuid.UUID // системный счет выручки магазина
 }
 
 func NewRepository(ctx context.Context, dsn string, log logger.Logger) (*PostgresDB, error) {
@@ -57,10 +103,24 @@ func NewRepository(ctx context.Context, dsn string, log logger.Logger) (*Postgre
 		return nil, err
 	}
 
-	return &PostgresDB{
-		DB:  db,
-		Log: log,
-	}, nil
+	r := &PostgresDB{
+		DB:    db,
+		Log:   log,
+		Bonus: model.BonusPolicy{StartingBalance: model.DefaultStartingBalance},
+	}
+
+	// загружаем идентификаторы системных счетов
+	for kind, id := range map[string]*uuid.UUID{
+		model.AccountIssuance:     &r.issuanceAccountID,
+		model.AccountStoreRevenue: &r.storeRevenueAccountID,
+	} {
+		if err := db.QueryRow(ctx, queries.SelectSystemAccount, pgx.NamedArgs{"kind": kind}).Scan(id); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to load system account %s: %w", kind, err)
+		}
+	}
+
+	return r, nil
 }
 
 //go:embed migrations/*.sql
@@ -91,6 +151,20 @@ func (r PostgresDB) Ping(ctx context.Context) error {
 	return r.DB.Ping(ctx)
 }
 
+// post записывает в журнал сбалансированную пару проводок:
+// списание amount со счета debitAccountID и зачисление на счет creditAccountID
+func post(ctx context.Context, tx pgx.Tx, operation string, operationID, debitAccountID, creditAccountID uuid.UUID, amount int64) error {
+	_, err := tx.Exec(ctx, queries.InsertPostings, pgx.NamedArgs{
+		"operation":         operation,
+		"operation_id":      operationID,
+		"debit_account_id":  debitAccountID,
+		"debit_amount":      -amount,
+		"credit_account_id": creditAccountID,
+		"credit_amount":     amount,
+	})
+	return err
+}
+
 // Close закрывает подключение к БД
 func (r PostgresDB) Close() {
 	r.DB.Close()
@@ -137,38 +211,125 @@ func (r PostgresDB) UserAuth(ctx context.Context, userLogin model.AuthRequest) (
 
 	var id uuid.UUID
 
-	err = r.DB.QueryRow(ctx, queries.InsertUser, pgx.NamedArgs{
-		"date":     time.Now(),
-		"login":    userLogin.UserName,
-		"password": hash,
-	}).Scan(&id)
+	err = r.inTx(ctx, func(tx pgx.Tx) error {
+		var accountID uuid.UUID
+
+		date := time.Now()
+
+		err := tx.QueryRow(ctx, queries.InsertUser, pgx.NamedArgs{
+			"date":     date,
+			"login":    userLogin.UserName,
+			"password": hash,
+		}).Scan(&id, &accountID)
+		if err != nil {
+			return err
+		}
+
+		// начисляем стартовые монеты со счета выпуска
+		if r.Bonus.StartingBalance > 0 {
+			err = post(ctx, tx, model.OperationBonus, uuid.Must(uuid.NewV4()), r.issuanceAccountID, accountID, r.Bonus.StartingBalance)
+			if err != nil {
+				return err
+			}
+		}
+
+		// начисляем бонусы по правилам, название правила сохраняется как причина начисления
+		for _, rule := range r.Bonus.Bonuses(date) {
+			var grantID uuid.UUID
+
+			err := tx.QueryRow(ctx, queries.InsertGrant, pgx.NamedArgs{
+				"date":    date,
+				"user_id": id,
+				"amount":  rule.Amount,
+				"reason":  rule.Name,
+				"period":  rule.Period(),
+			}).Scan(&grantID)
+			if err != nil {
+				return err
+			}
+
+			err = post(ctx, tx, model.OperationWelcome, grantID, r.issuanceAccountID, accountID, rule.Amount)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
 
 	if err != nil {
+		// логин занят пользователем с другим паролем
+		if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == pgerrors.UniqueViolation {
+			return uuid.Nil, apperr.ErrAuthenticationError
+		}
 		return uuid.Nil, err
 	}
 
 	return id, nil
 }
 
-// BuyItem обработка запороса покупки мерча
-func (r PostgresDB) BuyItem(ctx context.Context, userID uuid.UUID, item string) error {
-	var item_id uuid.UUID
-	var item_price int
+// BuyItem обработка запороса покупки мерча, promo - необязательный промокод
+func (r PostgresDB) BuyItem(ctx context.Context, userID uuid.UUID, item, promo string) error {
+	return r.inTx(ctx, func(tx pgx.Tx) error {
+		return r.buyItem(ctx, tx, userID, userID, item, nil, promo)
+	})
+}
 
-	err := r.DB.QueryRow(ctx, queries.SelectItem, pgx.NamedArgs{
-		"item_name": item,
-	}).Scan(&item_id, &item_price)
-	if err != nil {
-		if err == pgx.ErrNoRows {
-			return apperr.ErrItemNotFound
+// GiftItem покупает мерч за счет пользователя и передает его в инвентарь получателя
+func (r PostgresDB) GiftItem(ctx context.Context, userID uuid.UUID, req model.GiftRequest) error {
+	return r.inTx(ctx, func(tx pgx.Tx) error {
+		var ownerID uuid.UUID
+		err := tx.QueryRow(ctx, queries.SelectUserID, pgx.NamedArgs{
+			"login": req.ToUser,
+		}).Scan(&ownerID)
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return apperr.ErrRecipientNotFound
+			}
+			return err
 		}
+
+		if ownerID == userID {
+			return apperr.ErrSenderAndRecipientAreTheSame
+		}
+
+		var message *string
+		if req.Message != "" {
+			message = &req.Message
+		}
+
+		return r.buyItem(ctx, tx, userID, ownerID, req.Item, message, "")
+	})
+}
+
+// buyItem покупает мерч за счет userID в инвентарь ownerID в рамках транзакции tx
+func (r PostgresDB) buyItem(ctx context.Context, tx pgx.Tx, userID, ownerID uuid.UUID, item string, message *string, code string) error {
+	// промокод блокируем раньше мерча, как и при оформлении заказа
+	promo, err := r.lockPromo(ctx, tx, userID, code)
+	if err != nil {
 		return err
 	}
 
-	var user_anount int
-	err = r.DB.QueryRow(ctx, queries.SelectAccount, pgx.NamedArgs{
+	itemID, itemPrice, itemCategory, err := reserveItem(ctx, tx, item, 1)
+	if err != nil {
+		return err
+	}
+
+	var discount int64
+	if promo != nil {
+		if !promo.Applies(item, itemCategory) {
+			return apperr.ErrPromoNotApplicable
+		}
+		discount = promo.Discount(itemPrice)
+	}
+	price := itemPrice - discount
+
+	// блокируем счет до конца транзакции, чтобы параллельные покупки не прошли проверку баланса одновременно
+	var accountID uuid.UUID
+	var userAmount int64
+	err = tx.QueryRow(ctx, queries.SelectAccountForUpdate, pgx.NamedArgs{
 		"user_id": userID,
-	}).Scan(&user_anount)
+	}).Scan(&accountID, &userAmount)
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			return apperr.ErrAccountNotFound
@@ -176,117 +337,269 @@ func (r PostgresDB) BuyItem(ctx context.Context, userID uuid.UUID, item string)
 		return err
 	}
 
-	if user_anount < item_price {
+	if userAmount < price {
 		return apperr.ErrInsufficientFunds
 	}
 
-	ct, err := r.DB.Exec(ctx, queries.BuyItem, pgx.NamedArgs{
-		"user_id":   userID,
-		"item_name": item,
-		"price":     item_price,
-		"merch_id":  item_id,
-	})
+	var purchaseID uuid.UUID
+	err = tx.QueryRow(ctx, queries.InsertPurchase, pgx.NamedArgs{
+		"user_id":    userID,
+		"owner_id":   ownerID,
+		"merch_id":   itemID,
+		"order_id":   nil,
+		"message":    message,
+		"discount":   discount,
+		"promo_code": promoCode(promo),
+		"price_paid": price,
+	}).Scan(&purchaseID)
 	if err != nil {
 		return err
 	}
 
-	if ct.RowsAffected() == 0 {
-		return apperr.ErrMerchNotBought
+	if promo != nil {
+		if err := redeemPromo(ctx, tx, promo, userID, &purchaseID, nil); err != nil {
+			return err
+		}
 	}
 
-	return nil
+	// списываем стоимость мерча со скидкой в выручку магазина
+	return post(ctx, tx, model.OperationPurchase, purchaseID, accountID, r.storeRevenueAccountID, price)
 }
 
-// SendCoin обработка запороса отправки монет
-func (r PostgresDB) SendCoin(ctx context.Context, fromUser uuid.UUID, sendCoin model.SendCoinRequest) error {
-	var user_anount int
-
-	//проверяем наличие счета отправителя
-	err := r.DB.QueryRow(ctx, queries.SelectAccount, pgx.NamedArgs{
-		"user_id": fromUser,
-	}).Scan(&user_anount)
+// reserveItem проверяет, что мерч можно купить в количестве quantity, и уменьшает остаток
+// ограниченного мерча, возвращает цену, по которой мерч покупается; строка мерча
+// без ограничения остатка не блокируется, чтобы покупки не выстраивались в очередь
+func reserveItem(ctx context.Context, tx pgx.Tx, item string, quantity int) (uuid.UUID, int64, string, error) {
+	var itemID uuid.UUID
+	var itemPrice int64
+	var itemAvailable bool
+	var itemStock *int
+	var itemCategory string
+
+	err := tx.QueryRow(ctx, queries.SelectItem, pgx.NamedArgs{
+		"item_name": item,
+	}).Scan(&itemID, &itemPrice, &itemAvailable, &itemStock, &itemCategory)
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return apperr.ErrSenderNotFound
+			return uuid.Nil, 0, "", apperr.ErrItemNotFound
 		}
-		return err
+		return uuid.Nil, 0, "", err
 	}
 
-	// проверяем баланс у отправителя
-	if user_anount < sendCoin.Amount {
-		return apperr.ErrInsufficientFunds
+	if !itemAvailable {
+		return uuid.Nil, 0, "", apperr.ErrItemNotAvailable
+	}
+
+	if itemStock == nil {
+		return itemID, itemPrice, itemCategory, nil
 	}
 
+	// остаток проверяется и уменьшается одним запросом, списывается цена из того же запроса
+	err = tx.QueryRow(ctx, queries.DecrementStock, pgx.NamedArgs{
+		"id":       itemID,
+		"quantity": quantity,
+	}).Scan(&itemPrice)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return uuid.Nil, 0, "", apperr.ErrOutOfStock
+		}
+		return uuid.Nil, 0, "", err
+	}
+
+	return itemID, itemPrice, itemCategory, nil
+}
+
+// RefundPurchase возвращает покупку, совершенную не раньше window назад:
+// уплаченная цена возвращается на счет, остаток мерча восстанавливается
+func (r PostgresDB) RefundPurchase(ctx context.Context, userID, purchaseID uuid.UUID, window time.Duration) error {
+	return r.inTx(ctx, func(tx pgx.Tx) error {
+		var buyerID, ownerID, merchID uuid.UUID
+		var date time.Time
+		var paid int64
+		var code *string
+		var refundedAt *time.Time
+
+		// блокируем покупку и владение, чтобы ее нельзя было вернуть дважды или передать во время возврата
+		err := tx.QueryRow(ctx, queries.SelectPurchaseForUpdate, pgx.NamedArgs{
+			"id": purchaseID,
+		}).Scan(&buyerID, &ownerID, &merchID, &date, &paid, &code, &refundedAt)
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return apperr.ErrPurchaseNotFound
+			}
+			return err
+		}
+
+		// чужую покупку не раскрываем
+		if buyerID != userID {
+			return apperr.ErrPurchaseNotFound
+		}
+
+		if refundedAt != nil {
+			return apperr.ErrPurchaseAlreadyRefunded
+		}
+
+		// вернуть можно только мерч, который остался у покупателя
+		if ownerID != userID {
+			return apperr.ErrPurchaseNotOwned
+		}
+
+		now := time.Now()
+		if now.Sub(date) > window {
+			return apperr.ErrRefundWindowExpired
+		}
+
+		// у возврата своя операция в журнале, покупку он находит по refund_id
+		refundID := uuid.Must(uuid.NewV4())
+		_, err = tx.Exec(ctx, queries.RefundPurchase, pgx.NamedArgs{
+			"id":          purchaseID,
+			"refunded_at": now,
+			"refund_id":   refundID,
+		})
+		if err != nil {
+			return err
+		}
+
+		// возвращаем использование промокода, чтобы восстановились его лимиты
+		if code != nil {
+			if err := releasePromo(ctx, tx, *code, purchaseID); err != nil {
+				return err
+			}
+		}
+
+		// мерч блокируем раньше счета, как и при покупке
+		_, err = tx.Exec(ctx, queries.IncrementStock, pgx.NamedArgs{
+			"id": merchID,
+		})
+		if err != nil {
+			return err
+		}
+
+		var accountID uuid.UUID
+		var userAmount int64
+		err = tx.QueryRow(ctx, queries.SelectAccountForUpdate, pgx.NamedArgs{
+			"user_id": userID,
+		}).Scan(&accountID, &userAmount)
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return apperr.ErrAccountNotFound
+			}
+			return err
+		}
+
+		// возвращаем цену, списанную при покупке, а не текущую цену мерча
+		return post(ctx, tx, model.OperationRefund, refundID, r.storeRevenueAccountID, accountID, paid)
+	})
+}
+
+// SendCoin обработка запороса отправки монет
+func (r PostgresDB) SendCoin(ctx context.Context, fromUser uuid.UUID, sendCoin model.SendCoinRequest) error {
+	return r.inTx(ctx, func(tx pgx.Tx) error {
+		_, err := r.transfer(ctx, tx, fromUser, sendCoin)
+		return err
+	})
+}
+
+// transfer переводит монеты в транзакции tx и возвращает id перевода
+func (r PostgresDB) transfer(ctx context.Context, tx pgx.Tx, fromUser uuid.UUID, sendCoin model.SendCoinRequest) (uuid.UUID, error) {
 	var toUser uuid.UUID
-	//проверяем наличие счета получателя
-	err = r.DB.QueryRow(ctx, queries.SelectUserID, pgx.NamedArgs{
+
+	// ищем получателя, отсутствие получателя проверяем после проверок отправителя
+	err := tx.QueryRow(ctx, queries.SelectUserID, pgx.NamedArgs{
 		"login": sendCoin.ToUser,
 	}).Scan(&toUser)
+	if err != nil && err != pgx.ErrNoRows {
+		return uuid.Nil, err
+	}
+
+	// блокируем счета отправителя и получателя в порядке user_id, чтобы встречные переводы не приводили к взаимоблокировке
+	rows, err := tx.Query(ctx, queries.SelectAccountsForUpdate, pgx.NamedArgs{
+		"user_ids": []uuid.UUID{fromUser, toUser},
+	})
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return apperr.ErrRecipientNotFound
+		return uuid.Nil, err
+	}
+
+	accountIDs := make(map[uuid.UUID]uuid.UUID, 2)
+	amounts := make(map[uuid.UUID]int64, 2)
+	for rows.Next() {
+		var userID, accountID uuid.UUID
+		var amount int64
+		if err := rows.Scan(&userID, &accountID, &amount); err != nil {
+			rows.Close()
+			return uuid.Nil, err
 		}
-		return err
+		accountIDs[userID] = accountID
+		amounts[userID] = amount
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return uuid.Nil, err
 	}
 
-	// проверяем, что отправитель и получатель не один пользователь
-	if fromUser == toUser {
-		return apperr.ErrSenderAndRecipientAreTheSame
+	//проверяем наличие счета отправителя
+	userAmount, ok := amounts[fromUser]
+	if !ok {
+		return uuid.Nil, apperr.ErrSenderNotFound
 	}
 
-	// начинаем транзакцию
-	tx, err := r.DB.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.ReadCommitted, AccessMode: pgx.ReadWrite})
-	if err != nil {
-		return err
+	// проверяем баланс у отправителя
+	if userAmount < sendCoin.Amount {
+		return uuid.Nil, apperr.ErrInsufficientFunds
 	}
 
-	// при ошибке коммита откатываем назад
-	defer func() error {
-		return tx.Rollback(ctx)
-	}()
+	//проверяем наличие счета получателя
+	if _, ok := amounts[toUser]; !ok {
+		return uuid.Nil, apperr.ErrRecipientNotFound
+	}
 
-	// обновляем монеты у отправителя
-	_, err = tx.Exec(ctx, queries.UpdateCoin, pgx.NamedArgs{
-		"user_id": fromUser,
-		"amount":  -sendCoin.Amount,
-	})
-	if err != nil {
-		return err
+	// проверяем, что отправитель и получатель не один пользователь
+	if fromUser == toUser {
+		return uuid.Nil, apperr.ErrSenderAndRecipientAreTheSame
 	}
 
-	// обновляем монеты у получателя
-	_, err = tx.Exec(ctx, queries.UpdateCoin, pgx.NamedArgs{
-		"user_id": toUser,
-		"amount":  sendCoin.Amount,
-	})
+	// проверяем лимиты, счет отправителя заблокирован, поэтому параллельные переводы не превысят лимит
+	limits, sent, err := r.transferLimits(ctx, tx, fromUser)
 	if err != nil {
-		return err
+		return uuid.Nil, err
+	}
+	if !limits.Allows(sent, sendCoin.Amount) {
+		return uuid.Nil, &apperr.TransferLimitError{Remaining: limits.Remaining(sent)}
+	}
+
+	return insertTransfer(ctx, tx, fromUser, toUser, accountIDs[fromUser], accountIDs[toUser], sendCoin)
+}
+
+// insertTransfer записывает перевод и проводки по заблокированным счетам отправителя и получателя
+func insertTransfer(ctx context.Context, tx pgx.Tx, fromUser, toUser, fromAccountID, toAccountID uuid.UUID, sendCoin model.SendCoinRequest) (uuid.UUID, error) {
+	var message *string
+	if sendCoin.Message != "" {
+		message = &sendCoin.Message
 	}
 
 	// записываем информацию о транзакции
-	_, err = tx.Exec(ctx, queries.InsertTransaction, pgx.NamedArgs{
+	var transactionID uuid.UUID
+	err := tx.QueryRow(ctx, queries.InsertTransaction, pgx.NamedArgs{
 		"from_user_id": fromUser,
 		"to_user_id":   toUser,
 		"amount":       sendCoin.Amount,
-	})
+		"message":      message,
+	}).Scan(&transactionID)
 	if err != nil {
-		return err
-	}
-
-	err = tx.Commit(ctx)
-	if err != nil {
-		return err
+		return uuid.Nil, err
 	}
 
-	return nil
+	// переводим монеты со счета отправителя на счет получателя
+	err = post(ctx, tx, model.OperationTransfer, transactionID, fromAccountID, toAccountID, sendCoin.Amount)
+	return transactionID, err
 }
 
 // Info возвращает информацию о монетах, инвентаре и истории транзакций
 func (r PostgresDB) Info(ctx context.Context, userID uuid.UUID) (model.InfoResponse, error) {
 	var infoResponse model.InfoResponse
 
-	//проверяем наличие счета пользователя
-	err := r.DB.QueryRow(ctx, queries.SelectAccount, pgx.NamedArgs{
+	//проверяем наличие счета пользователя и считаем баланс по журналу проводок
+	err := r.DB.QueryRow(ctx, queries.SelectBalance, pgx.NamedArgs{
 		"user_id": userID,
 	}).Scan(&infoResponse.Coins)
 	if err != nil {
@@ -335,6 +648,7 @@ func (r PostgresDB) Info(ctx context.Context, userID uuid.UUID) (model.InfoRespo
 			&transaction.Login,
 			&transaction.Amount,
 			&transaction.Type,
+			&transaction.Messages,
 		)
 		if err != nil {
 			return model.InfoResponse{}, err
@@ -342,13 +656,15 @@ func (r PostgresDB) Info(ctx context.Context, userID uuid.UUID) (model.InfoRespo
 
 		if transaction.Type == "sent" {
 			infoResponse.CoinHistory.Sent = append(infoResponse.CoinHistory.Sent, model.Sent{
-				ToUser: transaction.Login,
-				Amount: transaction.Amount,
+				ToUser:   transaction.Login,
+				Amount:   transaction.Amount,
+				Messages: transaction.Messages,
 			})
 		} else {
 			infoResponse.CoinHistory.Received = append(infoResponse.CoinHistory.Received, model.Received{
 				FromUser: transaction.Login,
 				Amount:   transaction.Amount,
+				Messages: transaction.Messages,
 			})
 		}
 	}
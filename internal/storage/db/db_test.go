@@ -12,6 +12,7 @@ import (
 	"github.com/plasmatrip/avito_merch/internal/logger"
 	"github.com/plasmatrip/avito_merch/internal/model"
 	"github.com/plasmatrip/avito_merch/internal/storage/db"
+	"github.com/plasmatrip/avito_merch/internal/storage/storagetest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
@@ -88,6 +89,19 @@ func TestDBSuite(t *testing.T) {
 	suite.Run(t, new(DBTestSuite))
 }
 
+// запускаем общий набор тестов хранилища на том же контейнере
+func (suite *DBTestSuite) TestConformance() {
+	storagetest.Run(suite.T(), suite.db)
+}
+
+// начисления при регистрации проверяем на копии хранилища с тем же пулом соединений
+func (suite *DBTestSuite) TestBonusPolicy() {
+	stor := *suite.db
+	stor.Bonus = storagetest.BonusPolicy
+
+	storagetest.RunBonusPolicy(suite.T(), &stor)
+}
+
 func (suite *DBTestSuite) TestPing() {
 	err := suite.db.Ping(context.Background())
 	assert.NoError(suite.T(), err, "database connection check failed")
@@ -115,7 +129,7 @@ func (suite *DBTestSuite) TestBuyItemSuccess() {
 	assert.NoError(suite.T(), err, "an error occurred during user authorization")
 	assert.NotNil(suite.T(), userID, "non-zero id was returned")
 
-	err = suite.db.BuyItem(ctx, userID, itemName)
+	err = suite.db.BuyItem(ctx, userID, itemName, "")
 	assert.NoError(suite.T(), err, "an error occurred when buying an existing item")
 }
 
@@ -129,7 +143,7 @@ func (suite *DBTestSuite) TestBuyItemFail() {
 	assert.NoError(suite.T(), err, "an error occurred during user authorization")
 	assert.NotNil(suite.T(), userID, "non-zero id was returned")
 
-	err = suite.db.BuyItem(ctx, userID, "hummer")
+	err = suite.db.BuyItem(ctx, userID, "hummer", "")
 	assert.Error(suite.T(), err, "an error occurred when trying to buy a non-existent item")
 	assert.ErrorIs(suite.T(), err, apperr.ErrItemNotFound, "an unexpected error occurred when attempting to purchase a non-existent item")
 }
@@ -158,7 +172,7 @@ func (suite *DBTestSuite) TestSendCoin() {
 		testName   string
 		fromUserID uuid.UUID
 		toUser     string
-		amount     int
+		amount     int64
 		wantErr    error
 		f          func(t assert.TestingT, err error, msgAndArgs ...interface{}) bool
 	}{
@@ -226,12 +240,12 @@ func (suite *DBTestSuite) TestInfo() {
 	user1 := "boris"
 
 	user2 := "kevin"
-	user2Amount := 10
+	user2Amount := int64(10)
 
 	user3 := "ivan"
-	user3Amount := 25
+	user3Amount := int64(25)
 
-	user1InitialAmount := 1000
+	user1InitialAmount := int64(1000)
 
 	user1ID, err := suite.db.UserAuth(ctx, model.AuthRequest{
 		UserName: user1,
@@ -254,7 +268,7 @@ func (suite *DBTestSuite) TestInfo() {
 	assert.NoError(suite.T(), err, "an error occurred during user authorization")
 	assert.NotNil(suite.T(), user3ID, "non-zero id was returned")
 
-	err = suite.db.BuyItem(ctx, user1ID, itemName)
+	err = suite.db.BuyItem(ctx, user1ID, itemName, "")
 	assert.NoError(suite.T(), err, "an error occurred when buying an existing item")
 
 	err = suite.db.SendCoin(ctx, user1ID, model.SendCoinRequest{
@@ -287,3 +301,39 @@ func (suite *DBTestSuite) TestInfo() {
 	assert.ErrorIs(suite.T(), err, apperr.ErrAccountNotFound, "unexpected error when getting information about a non-existent user")
 	assert.Equal(suite.T(), emptyInfo, info, "unexpected response when getting information about a non-existent user")
 }
+
+// журнал проводок сбалансирован, а балансы счетов совпадают с суммой проводок
+func (suite *DBTestSuite) TestLedgerBalanced() {
+	ctx := context.Background()
+
+	fromUserID, err := suite.db.UserAuth(ctx, model.AuthRequest{UserName: "alice", Password: "alice"})
+	require.NoError(suite.T(), err, "an error occurred during user authorization")
+
+	_, err = suite.db.UserAuth(ctx, model.AuthRequest{UserName: "bob", Password: "bob"})
+	require.NoError(suite.T(), err, "an error occurred during user authorization")
+
+	require.NoError(suite.T(), suite.db.BuyItem(ctx, fromUserID, itemName, ""))
+	require.NoError(suite.T(), suite.db.SendCoin(ctx, fromUserID, model.SendCoinRequest{ToUser: "bob", Amount: 15}))
+
+	var unbalanced int
+	err = suite.db.DB.QueryRow(ctx, `
+		SELECT count(*) FROM (
+			SELECT operation_id FROM ledger_entries GROUP BY operation_id HAVING sum(amount) <> 0
+		) o
+	`).Scan(&unbalanced)
+	require.NoError(suite.T(), err)
+	assert.Zero(suite.T(), unbalanced, "every ledger operation must be balanced")
+
+	var mismatched int
+	err = suite.db.DB.QueryRow(ctx, `
+		SELECT count(*) FROM (
+			SELECT a.id FROM accounts a
+			LEFT JOIN ledger_entries l ON l.account_id = a.id
+			WHERE a.kind = 'user'
+			GROUP BY a.id, a.amount
+			HAVING a.amount <> COALESCE(sum(l.amount), 0)
+		) a
+	`).Scan(&mismatched)
+	require.NoError(suite.T(), err)
+	assert.Zero(suite.T(), mismatched, "account balances must match the ledger")
+}
@@ -0,0 +1,95 @@
+package db
+
+import (
+	"context"
+
+	"github.com/gofrs/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/plasmatrip/avito_merch/internal/apperr"
+	"github.com/plasmatrip/avito_merch/internal/model"
+	"github.com/plasmatrip/avito_merch/internal/storage/db/queries"
+)
+
+// GrantCoins начисляет монеты со счета выпуска перечисленным пользователям или всем сразу.
+// Пользователи, уже получившие начисление за указанный период, пропускаются,
+// возвращается количество получателей
+func (r PostgresDB) GrantCoins(ctx context.Context, grant model.GrantRequest) (int, error) {
+	var granted int
+
+	err := r.inTx(ctx, func(tx pgx.Tx) error {
+		var userIDs []uuid.UUID
+
+		if !grant.All {
+			var err error
+			userIDs, err = selectUserIDs(ctx, tx, grant.Users)
+			if err != nil {
+				return err
+			}
+		}
+
+		var period *string
+		if grant.Period != "" {
+			period = &grant.Period
+		}
+
+		rows, err := tx.Query(ctx, queries.InsertGrants, pgx.NamedArgs{
+			"amount":   grant.Amount,
+			"reason":   grant.Reason,
+			"period":   period,
+			"all":      grant.All,
+			"user_ids": userIDs,
+		})
+		if err != nil {
+			return err
+		}
+
+		grantIDs, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+		if err != nil {
+			return err
+		}
+
+		granted = len(grantIDs)
+		if granted == 0 {
+			return nil
+		}
+
+		// начисляем монеты со счета выпуска
+		_, err = tx.Exec(ctx, queries.InsertGrantPostings, pgx.NamedArgs{
+			"issuance_account_id": r.issuanceAccountID,
+			"grant_ids":           grantIDs,
+		})
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return granted, nil
+}
+
+// selectUserIDs возвращает id пользователей по логинам, если хотя бы один логин не найден - ошибку
+func selectUserIDs(ctx context.Context, tx pgx.Tx, logins []string) ([]uuid.UUID, error) {
+	rows, err := tx.Query(ctx, queries.SelectUserIDs, pgx.NamedArgs{
+		"logins": logins,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	userIDs, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		return nil, err
+	}
+
+	unique := make(map[string]struct{}, len(logins))
+	for _, login := range logins {
+		unique[login] = struct{}{}
+	}
+
+	if len(userIDs) != len(unique) {
+		return nil, apperr.ErrRecipientNotFound
+	}
+
+	return userIDs, nil
+}
@@ -0,0 +1,88 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/plasmatrip/avito_merch/internal/apperr"
+	"github.com/plasmatrip/avito_merch/internal/model"
+	"github.com/plasmatrip/avito_merch/internal/storage/db/queries"
+)
+
+// TransactionHistory возвращает страницу истории операций пользователя, от новых к старым
+func (r PostgresDB) TransactionHistory(ctx context.Context, userID uuid.UUID, filter model.HistoryFilter) (model.HistoryPage, error) {
+	var page model.HistoryPage
+
+	//проверяем наличие счета пользователя
+	var balance int64
+	err := r.DB.QueryRow(ctx, queries.SelectBalance, pgx.NamedArgs{
+		"user_id": userID,
+	}).Scan(&balance)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return page, apperr.ErrAccountNotFound
+		}
+		return page, err
+	}
+
+	// запрашиваем на одну запись больше, чтобы узнать о наличии следующей страницы
+	rows, err := r.DB.Query(ctx, queries.SelectHistory, pgx.NamedArgs{
+		"user_id":     userID,
+		"before":      filter.Before,
+		"direction":   filter.Direction,
+		"counterpart": filter.Counterpart,
+		"from":        nullTime(filter.From),
+		"to":          nullTime(filter.To),
+		"limit":       filter.Limit + 1,
+	})
+	if err != nil {
+		return page, err
+	}
+	defer rows.Close()
+
+	page.Entries = make([]model.HistoryEntry, 0, filter.Limit)
+	for rows.Next() {
+		if len(page.Entries) == filter.Limit {
+			page.Next = page.Entries[len(page.Entries)-1].Seq
+			break
+		}
+
+		entry := model.HistoryEntry{}
+		err := rows.Scan(
+			&entry.ID,
+			&entry.OperationID,
+			&entry.Seq,
+			&entry.Date,
+			&entry.Type,
+			&entry.Amount,
+			&entry.BalanceAfter,
+			&entry.Counterpart,
+			&entry.Description,
+			&entry.Item,
+		)
+		if err != nil {
+			return model.HistoryPage{}, err
+		}
+
+		entry.Direction = model.DirectionIn
+		if entry.Amount < 0 {
+			entry.Direction = model.DirectionOut
+			entry.Amount = -entry.Amount
+		}
+
+		page.Entries = append(page.Entries, entry)
+	}
+
+	return page, rows.Err()
+}
+
+// nullTime преобразует нулевое время в NULL
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
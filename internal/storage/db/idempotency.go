@@ -0,0 +1,89 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/plasmatrip/avito_merch/internal/apperr"
+	"github.com/plasmatrip/avito_merch/internal/model"
+	"github.com/plasmatrip/avito_merch/internal/storage/db/queries"
+)
+
+// AcquireIdempotencyKey резервирует ключ идемпотентности за запросом, ключи старше ttl резервируются заново
+func (r PostgresDB) AcquireIdempotencyKey(ctx context.Context, userID uuid.UUID, key, request string, ttl time.Duration) (*model.IdempotentResponse, error) {
+	now := time.Now()
+
+	var id uuid.UUID
+	err := r.DB.QueryRow(ctx, queries.AcquireIdempotencyKey, pgx.NamedArgs{
+		"user_id":        userID,
+		"key":            key,
+		"request":        request,
+		"date":           now,
+		"expired_before": now.Add(-ttl),
+	}).Scan(&id)
+	if err == nil {
+		return nil, nil
+	}
+	if err != pgx.ErrNoRows {
+		return nil, err
+	}
+
+	// ключ уже используется, проверяем состояние запроса
+	var savedRequest string
+	var status *int
+	var contentType *string
+	var body []byte
+	err = r.DB.QueryRow(ctx, queries.SelectIdempotencyKey, pgx.NamedArgs{
+		"user_id": userID,
+		"key":     key,
+	}).Scan(&savedRequest, &status, &contentType, &body)
+	if err != nil {
+		// ключ освободили между запросами, клиенту нужно повторить запрос
+		if err == pgx.ErrNoRows {
+			return nil, apperr.ErrIdempotencyKeyInProgress
+		}
+		return nil, err
+	}
+
+	if savedRequest != request {
+		return nil, apperr.ErrIdempotencyKeyReused
+	}
+
+	if status == nil {
+		return nil, apperr.ErrIdempotencyKeyInProgress
+	}
+
+	resp := &model.IdempotentResponse{
+		Status: *status,
+		Body:   body,
+	}
+	if contentType != nil {
+		resp.ContentType = *contentType
+	}
+
+	return resp, nil
+}
+
+// SaveIdempotentResponse сохраняет ответ на запрос с ключом идемпотентности
+func (r PostgresDB) SaveIdempotentResponse(ctx context.Context, userID uuid.UUID, key string, resp model.IdempotentResponse) error {
+	_, err := r.DB.Exec(ctx, queries.UpdateIdempotentResponse, pgx.NamedArgs{
+		"user_id":      userID,
+		"key":          key,
+		"status":       resp.Status,
+		"content_type": resp.ContentType,
+		"body":         resp.Body,
+	})
+	return err
+}
+
+// ReleaseIdempotencyKey освобождает ключ незавершенного запроса, чтобы его можно было повторить
+func (r PostgresDB) ReleaseIdempotencyKey(ctx context.Context, userID uuid.UUID, key string) error {
+	_, err := r.DB.Exec(ctx, queries.DeleteIdempotencyKey, pgx.NamedArgs{
+		"user_id": userID,
+		"key":     key,
+	})
+	return err
+}
@@ -0,0 +1,126 @@
+package db
+
+import (
+	"context"
+
+	"github.com/gofrs/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/plasmatrip/avito_merch/internal/apperr"
+	"github.com/plasmatrip/avito_merch/internal/model"
+	"github.com/plasmatrip/avito_merch/internal/storage/db/queries"
+)
+
+// TransferItems передает req.Quantity единиц мерча из инвентаря пользователя другому пользователю,
+// передаются единицы, полученные раньше остальных
+func (r PostgresDB) TransferItems(ctx context.Context, userID uuid.UUID, req model.ItemTransferRequest) (model.ItemTransfer, error) {
+	if req.Quantity <= 0 {
+		return model.ItemTransfer{}, apperr.ErrInvalidQuantity
+	}
+
+	transfer := model.ItemTransfer{
+		ToUser:   req.ToUser,
+		Item:     req.Item,
+		Quantity: req.Quantity,
+	}
+
+	err := r.inTx(ctx, func(tx pgx.Tx) error {
+		var toUser uuid.UUID
+		err := tx.QueryRow(ctx, queries.SelectUserID, pgx.NamedArgs{
+			"login": req.ToUser,
+		}).Scan(&toUser)
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return apperr.ErrRecipientNotFound
+			}
+			return err
+		}
+
+		if toUser == userID {
+			return apperr.ErrSenderAndRecipientAreTheSame
+		}
+
+		err = tx.QueryRow(ctx, queries.SelectLogin, pgx.NamedArgs{
+			"user_id": userID,
+		}).Scan(&transfer.FromUser)
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return apperr.ErrSenderNotFound
+			}
+			return err
+		}
+
+		// блокируем все единицы мерча отправителя, чтобы их нельзя было одновременно вернуть или передать еще раз:
+		// с LIMIT строка, которую успели вернуть или передать, не заменяется следующей и единиц не хватает
+		rows, err := tx.Query(ctx, queries.SelectOwnedItemsForUpdate, pgx.NamedArgs{
+			"user_id": userID,
+			"item":    req.Item,
+		})
+		if err != nil {
+			return err
+		}
+
+		purchaseIDs := make([]uuid.UUID, 0, req.Quantity)
+		var merchID uuid.UUID
+		for rows.Next() {
+			var purchaseID uuid.UUID
+			if err := rows.Scan(&purchaseID, &merchID); err != nil {
+				rows.Close()
+				return err
+			}
+			purchaseIDs = append(purchaseIDs, purchaseID)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		if len(purchaseIDs) < req.Quantity {
+			return apperr.ErrNotEnoughItems
+		}
+		// передаем единицы, полученные раньше остальных
+		purchaseIDs = purchaseIDs[:req.Quantity]
+
+		err = tx.QueryRow(ctx, queries.InsertItemTransfer, pgx.NamedArgs{
+			"from_user_id": userID,
+			"to_user_id":   toUser,
+			"merch_id":     merchID,
+			"quantity":     req.Quantity,
+		}).Scan(&transfer.ID, &transfer.Date)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, queries.InsertItemTransferUnits, pgx.NamedArgs{
+			"transfer_id":  transfer.ID,
+			"purchase_ids": purchaseIDs,
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, queries.UpdateItemOwner, pgx.NamedArgs{
+			"owner_id":     toUser,
+			"acquired_at":  transfer.Date,
+			"purchase_ids": purchaseIDs,
+		})
+		return err
+	})
+	if err != nil {
+		return model.ItemTransfer{}, err
+	}
+
+	return transfer, nil
+}
+
+// ItemTransfers возвращает передачи мерча, в которых участвовал пользователь, от новых к старым
+func (r PostgresDB) ItemTransfers(ctx context.Context, userID uuid.UUID) ([]model.ItemTransfer, error) {
+	rows, err := r.DB.Query(ctx, queries.SelectItemTransfers, pgx.NamedArgs{
+		"user_id": userID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByPos[model.ItemTransfer])
+}
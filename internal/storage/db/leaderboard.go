@@ -0,0 +1,55 @@
+package db
+
+import (
+	"context"
+
+	"github.com/gofrs/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/plasmatrip/avito_merch/internal/apperr"
+	"github.com/plasmatrip/avito_merch/internal/model"
+	"github.com/plasmatrip/avito_merch/internal/storage/db/queries"
+)
+
+// Leaderboard возвращает рейтинги пользователей за период, пользователи, отказавшиеся от участия, не учитываются
+func (r PostgresDB) Leaderboard(ctx context.Context, filter model.LeaderboardFilter) (model.Leaderboard, error) {
+	var board model.Leaderboard
+
+	for query, entries := range map[string]*[]model.LeaderboardEntry{
+		queries.SelectTopReceivers:  &board.Receivers,
+		queries.SelectTopSenders:    &board.Senders,
+		queries.SelectTopPurchasers: &board.Purchasers,
+	} {
+		rows, err := r.DB.Query(ctx, query, pgx.NamedArgs{
+			"since": nullTime(filter.Since),
+			"limit": filter.Limit,
+		})
+		if err != nil {
+			return model.Leaderboard{}, err
+		}
+
+		*entries, err = pgx.CollectRows(rows, pgx.RowToStructByPos[model.LeaderboardEntry])
+		if err != nil {
+			return model.Leaderboard{}, err
+		}
+	}
+
+	return board, nil
+}
+
+// SetLeaderboardHidden скрывает пользователя из рейтинга или возвращает его в рейтинг
+func (r PostgresDB) SetLeaderboardHidden(ctx context.Context, userID uuid.UUID, hidden bool) error {
+	ct, err := r.DB.Exec(ctx, queries.UpdateLeaderboardHidden, pgx.NamedArgs{
+		"user_id": userID,
+		"hidden":  hidden,
+	})
+	if err != nil {
+		return err
+	}
+
+	if ct.RowsAffected() == 0 {
+		return apperr.ErrUserNotFound
+	}
+
+	return nil
+}
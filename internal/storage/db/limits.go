@@ -0,0 +1,92 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/plasmatrip/avito_merch/internal/apperr"
+	"github.com/plasmatrip/avito_merch/internal/model"
+	"github.com/plasmatrip/avito_merch/internal/storage/db/queries"
+)
+
+// SetTransferLimits устанавливает индивидуальные лимиты переводов пользователя и возвращает действующие лимиты
+func (r PostgresDB) SetTransferLimits(ctx context.Context, login string, req model.TransferLimitsRequest) (model.TransferLimits, error) {
+	userID, err := r.selectUserID(ctx, login)
+	if err != nil {
+		return model.TransferLimits{}, err
+	}
+
+	_, err = r.DB.Exec(ctx, queries.UpsertTransferLimits, pgx.NamedArgs{
+		"user_id":      userID,
+		"date":         time.Now(),
+		"per_transfer": req.PerTransfer,
+		"daily":        req.Daily,
+	})
+	if err != nil {
+		return model.TransferLimits{}, err
+	}
+
+	return r.Limits.Override(req), nil
+}
+
+// ResetTransferLimits удаляет индивидуальные лимиты переводов пользователя и возвращает лимиты по умолчанию
+func (r PostgresDB) ResetTransferLimits(ctx context.Context, login string) (model.TransferLimits, error) {
+	userID, err := r.selectUserID(ctx, login)
+	if err != nil {
+		return model.TransferLimits{}, err
+	}
+
+	_, err = r.DB.Exec(ctx, queries.DeleteTransferLimits, pgx.NamedArgs{
+		"user_id": userID,
+	})
+	if err != nil {
+		return model.TransferLimits{}, err
+	}
+
+	return r.Limits, nil
+}
+
+// transferLimits возвращает действующие лимиты переводов пользователя и сумму его переводов за последние 24 часа
+func (r PostgresDB) transferLimits(ctx context.Context, tx pgx.Tx, userID uuid.UUID) (model.TransferLimits, int64, error) {
+	var override model.TransferLimitsRequest
+	err := tx.QueryRow(ctx, queries.SelectTransferLimits, pgx.NamedArgs{
+		"user_id": userID,
+	}).Scan(&override.PerTransfer, &override.Daily)
+	if err != nil && err != pgx.ErrNoRows {
+		return model.TransferLimits{}, 0, err
+	}
+
+	limits := r.Limits.Override(override)
+	if limits.Daily == 0 {
+		return limits, 0, nil
+	}
+
+	var sent int64
+	err = tx.QueryRow(ctx, queries.SelectSentSince, pgx.NamedArgs{
+		"user_id": userID,
+		"since":   time.Now().Add(-model.TransferLimitWindow),
+	}).Scan(&sent)
+	if err != nil {
+		return model.TransferLimits{}, 0, err
+	}
+
+	return limits, sent, nil
+}
+
+// selectUserID возвращает id пользователя по логину
+func (r PostgresDB) selectUserID(ctx context.Context, login string) (uuid.UUID, error) {
+	var userID uuid.UUID
+	err := r.DB.QueryRow(ctx, queries.SelectUserID, pgx.NamedArgs{
+		"login": login,
+	}).Scan(&userID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return uuid.Nil, apperr.ErrUserNotFound
+		}
+		return uuid.Nil, err
+	}
+	return userID, nil
+}
@@ -0,0 +1,160 @@
+package db
+
+import (
+	"context"
+
+	"github.com/gofrs/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/rgurov/pgerrors"
+
+	"github.com/plasmatrip/avito_merch/internal/apperr"
+	"github.com/plasmatrip/avito_merch/internal/model"
+	"github.com/plasmatrip/avito_merch/internal/storage/db/queries"
+)
+
+// Merch возвращает каталог мерча с учетом фильтра
+func (r PostgresDB) Merch(ctx context.Context, filter model.MerchFilter) ([]model.MerchItem, error) {
+	rows, err := r.DB.Query(ctx, queries.SelectMerch, pgx.NamedArgs{
+		"min_price": filter.MinPrice,
+		"max_price": filter.MaxPrice,
+		"sort":      filter.Sort,
+		"desc":      filter.Desc,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]model.MerchItem, 0)
+	for rows.Next() {
+		item := model.MerchItem{}
+
+		err := rows.Scan(
+			&item.Name,
+			&item.Price,
+			&item.Description,
+			&item.Available,
+			&item.Stock,
+			&item.Category,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// MerchItem возвращает товар каталога по названию
+func (r PostgresDB) MerchItem(ctx context.Context, name string) (model.MerchItem, error) {
+	var item model.MerchItem
+
+	err := r.DB.QueryRow(ctx, queries.SelectMerchItem, pgx.NamedArgs{
+		"name": name,
+	}).Scan(&item.Name, &item.Price, &item.Description, &item.Available, &item.Stock, &item.Category)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return model.MerchItem{}, apperr.ErrItemNotFound
+		}
+		return model.MerchItem{}, err
+	}
+
+	return item, nil
+}
+
+// CreateMerch добавляет товар в каталог
+func (r PostgresDB) CreateMerch(ctx context.Context, item model.MerchItem) (model.MerchItem, error) {
+	id, err := uuid.NewV4()
+	if err != nil {
+		return model.MerchItem{}, err
+	}
+
+	var created model.MerchItem
+	err = r.DB.QueryRow(ctx, queries.InsertMerch, pgx.NamedArgs{
+		"id":          id,
+		"name":        item.Name,
+		"price":       item.Price,
+		"description": item.Description,
+		"available":   item.Available,
+		"stock":       item.Stock,
+		"category":    item.Category,
+	}).Scan(&created.Name, &created.Price, &created.Description, &created.Available, &created.Stock, &created.Category)
+	if err != nil {
+		if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == pgerrors.UniqueViolation {
+			return model.MerchItem{}, apperr.ErrItemAlreadyExists
+		}
+		return model.MerchItem{}, err
+	}
+
+	return created, nil
+}
+
+// UpdateMerch изменяет заполненные в запросе поля товара,
+// снятый с продажи товар остается в каталоге для истории покупок
+func (r PostgresDB) UpdateMerch(ctx context.Context, name string, req model.MerchRequest) (model.MerchItem, error) {
+	var updated model.MerchItem
+	err := r.DB.QueryRow(ctx, queries.UpdateMerch, pgx.NamedArgs{
+		"name":        name,
+		"price":       req.Price,
+		"description": req.Description,
+		"available":   req.Available,
+		"stock":       req.Stock,
+		"category":    req.Category,
+	}).Scan(&updated.Name, &updated.Price, &updated.Description, &updated.Available, &updated.Stock, &updated.Category)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return model.MerchItem{}, apperr.ErrItemNotFound
+		}
+		return model.MerchItem{}, err
+	}
+
+	return updated, nil
+}
+
+// SetStock устанавливает остаток товара, nil - без ограничения
+func (r PostgresDB) SetStock(ctx context.Context, name string, stock *int) (model.MerchItem, error) {
+	var item model.MerchItem
+	err := r.DB.QueryRow(ctx, queries.SetStock, pgx.NamedArgs{
+		"name":  name,
+		"stock": stock,
+	}).Scan(&item.Name, &item.Price, &item.Description, &item.Available, &item.Stock, &item.Category)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return model.MerchItem{}, apperr.ErrItemNotFound
+		}
+		if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == pgerrors.NumericValueOutOfRange {
+			return model.MerchItem{}, apperr.ErrInvalidStock
+		}
+		return model.MerchItem{}, err
+	}
+
+	return item, nil
+}
+
+// Restock пополняет остаток ограниченного товара
+func (r PostgresDB) Restock(ctx context.Context, name string, quantity int) (model.MerchItem, error) {
+	var item model.MerchItem
+	err := r.DB.QueryRow(ctx, queries.Restock, pgx.NamedArgs{
+		"name":     name,
+		"quantity": quantity,
+	}).Scan(&item.Name, &item.Price, &item.Description, &item.Available, &item.Stock, &item.Category)
+	if err == nil {
+		return item, nil
+	}
+	// остаток не помещается в столбец stock
+	if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == pgerrors.NumericValueOutOfRange {
+		return model.MerchItem{}, apperr.ErrInvalidStock
+	}
+	if err != pgx.ErrNoRows {
+		return model.MerchItem{}, err
+	}
+
+	// товар не найден или его остаток не ограничен
+	if _, err := r.MerchItem(ctx, name); err != nil {
+		return model.MerchItem{}, err
+	}
+	return model.MerchItem{}, apperr.ErrStockIsUnlimited
+}
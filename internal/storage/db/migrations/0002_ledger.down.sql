@@ -0,0 +1,14 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS ledger_entries_balanced ON ledger_entries;
+DROP TRIGGER IF EXISTS ledger_entries_apply ON ledger_entries;
+DROP FUNCTION IF EXISTS ledger_check_balance();
+DROP FUNCTION IF EXISTS ledger_apply_entry();
+DROP TABLE IF EXISTS ledger_entries;
+
+DELETE FROM accounts WHERE kind <> 'user';
+DROP INDEX IF EXISTS accounts_system_kind;
+ALTER TABLE accounts DROP COLUMN IF EXISTS kind;
+ALTER TABLE accounts ALTER COLUMN user_id SET NOT NULL;
+
+COMMIT;
@@ -0,0 +1,93 @@
+BEGIN;
+
+-- system accounts have no owner, they are distinguished by kind
+ALTER TABLE accounts ALTER COLUMN user_id DROP NOT NULL;
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS kind varchar(32) NOT NULL DEFAULT 'user'; -- user, issuance, store_revenue
+CREATE UNIQUE INDEX IF NOT EXISTS accounts_system_kind ON accounts (kind) WHERE kind <> 'user';
+
+INSERT INTO accounts (id, user_id, amount, kind) VALUES
+(gen_random_uuid (), NULL, 0, 'issuance'), -- source of all coins in the system
+(gen_random_uuid (), NULL, 0, 'store_revenue'); -- coins spent on merch
+
+-- create tables and indexes for ledger
+CREATE TABLE IF NOT EXISTS ledger_entries (
+    id uuid NOT NULL UNIQUE PRIMARY KEY,
+    seq bigserial NOT NULL UNIQUE, -- posting order
+    date timestamp with time zone NOT NULL, -- posting date
+    operation varchar(32) NOT NULL, -- bonus, transfer, purchase, adjustment
+    operation_id uuid NOT NULL, -- transaction id, purchase id or generated id of the operation
+    account_id uuid NOT NULL REFERENCES accounts (id), -- account
+    amount money NOT NULL -- credit is positive, debit is negative
+);
+CREATE INDEX IF NOT EXISTS ledger_entries_operation_id ON ledger_entries (operation_id);
+CREATE INDEX IF NOT EXISTS ledger_entries_account_id_seq ON ledger_entries (account_id, seq);
+
+-- move existing history to the ledger in chronological order
+WITH operations AS (
+    SELECT gen_random_uuid () AS operation_id, u.date, 'bonus' AS operation,
+        (SELECT id FROM accounts WHERE kind = 'issuance') AS debit_account_id, a.id AS credit_account_id, 1000::money AS amount
+        FROM users u
+        JOIN accounts a ON a.user_id = u.id
+    UNION ALL
+    SELECT t.id, t.date, 'transfer', fa.id, ta.id, t.amount
+        FROM transactions t
+        JOIN accounts fa ON fa.user_id = t.from_user_id
+        JOIN accounts ta ON ta.user_id = t.to_user_id
+    UNION ALL
+    SELECT p.id, p.date, 'purchase', a.id, (SELECT id FROM accounts WHERE kind = 'store_revenue'), m.price
+        FROM purchases p
+        JOIN accounts a ON a.user_id = p.user_id
+        JOIN merch m ON m.id = p.merch_id
+)
+INSERT INTO ledger_entries (id, date, operation, operation_id, account_id, amount)
+SELECT gen_random_uuid (), date, operation, operation_id, account_id, amount
+FROM (
+    SELECT date, operation, operation_id, debit_account_id AS account_id, amount * -1 AS amount, 0 AS side FROM operations
+    UNION ALL
+    SELECT date, operation, operation_id, credit_account_id, amount, 1 FROM operations
+) postings
+ORDER BY date, operation_id, side;
+
+-- balances that do not match the history are kept as they are with an adjustment from issuance
+WITH diff AS (
+    SELECT gen_random_uuid () AS operation_id, a.id AS account_id, a.amount - COALESCE(sum(l.amount), 0::money) AS amount
+    FROM accounts a
+    LEFT JOIN ledger_entries l ON l.account_id = a.id
+    WHERE a.kind = 'user'
+    GROUP BY a.id, a.amount
+    HAVING a.amount <> COALESCE(sum(l.amount), 0::money)
+)
+INSERT INTO ledger_entries (id, date, operation, operation_id, account_id, amount)
+SELECT gen_random_uuid (), CURRENT_TIMESTAMP, 'adjustment', operation_id, (SELECT id FROM accounts WHERE kind = 'issuance'), amount * -1 FROM diff
+UNION ALL
+SELECT gen_random_uuid (), CURRENT_TIMESTAMP, 'adjustment', operation_id, account_id, amount FROM diff;
+
+-- user balances are derived from the ledger, system balances are computed on demand
+-- so that every purchase does not lock the same store_revenue row
+CREATE OR REPLACE FUNCTION ledger_apply_entry() RETURNS trigger AS $$
+BEGIN
+    UPDATE accounts SET amount = amount + NEW.amount WHERE id = NEW.account_id AND kind = 'user';
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER ledger_entries_apply
+    AFTER INSERT ON ledger_entries
+    FOR EACH ROW EXECUTE FUNCTION ledger_apply_entry();
+
+-- every operation must be balanced by the end of the transaction
+CREATE OR REPLACE FUNCTION ledger_check_balance() RETURNS trigger AS $$
+BEGIN
+    IF (SELECT sum(amount) FROM ledger_entries WHERE operation_id = NEW.operation_id) <> 0::money THEN
+        RAISE EXCEPTION 'unbalanced ledger operation %', NEW.operation_id USING ERRCODE = 'check_violation';
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER ledger_entries_balanced
+    AFTER INSERT ON ledger_entries
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION ledger_check_balance();
+
+COMMIT;
@@ -0,0 +1,26 @@
+BEGIN;
+
+ALTER TABLE accounts ALTER COLUMN amount DROP DEFAULT;
+ALTER TABLE accounts ALTER COLUMN amount TYPE money USING amount::numeric::money;
+ALTER TABLE accounts ALTER COLUMN amount SET DEFAULT '0';
+
+ALTER TABLE transactions ALTER COLUMN amount DROP DEFAULT;
+ALTER TABLE transactions ALTER COLUMN amount TYPE money USING amount::numeric::money;
+ALTER TABLE transactions ALTER COLUMN amount SET DEFAULT '0';
+
+ALTER TABLE merch ALTER COLUMN price DROP DEFAULT;
+ALTER TABLE merch ALTER COLUMN price TYPE money USING price::numeric::money;
+ALTER TABLE merch ALTER COLUMN price SET DEFAULT '0';
+
+ALTER TABLE ledger_entries ALTER COLUMN amount TYPE money USING amount::numeric::money;
+
+CREATE OR REPLACE FUNCTION ledger_check_balance() RETURNS trigger AS $$
+BEGIN
+    IF (SELECT sum(amount) FROM ledger_entries WHERE operation_id = NEW.operation_id) <> 0::money THEN
+        RAISE EXCEPTION 'unbalanced ledger operation %', NEW.operation_id USING ERRCODE = 'check_violation';
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+COMMIT;
@@ -0,0 +1,27 @@
+BEGIN;
+
+-- store coins as integer minor units instead of locale dependent money
+ALTER TABLE accounts ALTER COLUMN amount DROP DEFAULT;
+ALTER TABLE accounts ALTER COLUMN amount TYPE bigint USING amount::numeric::bigint;
+ALTER TABLE accounts ALTER COLUMN amount SET DEFAULT 0;
+
+ALTER TABLE transactions ALTER COLUMN amount DROP DEFAULT;
+ALTER TABLE transactions ALTER COLUMN amount TYPE bigint USING amount::numeric::bigint;
+ALTER TABLE transactions ALTER COLUMN amount SET DEFAULT 0;
+
+ALTER TABLE merch ALTER COLUMN price DROP DEFAULT;
+ALTER TABLE merch ALTER COLUMN price TYPE bigint USING price::numeric::bigint;
+ALTER TABLE merch ALTER COLUMN price SET DEFAULT 0;
+
+ALTER TABLE ledger_entries ALTER COLUMN amount TYPE bigint USING amount::numeric::bigint;
+
+CREATE OR REPLACE FUNCTION ledger_check_balance() RETURNS trigger AS $$
+BEGIN
+    IF (SELECT sum(amount) FROM ledger_entries WHERE operation_id = NEW.operation_id) <> 0 THEN
+        RAISE EXCEPTION 'unbalanced ledger operation %', NEW.operation_id USING ERRCODE = 'check_violation';
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+COMMIT;
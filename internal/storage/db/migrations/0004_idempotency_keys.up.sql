@@ -0,0 +1,16 @@
+BEGIN;
+
+-- create tables and indexes for idempotency keys
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    user_id uuid NOT NULL REFERENCES users (id), -- user id
+    key varchar(255) NOT NULL, -- Idempotency-Key header value
+    request varchar(255) NOT NULL, -- method and path the key was first used with
+    date timestamp with time zone NOT NULL, -- first request date
+    status integer, -- response status, null while the request is in progress
+    content_type varchar(255), -- response content type
+    body bytea, -- response body
+    PRIMARY KEY (user_id, key)
+);
+CREATE INDEX IF NOT EXISTS idempotency_keys_date ON idempotency_keys (date);
+
+COMMIT;
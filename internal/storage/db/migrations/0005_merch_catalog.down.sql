@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP INDEX IF EXISTS merch_price;
+
+ALTER TABLE merch
+    DROP COLUMN IF EXISTS available,
+    DROP COLUMN IF EXISTS description;
+
+COMMIT;
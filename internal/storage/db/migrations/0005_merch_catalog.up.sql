@@ -0,0 +1,26 @@
+BEGIN;
+
+-- catalog attributes of merch
+ALTER TABLE merch
+    ADD COLUMN IF NOT EXISTS description text NOT NULL DEFAULT '', -- merch description
+    ADD COLUMN IF NOT EXISTS available boolean NOT NULL DEFAULT true; -- merch can be bought
+
+-- describe the seeded merch
+UPDATE merch SET description = d.description
+FROM (VALUES
+    ('t-shirt', 'T-shirt with the Avito logo'),
+    ('cup', 'Ceramic cup'),
+    ('book', 'Notebook for ideas'),
+    ('pen', 'Ballpoint pen'),
+    ('powerbank', 'Portable charger'),
+    ('hoody', 'Warm hoody'),
+    ('umbrella', 'Folding umbrella'),
+    ('socks', 'Pair of socks'),
+    ('wallet', 'Leather wallet'),
+    ('pink-hoody', 'Pink hoody')
+) AS d (name, description)
+WHERE merch.name = d.name;
+
+CREATE INDEX IF NOT EXISTS merch_price ON merch (price);
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+-- role is granted by an administrator or on startup, never at registration
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS role varchar(16) NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'admin'));
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE merch
+    DROP COLUMN IF EXISTS stock;
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+-- limited merch, null stock means unlimited
+ALTER TABLE merch
+    ADD COLUMN IF NOT EXISTS stock integer CHECK (stock >= 0); -- units left in stock
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE purchases
+    DROP COLUMN IF EXISTS refunded_at;
+
+COMMIT;
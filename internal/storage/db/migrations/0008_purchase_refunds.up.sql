@@ -0,0 +1,8 @@
+BEGIN;
+
+-- refunded purchases stay for history but leave the inventory
+ALTER TABLE purchases
+    ADD COLUMN IF NOT EXISTS refunded_at timestamp with time zone, -- refund date, null if not refunded
+    ADD COLUMN IF NOT EXISTS refund_id uuid UNIQUE; -- ledger operation of the refund, null if not refunded
+
+COMMIT;
@@ -0,0 +1,10 @@
+BEGIN;
+
+DROP INDEX IF EXISTS purchases_order_id;
+
+ALTER TABLE purchases
+    DROP COLUMN IF EXISTS order_id;
+
+DROP TABLE IF EXISTS orders;
+
+COMMIT;
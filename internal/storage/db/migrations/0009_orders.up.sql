@@ -0,0 +1,17 @@
+BEGIN;
+
+-- create tables and indexes for orders
+CREATE TABLE IF NOT EXISTS orders (
+    id uuid NOT NULL UNIQUE PRIMARY KEY,
+    date timestamp with time zone NOT NULL, -- order date
+    user_id uuid NOT NULL REFERENCES users (id), -- user id
+    total bigint NOT NULL -- total price of the order
+);
+CREATE INDEX IF NOT EXISTS orders_user_id ON orders (user_id);
+
+-- every unit of an order is a separate purchase
+ALTER TABLE purchases
+    ADD COLUMN IF NOT EXISTS order_id uuid REFERENCES orders (id); -- order id, null for single purchases
+CREATE INDEX IF NOT EXISTS purchases_order_id ON purchases (order_id);
+
+COMMIT;
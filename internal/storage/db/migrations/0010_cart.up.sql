@@ -0,0 +1,13 @@
+BEGIN;
+
+-- create tables and indexes for carts
+CREATE TABLE IF NOT EXISTS cart_items (
+    user_id uuid NOT NULL REFERENCES users (id), -- user id
+    merch_id uuid NOT NULL REFERENCES merch (id), -- merch id
+    quantity integer NOT NULL CHECK (quantity > 0), -- units to buy
+    price bigint NOT NULL, -- merch price when the item was put in the cart
+    date timestamp with time zone NOT NULL, -- date the item was put in the cart
+    PRIMARY KEY (user_id, merch_id)
+);
+
+COMMIT;
@@ -0,0 +1,15 @@
+BEGIN;
+
+-- create tables and indexes for coin grants
+CREATE TABLE IF NOT EXISTS grants (
+    id uuid NOT NULL UNIQUE PRIMARY KEY,
+    date timestamp with time zone NOT NULL, -- grant date
+    user_id uuid NOT NULL REFERENCES users (id), -- user id
+    amount bigint NOT NULL CHECK (amount > 0), -- granted coins
+    reason text NOT NULL, -- reason shown in history
+    period varchar(64), -- grant period, at most one grant per user and period
+    UNIQUE (user_id, period)
+);
+CREATE INDEX IF NOT EXISTS grants_user_id ON grants (user_id);
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+-- optional message from the sender to the recipient
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS message varchar(255);
+
+COMMIT;
@@ -0,0 +1,21 @@
+BEGIN;
+
+-- create tables and indexes for coin requests
+CREATE TABLE IF NOT EXISTS coin_requests (
+    id uuid NOT NULL UNIQUE PRIMARY KEY,
+    date timestamp with time zone NOT NULL, -- request date
+    requester_id uuid NOT NULL REFERENCES users (id), -- receives coins on approval
+    payer_id uuid NOT NULL REFERENCES users (id), -- sends coins on approval
+    amount bigint NOT NULL CHECK (amount > 0), -- requested coins
+    message varchar(255), -- message to the payer
+    -- a pending request past expires_at is reported as expired
+    status varchar(16) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'declined')),
+    expires_at timestamp with time zone NOT NULL, -- pending request expiration date
+    resolved_at timestamp with time zone, -- approval or decline date
+    transaction_id uuid REFERENCES transactions (id), -- transfer made on approval
+    CHECK (requester_id <> payer_id)
+);
+CREATE INDEX IF NOT EXISTS coin_requests_payer_id ON coin_requests (payer_id, status);
+CREATE INDEX IF NOT EXISTS coin_requests_requester_id ON coin_requests (requester_id);
+
+COMMIT;
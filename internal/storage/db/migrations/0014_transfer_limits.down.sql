@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP INDEX IF EXISTS transactions_from_user_id_date;
+DROP TABLE IF EXISTS transfer_limits;
+
+COMMIT;
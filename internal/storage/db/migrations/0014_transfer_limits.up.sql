@@ -0,0 +1,14 @@
+BEGIN;
+
+-- per-user overrides of the default transfer limits, null means the default limit, 0 means unlimited
+CREATE TABLE IF NOT EXISTS transfer_limits (
+    user_id uuid NOT NULL UNIQUE PRIMARY KEY REFERENCES users (id),
+    date timestamp with time zone NOT NULL, -- override date
+    per_transfer bigint CHECK (per_transfer >= 0), -- maximum amount of a single transfer
+    daily bigint CHECK (daily >= 0) -- maximum amount sent in the last 24 hours
+);
+
+-- sent amounts are summed over a rolling window
+CREATE INDEX IF NOT EXISTS transactions_from_user_id_date ON transactions (from_user_id, date);
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP INDEX IF EXISTS purchases_date;
+DROP INDEX IF EXISTS transactions_date;
+ALTER TABLE users DROP COLUMN IF EXISTS leaderboard_hidden;
+
+COMMIT;
@@ -0,0 +1,10 @@
+BEGIN;
+
+-- users who opted out of the leaderboard
+ALTER TABLE users ADD COLUMN IF NOT EXISTS leaderboard_hidden boolean NOT NULL DEFAULT false;
+
+-- leaderboards are computed over a date window
+CREATE INDEX IF NOT EXISTS transactions_date ON transactions (date);
+CREATE INDEX IF NOT EXISTS purchases_date ON purchases (date);
+
+COMMIT;
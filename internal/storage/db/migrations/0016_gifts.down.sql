@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP INDEX IF EXISTS purchases_owner_id;
+
+ALTER TABLE purchases
+    DROP COLUMN IF EXISTS message,
+    DROP COLUMN IF EXISTS owner_id;
+
+COMMIT;
@@ -0,0 +1,14 @@
+BEGIN;
+
+-- a purchase may be a gift: the buyer pays, the owner receives the item
+ALTER TABLE purchases
+    ADD COLUMN IF NOT EXISTS owner_id uuid REFERENCES users (id), -- owner id, equals user_id unless the item is a gift
+    ADD COLUMN IF NOT EXISTS message varchar(255); -- optional greeting from the buyer to the owner
+
+UPDATE purchases SET owner_id = user_id WHERE owner_id IS NULL;
+
+ALTER TABLE purchases ALTER COLUMN owner_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS purchases_owner_id ON purchases (owner_id);
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP TABLE IF EXISTS item_transfer_units;
+DROP TABLE IF EXISTS item_transfers;
+DROP TABLE IF EXISTS item_ownership;
+
+COMMIT;
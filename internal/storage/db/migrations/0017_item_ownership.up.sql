@@ -0,0 +1,35 @@
+BEGIN;
+
+-- current owner of every purchased unit, purchases keep the buyer and the original owner
+CREATE TABLE IF NOT EXISTS item_ownership (
+    purchase_id uuid NOT NULL PRIMARY KEY REFERENCES purchases (id), -- purchased unit
+    owner_id uuid NOT NULL REFERENCES users (id), -- current owner
+    acquired_at timestamp with time zone NOT NULL -- date the owner received the unit
+);
+CREATE INDEX IF NOT EXISTS item_ownership_owner_id ON item_ownership (owner_id);
+
+INSERT INTO item_ownership (purchase_id, owner_id, acquired_at)
+SELECT id, owner_id, date FROM purchases
+ON CONFLICT (purchase_id) DO NOTHING;
+
+-- handovers of owned units between users
+CREATE TABLE IF NOT EXISTS item_transfers (
+    id uuid NOT NULL PRIMARY KEY,
+    date timestamp with time zone NOT NULL,
+    from_user_id uuid NOT NULL REFERENCES users (id), -- previous owner
+    to_user_id uuid NOT NULL REFERENCES users (id), -- new owner
+    merch_id uuid NOT NULL REFERENCES merch (id),
+    quantity integer NOT NULL CHECK (quantity > 0)
+);
+CREATE INDEX IF NOT EXISTS item_transfers_from_user_id ON item_transfers (from_user_id);
+CREATE INDEX IF NOT EXISTS item_transfers_to_user_id ON item_transfers (to_user_id);
+
+-- units handed over, the ownership history of a unit is the list of its handovers
+CREATE TABLE IF NOT EXISTS item_transfer_units (
+    transfer_id uuid NOT NULL REFERENCES item_transfers (id),
+    purchase_id uuid NOT NULL REFERENCES purchases (id),
+    PRIMARY KEY (transfer_id, purchase_id)
+);
+CREATE INDEX IF NOT EXISTS item_transfer_units_purchase_id ON item_transfer_units (purchase_id);
+
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS merch_wishlist_notifications ON merch;
+DROP FUNCTION IF EXISTS merch_notify_wishlist();
+DROP TABLE IF EXISTS notifications;
+DROP TABLE IF EXISTS wishlist;
+
+COMMIT;
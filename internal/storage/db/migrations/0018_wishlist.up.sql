@@ -0,0 +1,52 @@
+BEGIN;
+
+-- merch users are saving for
+CREATE TABLE IF NOT EXISTS wishlist (
+    user_id uuid NOT NULL REFERENCES users (id),
+    merch_id uuid NOT NULL REFERENCES merch (id),
+    date timestamp with time zone NOT NULL, -- date the item was added
+    PRIMARY KEY (user_id, merch_id)
+);
+CREATE INDEX IF NOT EXISTS wishlist_merch_id ON wishlist (merch_id);
+
+-- in-app notifications about wishlisted merch
+CREATE TABLE IF NOT EXISTS notifications (
+    id uuid NOT NULL PRIMARY KEY,
+    date timestamp with time zone NOT NULL,
+    user_id uuid NOT NULL REFERENCES users (id),
+    merch_id uuid NOT NULL REFERENCES merch (id),
+    type varchar(16) NOT NULL CHECK (type IN ('price_drop', 'restock')),
+    old_price bigint, -- price before the drop
+    price bigint NOT NULL, -- price at the moment of the notification
+    read_at timestamp with time zone -- null if not read
+);
+CREATE INDEX IF NOT EXISTS notifications_user_id ON notifications (user_id, date);
+
+-- every change of the catalog notifies users who wishlisted the item,
+-- an item comes back in stock when it becomes available for purchase again
+CREATE OR REPLACE FUNCTION merch_notify_wishlist() RETURNS trigger AS $$
+BEGIN
+    IF NEW.price < OLD.price THEN
+        INSERT INTO notifications (id, date, user_id, merch_id, type, old_price, price)
+        SELECT gen_random_uuid (), CURRENT_TIMESTAMP, w.user_id, NEW.id, 'price_drop', OLD.price, NEW.price
+        FROM wishlist w WHERE w.merch_id = NEW.id;
+    END IF;
+
+    IF NOT (OLD.available AND COALESCE(OLD.stock, 1) > 0) AND NEW.available AND COALESCE(NEW.stock, 1) > 0 THEN
+        INSERT INTO notifications (id, date, user_id, merch_id, type, price)
+        SELECT gen_random_uuid (), CURRENT_TIMESTAMP, w.user_id, NEW.id, 'restock', NEW.price
+        FROM wishlist w WHERE w.merch_id = NEW.id;
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER merch_wishlist_notifications
+    AFTER UPDATE OF price, available, stock ON merch
+    FOR EACH ROW
+    WHEN (NEW.price < OLD.price
+        OR (NOT (OLD.available AND COALESCE(OLD.stock, 1) > 0) AND NEW.available AND COALESCE(NEW.stock, 1) > 0))
+    EXECUTE FUNCTION merch_notify_wishlist();
+
+COMMIT;
@@ -0,0 +1,14 @@
+BEGIN;
+
+ALTER TABLE purchases
+    DROP COLUMN IF EXISTS promo_code,
+    DROP COLUMN IF EXISTS discount;
+
+DROP TABLE IF EXISTS promo_redemptions;
+DROP TABLE IF EXISTS promo_codes;
+
+DROP INDEX IF EXISTS merch_category;
+
+ALTER TABLE merch DROP COLUMN IF EXISTS category;
+
+COMMIT;
@@ -0,0 +1,56 @@
+BEGIN;
+
+-- merch category, promo codes may be limited to a category
+ALTER TABLE merch ADD COLUMN IF NOT EXISTS category varchar(64) NOT NULL DEFAULT '';
+
+UPDATE merch SET category = c.category
+FROM (VALUES
+    ('t-shirt', 'clothing'),
+    ('hoody', 'clothing'),
+    ('pink-hoody', 'clothing'),
+    ('socks', 'clothing'),
+    ('book', 'stationery'),
+    ('pen', 'stationery'),
+    ('cup', 'accessories'),
+    ('powerbank', 'accessories'),
+    ('umbrella', 'accessories'),
+    ('wallet', 'accessories')
+) AS c (name, category)
+WHERE merch.name = c.name;
+
+CREATE INDEX IF NOT EXISTS merch_category ON merch (category);
+
+-- promo codes giving a discount on every unit of matching merch
+CREATE TABLE IF NOT EXISTS promo_codes (
+    code varchar(32) NOT NULL PRIMARY KEY,
+    date timestamp with time zone NOT NULL, -- creation date
+    type varchar(16) NOT NULL CHECK (type IN ('percent', 'fixed')),
+    value bigint NOT NULL CHECK (value > 0), -- percent of the price or coins off the price
+    merch_id uuid REFERENCES merch (id), -- the code applies only to this merch
+    category varchar(64), -- the code applies only to merch of this category
+    valid_from timestamp with time zone, -- null if valid since creation
+    valid_to timestamp with time zone, -- exclusive, null if valid forever
+    per_user_limit integer NOT NULL DEFAULT 0 CHECK (per_user_limit >= 0), -- 0 if unlimited
+    total_limit integer NOT NULL DEFAULT 0 CHECK (total_limit >= 0), -- 0 if unlimited
+    CHECK (type <> 'percent' OR value < 100), -- discounted merch costs at least one coin
+    CHECK (merch_id IS NULL OR category IS NULL)
+);
+
+-- every purchase or order paid with a promo code
+CREATE TABLE IF NOT EXISTS promo_redemptions (
+    id uuid NOT NULL PRIMARY KEY,
+    date timestamp with time zone NOT NULL,
+    code varchar(32) NOT NULL REFERENCES promo_codes (code),
+    user_id uuid NOT NULL REFERENCES users (id),
+    purchase_id uuid REFERENCES purchases (id), -- single item purchase
+    order_id uuid REFERENCES orders (id), -- order of several items
+    CHECK ((purchase_id IS NULL) <> (order_id IS NULL))
+);
+CREATE INDEX IF NOT EXISTS promo_redemptions_code ON promo_redemptions (code, user_id);
+
+-- discount received on the purchase, the charged price is the merch price minus the discount
+ALTER TABLE purchases
+    ADD COLUMN IF NOT EXISTS discount bigint NOT NULL DEFAULT 0 CHECK (discount >= 0),
+    ADD COLUMN IF NOT EXISTS promo_code varchar(32) REFERENCES promo_codes (code);
+
+COMMIT;
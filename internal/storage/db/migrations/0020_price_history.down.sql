@@ -0,0 +1,10 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS merch_price_changed ON merch;
+DROP TRIGGER IF EXISTS merch_price_created ON merch;
+DROP FUNCTION IF EXISTS merch_record_price();
+DROP TABLE IF EXISTS merch_price_history;
+
+ALTER TABLE purchases DROP COLUMN IF EXISTS price_paid;
+
+COMMIT;
@@ -0,0 +1,50 @@
+BEGIN;
+
+-- price charged for the purchase, including the promo code discount
+ALTER TABLE purchases ADD COLUMN IF NOT EXISTS price_paid bigint CHECK (price_paid >= 0);
+
+UPDATE purchases p SET price_paid = -l.amount
+FROM ledger_entries l
+WHERE l.operation = 'purchase' AND l.operation_id = p.id AND l.amount < 0 AND p.price_paid IS NULL;
+
+-- purchases made before the ledger cost the current price minus the discount
+UPDATE purchases p SET price_paid = m.price - p.discount
+FROM merch m
+WHERE m.id = p.merch_id AND p.price_paid IS NULL;
+
+ALTER TABLE purchases ALTER COLUMN price_paid SET NOT NULL;
+
+-- every price of merch since the moment it was set
+CREATE TABLE IF NOT EXISTS merch_price_history (
+    id uuid NOT NULL PRIMARY KEY,
+    merch_id uuid NOT NULL REFERENCES merch (id),
+    date timestamp with time zone NOT NULL, -- the price is in effect since this date
+    price bigint NOT NULL
+);
+CREATE INDEX IF NOT EXISTS merch_price_history_merch_id ON merch_price_history (merch_id, date);
+
+-- the history of existing merch starts with its current price
+INSERT INTO merch_price_history (id, merch_id, date, price)
+SELECT gen_random_uuid (), id, CURRENT_TIMESTAMP, price FROM merch;
+
+CREATE OR REPLACE FUNCTION merch_record_price() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO merch_price_history (id, merch_id, date, price)
+    VALUES (gen_random_uuid (), NEW.id, CURRENT_TIMESTAMP, NEW.price);
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER merch_price_created
+    AFTER INSERT ON merch
+    FOR EACH ROW
+    EXECUTE FUNCTION merch_record_price();
+
+CREATE TRIGGER merch_price_changed
+    AFTER UPDATE OF price ON merch
+    FOR EACH ROW
+    WHEN (NEW.price <> OLD.price)
+    EXECUTE FUNCTION merch_record_price();
+
+COMMIT;
@@ -0,0 +1,150 @@
+package db
+
+import (
+	"context"
+	"sort"
+
+	"github.com/gofrs/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/plasmatrip/avito_merch/internal/apperr"
+	"github.com/plasmatrip/avito_merch/internal/model"
+	"github.com/plasmatrip/avito_merch/internal/storage/db/queries"
+)
+
+// PlaceOrder оформляет заказ из нескольких позиций в одной транзакции: заказ
+// оплачивается целиком или не оплачивается вовсе, названия позиций не повторяются,
+// promo - необязательный промокод на подходящие позиции
+func (r PostgresDB) PlaceOrder(ctx context.Context, userID uuid.UUID, items []model.OrderItem, promo string) (model.Order, error) {
+	var order model.Order
+
+	err := r.inTx(ctx, func(tx pgx.Tx) error {
+		var err error
+		order, err = r.placeOrder(ctx, tx, userID, items, nil, promo)
+		return err
+	})
+	if err != nil {
+		return model.Order{}, err
+	}
+
+	return order, nil
+}
+
+// placeOrder оформляет заказ в транзакции tx, если переданы ожидаемые цены
+// позиций, заказ с изменившейся ценой не оформляется, скидка по промокоду
+// code действует на подходящие позиции
+func (r PostgresDB) placeOrder(ctx context.Context, tx pgx.Tx, userID uuid.UUID, items []model.OrderItem, prices map[string]int64, code string) (model.Order, error) {
+	promo, err := r.lockPromo(ctx, tx, userID, code)
+	if err != nil {
+		return model.Order{}, err
+	}
+
+	// уменьшаем остатки в порядке названий, чтобы параллельные заказы не взаимоблокировались
+	items = append([]model.OrderItem(nil), items...)
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Name < items[j].Name
+	})
+
+	order := model.Order{Items: make([]model.OrderLine, 0, len(items)), Promo: code}
+	merchIDs := make([]uuid.UUID, 0, len(items))
+	promoLines := make([]bool, 0, len(items)) // позиции, к которым применен промокод
+	applied := false
+
+	for _, item := range items {
+		itemID, itemPrice, itemCategory, err := reserveItem(ctx, tx, item.Name, item.Quantity)
+		if err != nil {
+			return model.Order{}, err
+		}
+
+		if price, ok := prices[item.Name]; ok && price != itemPrice {
+			return model.Order{}, apperr.ErrCartPriceChanged
+		}
+
+		line := model.OrderLine{
+			Name:     item.Name,
+			Quantity: item.Quantity,
+			Price:    itemPrice,
+		}
+		applies := promo != nil && promo.Applies(item.Name, itemCategory)
+		if applies {
+			line.Discount = promo.Discount(itemPrice)
+			applied = true
+		}
+		line.Total = (line.Price - line.Discount) * int64(item.Quantity)
+
+		order.Items = append(order.Items, line)
+		order.Total += line.Total
+		merchIDs = append(merchIDs, itemID)
+		promoLines = append(promoLines, applies)
+	}
+
+	// промокод должен подойти хотя бы к одной позиции заказа
+	if promo != nil && !applied {
+		return model.Order{}, apperr.ErrPromoNotApplicable
+	}
+
+	// блокируем счет до конца транзакции, как и при покупке одного мерча
+	var accountID uuid.UUID
+	var userAmount int64
+	err = tx.QueryRow(ctx, queries.SelectAccountForUpdate, pgx.NamedArgs{
+		"user_id": userID,
+	}).Scan(&accountID, &userAmount)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return model.Order{}, apperr.ErrAccountNotFound
+		}
+		return model.Order{}, err
+	}
+
+	if userAmount < order.Total {
+		return model.Order{}, apperr.ErrInsufficientFunds
+	}
+
+	err = tx.QueryRow(ctx, queries.InsertOrder, pgx.NamedArgs{
+		"user_id": userID,
+		"total":   order.Total,
+	}).Scan(&order.ID, &order.Date)
+	if err != nil {
+		return model.Order{}, err
+	}
+
+	if promo != nil {
+		if err := redeemPromo(ctx, tx, promo, userID, nil, &order.ID); err != nil {
+			return model.Order{}, err
+		}
+	}
+
+	// каждая единица мерча - отдельная покупка, чтобы ее можно было вернуть
+	for i, line := range order.Items {
+		// промокод записываем во все покупки, к которым он применен, даже если скидка
+		// округлилась до нуля, иначе возврат заказа не отменит его использование
+		var linePromo *string
+		if promoLines[i] {
+			linePromo = promoCode(promo)
+		}
+
+		for range line.Quantity {
+			var purchaseID uuid.UUID
+			err = tx.QueryRow(ctx, queries.InsertPurchase, pgx.NamedArgs{
+				"user_id":    userID,
+				"owner_id":   userID,
+				"merch_id":   merchIDs[i],
+				"order_id":   order.ID,
+				"message":    nil,
+				"discount":   line.Discount,
+				"promo_code": linePromo,
+				"price_paid": line.Price - line.Discount,
+			}).Scan(&purchaseID)
+			if err != nil {
+				return model.Order{}, err
+			}
+
+			err = post(ctx, tx, model.OperationPurchase, purchaseID, accountID, r.storeRevenueAccountID, line.Price-line.Discount)
+			if err != nil {
+				return model.Order{}, err
+			}
+		}
+	}
+
+	return order, nil
+}
@@ -0,0 +1,181 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/rgurov/pgerrors"
+
+	"github.com/plasmatrip/avito_merch/internal/apperr"
+	"github.com/plasmatrip/avito_merch/internal/model"
+	"github.com/plasmatrip/avito_merch/internal/storage/db/queries"
+)
+
+// CreatePromoCode добавляет промокод, мерч промокода должен быть в каталоге
+func (r PostgresDB) CreatePromoCode(ctx context.Context, promo model.PromoCode) (model.PromoCode, error) {
+	if promo.Item != "" {
+		if _, err := r.MerchItem(ctx, promo.Item); err != nil {
+			return model.PromoCode{}, err
+		}
+	}
+
+	var category *string
+	if promo.Category != "" {
+		category = &promo.Category
+	}
+
+	_, err := r.DB.Exec(ctx, queries.InsertPromoCode, pgx.NamedArgs{
+		"code":           promo.Code,
+		"type":           promo.Type,
+		"value":          promo.Value,
+		"item":           promo.Item,
+		"category":       category,
+		"valid_from":     nullTime(promo.ValidFrom),
+		"valid_to":       nullTime(promo.ValidTo),
+		"per_user_limit": promo.PerUserLimit,
+		"total_limit":    promo.TotalLimit,
+	})
+	if err != nil {
+		if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == pgerrors.UniqueViolation {
+			return model.PromoCode{}, apperr.ErrPromoAlreadyExists
+		}
+		return model.PromoCode{}, err
+	}
+
+	promo.Used = 0
+	return promo, nil
+}
+
+// PromoCodes возвращает промокоды с количеством использований, новые первыми
+func (r PostgresDB) PromoCodes(ctx context.Context) ([]model.PromoCode, error) {
+	rows, err := r.DB.Query(ctx, queries.SelectPromoCodes)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	promos := make([]model.PromoCode, 0)
+	for rows.Next() {
+		var promo model.PromoCode
+		var validFrom, validTo *time.Time
+
+		err := rows.Scan(
+			&promo.Code,
+			&promo.Type,
+			&promo.Value,
+			&promo.Item,
+			&promo.Category,
+			&validFrom,
+			&validTo,
+			&promo.PerUserLimit,
+			&promo.TotalLimit,
+			&promo.Used,
+		)
+		if err != nil {
+			return nil, err
+		}
+		promo.ValidFrom = timeOrZero(validFrom)
+		promo.ValidTo = timeOrZero(validTo)
+
+		promos = append(promos, promo)
+	}
+
+	return promos, rows.Err()
+}
+
+// lockPromo блокирует промокод до конца транзакции tx и проверяет, что пользователь
+// может его использовать, пустой промокод не применяется
+func (r PostgresDB) lockPromo(ctx context.Context, tx pgx.Tx, userID uuid.UUID, code string) (*model.PromoCode, error) {
+	if code == "" {
+		return nil, nil
+	}
+
+	var promo model.PromoCode
+	var validFrom, validTo *time.Time
+	var usedByUser int
+
+	// параллельные покупки с одним промокодом не превысят лимиты использований
+	err := tx.QueryRow(ctx, queries.SelectPromoCodeForUpdate, pgx.NamedArgs{
+		"code":    code,
+		"user_id": userID,
+	}).Scan(
+		&promo.Code,
+		&promo.Type,
+		&promo.Value,
+		&promo.Item,
+		&promo.Category,
+		&validFrom,
+		&validTo,
+		&promo.PerUserLimit,
+		&promo.TotalLimit,
+		&promo.Used,
+		&usedByUser,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, apperr.ErrPromoNotFound
+		}
+		return nil, err
+	}
+	promo.ValidFrom = timeOrZero(validFrom)
+	promo.ValidTo = timeOrZero(validTo)
+
+	if !promo.Active(time.Now()) {
+		return nil, apperr.ErrPromoNotActive
+	}
+
+	if (promo.TotalLimit > 0 && promo.Used >= promo.TotalLimit) ||
+		(promo.PerUserLimit > 0 && usedByUser >= promo.PerUserLimit) {
+		return nil, apperr.ErrPromoLimitReached
+	}
+
+	return &promo, nil
+}
+
+// redeemPromo учитывает использование промокода покупкой или заказом
+func redeemPromo(ctx context.Context, tx pgx.Tx, promo *model.PromoCode, userID uuid.UUID, purchaseID, orderID *uuid.UUID) error {
+	_, err := tx.Exec(ctx, queries.InsertPromoRedemption, pgx.NamedArgs{
+		"code":        promo.Code,
+		"user_id":     userID,
+		"purchase_id": purchaseID,
+		"order_id":    orderID,
+	})
+	return err
+}
+
+// releasePromo отменяет использование промокода code возвращенной покупкой purchaseID,
+// использование заказом отменяется вместе с возвратом последней покупки заказа с промокодом
+func releasePromo(ctx context.Context, tx pgx.Tx, code string, purchaseID uuid.UUID) error {
+	// промокод блокируем, как и при покупке, чтобы параллельные возвраты покупок
+	// одного заказа увидели друг друга
+	_, err := tx.Exec(ctx, queries.LockPromoCode, pgx.NamedArgs{
+		"code": code,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, queries.DeletePromoRedemption, pgx.NamedArgs{
+		"purchase_id": purchaseID,
+	})
+	return err
+}
+
+// promoCode возвращает код примененного промокода, nil - покупка без промокода
+func promoCode(promo *model.PromoCode) *string {
+	if promo == nil {
+		return nil
+	}
+	return &promo.Code
+}
+
+// timeOrZero возвращает нулевое время вместо NULL
+func timeOrZero(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
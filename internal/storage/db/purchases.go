@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+
+	"github.com/gofrs/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/plasmatrip/avito_merch/internal/model"
+	"github.com/plasmatrip/avito_merch/internal/storage/db/queries"
+)
+
+// Purchases возвращает последние limit покупок пользователя от новых к старым
+// с ценой, списанной при покупке
+func (r PostgresDB) Purchases(ctx context.Context, userID uuid.UUID, limit int) ([]model.Purchase, error) {
+	rows, err := r.DB.Query(ctx, queries.SelectUserPurchases, pgx.NamedArgs{
+		"user_id": userID,
+		"limit":   limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	purchases := make([]model.Purchase, 0)
+	for rows.Next() {
+		p := model.Purchase{}
+
+		err := rows.Scan(
+			&p.ID,
+			&p.Date,
+			&p.Item,
+			&p.PricePaid,
+			&p.Discount,
+			&p.Promo,
+			&p.OrderID,
+			&p.ToUser,
+			&p.RefundedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		purchases = append(purchases, p)
+	}
+
+	return purchases, rows.Err()
+}
+
+// PriceHistory возвращает цены мерча от старых к новым, первая цена - цена при добавлении в каталог
+func (r PostgresDB) PriceHistory(ctx context.Context, name string) ([]model.PricePoint, error) {
+	rows, err := r.DB.Query(ctx, queries.SelectPriceHistory, pgx.NamedArgs{
+		"name": name,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prices := make([]model.PricePoint, 0)
+	for rows.Next() {
+		var p model.PricePoint
+		if err := rows.Scan(&p.Date, &p.Price); err != nil {
+			return nil, err
+		}
+		prices = append(prices, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// у мерча из каталога всегда есть цена
+	if len(prices) == 0 {
+		if _, err := r.MerchItem(ctx, name); err != nil {
+			return nil, err
+		}
+	}
+
+	return prices, nil
+}
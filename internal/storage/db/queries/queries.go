@@ -8,16 +8,94 @@ const (
 			RETURNING id
 		)
 		INSERT INTO accounts (id, user_id, amount)
-		VALUES (gen_random_uuid (), (SELECT id FROM insertIntoUsers), 1000)
-		RETURNING user_id;
+		VALUES (gen_random_uuid (), (SELECT id FROM insertIntoUsers), 0)
+		RETURNING user_id, id;
+	`
+
+	SelectSystemAccount = `
+		SELECT id FROM accounts WHERE kind = @kind
+	`
+
+	InsertPostings = `
+		INSERT INTO ledger_entries (id, date, operation, operation_id, account_id, amount)
+		VALUES
+			(gen_random_uuid (), CURRENT_TIMESTAMP, @operation, @operation_id, @debit_account_id, @debit_amount),
+			(gen_random_uuid (), CURRENT_TIMESTAMP, @operation, @operation_id, @credit_account_id, @credit_amount)
 	`
 
 	SelectItem = `
-		SELECT id, price::money::numeric FROM merch WHERE name = @item_name
+		SELECT id, price, available, stock, category FROM merch WHERE name = @item_name
+	`
+
+	DecrementStock = `
+		UPDATE merch SET stock = stock - @quantity
+		WHERE id = @id AND available AND (stock IS NULL OR stock >= @quantity)
+		RETURNING price
+	`
+
+	SelectMerch = `
+		SELECT name, price, description, available, stock, category FROM merch
+		WHERE (@min_price::bigint = 0 OR price >= @min_price)
+			AND (@max_price::bigint = 0 OR price <= @max_price)
+		ORDER BY
+			CASE WHEN @sort::text = 'price' AND NOT @desc::boolean THEN price END ASC,
+			CASE WHEN @sort = 'price' AND @desc THEN price END DESC,
+			CASE WHEN @desc THEN name END DESC,
+			name ASC
+	`
+
+	SelectMerchItem = `
+		SELECT name, price, description, available, stock, category FROM merch WHERE name = @name
+	`
+
+	InsertMerch = `
+		INSERT INTO merch (id, name, price, description, available, stock, category)
+		VALUES (@id, @name, @price, @description, @available, @stock, @category)
+		RETURNING name, price, description, available, stock, category
+	`
+
+	UpdateMerch = `
+		UPDATE merch SET
+			price = COALESCE(@price, price),
+			description = COALESCE(@description, description),
+			available = COALESCE(@available, available),
+			stock = COALESCE(@stock, stock),
+			category = COALESCE(@category, category)
+		WHERE name = @name
+		RETURNING name, price, description, available, stock, category
+	`
+
+	SetStock = `
+		UPDATE merch SET stock = @stock WHERE name = @name
+		RETURNING name, price, description, available, stock, category
+	`
+
+	Restock = `
+		UPDATE merch SET stock = stock + @quantity WHERE name = @name AND stock IS NOT NULL
+		RETURNING name, price, description, available, stock, category
 	`
 
 	SelectAccount = `
-		SELECT amount::money::numeric FROM accounts WHERE user_id = @user_id
+		SELECT amount FROM accounts WHERE user_id = @user_id
+	`
+
+	SelectBalance = `
+		SELECT COALESCE(sum(l.amount), 0)::bigint
+		FROM accounts a
+		LEFT JOIN ledger_entries l ON l.account_id = a.id
+		WHERE a.user_id = @user_id
+		GROUP BY a.id
+	`
+
+	SelectAccountForUpdate = `
+		SELECT id, amount FROM accounts WHERE user_id = @user_id FOR UPDATE
+	`
+
+	SelectAccountsForUpdate = `
+		SELECT user_id, id, amount FROM accounts
+		WHERE user_id = ANY(@user_ids)
+		ORDER BY user_id
+		FOR UPDATE
 	`
 
 	SelectUser = `
@@ -28,64 +106,445 @@ const (
 		SELECT id FROM users WHERE login = @login
 	`
 
-	BuyItem = `
-		WITH insertPurchase AS (
-			INSERT INTO purchases (id, date, user_id, merch_id)
-			values (
-				gen_random_uuid (),
-				CURRENT_TIMESTAMP,
-				@user_id,
-				@merch_id
-			)
-		) UPDATE accounts
-			SET amount = accounts.amount - @price
-			WHERE user_id = @user_id;
+	SelectLogin = `
+		SELECT login FROM users WHERE id = @user_id
+	`
+
+	SelectUserRole = `
+		SELECT role FROM users WHERE id = @user_id
+	`
+
+	UpdateUserRole = `
+		UPDATE users SET role = @role WHERE login = @login
 	`
 
-	UpdateCoin = `
-		UPDATE accounts
-		SET amount = accounts.amount + @amount
-		WHERE user_id = @user_id
+	InsertPurchase = `
+		WITH p AS (
+			INSERT INTO purchases (id, date, user_id, owner_id, merch_id, order_id, message, discount, promo_code, price_paid)
+			VALUES (gen_random_uuid (), CURRENT_TIMESTAMP, @user_id, @owner_id, @merch_id, @order_id, @message, @discount, @promo_code, @price_paid)
+			RETURNING id, owner_id, date
+		)
+		INSERT INTO item_ownership (purchase_id, owner_id, acquired_at)
+		SELECT id, owner_id, date FROM p
+		RETURNING purchase_id
 	`
 
 	InsertTransaction = `
-		INSERT INTO transactions (id, date, from_user_id, to_user_id, amount)
-		VALUES (gen_random_uuid (), CURRENT_TIMESTAMP, @from_user_id, @to_user_id, @amount)
+		INSERT INTO transactions (id, date, from_user_id, to_user_id, amount, message)
+		VALUES (gen_random_uuid (), CURRENT_TIMESTAMP, @from_user_id, @to_user_id, @amount, @message)
+		RETURNING id
 	`
 
 	SelectPurchases = `
 		SELECT m.name, COUNT(p.merch_id)
 		FROM merch m 
 		RIGHT JOIN purchases p ON m.id = p.merch_id 
-		WHERE p.user_id =@user_id
+		JOIN item_ownership o ON o.purchase_id = p.id
+		WHERE o.owner_id =@user_id AND p.refunded_at IS NULL
 		GROUP BY m."name" 
 	`
 
-	SelectRecicedCoins = `
-		SELECT u.login, sum(t.amount)::money::numeric
-		FROM users u 
-		RIGHT JOIN transactions t ON u.id = t.from_user_id 
-		WHERE t.to_user_id = @user_id
+	InsertOrder = `
+		INSERT INTO orders (id, date, user_id, total)
+		VALUES (gen_random_uuid (), CURRENT_TIMESTAMP, @user_id, @total)
+		RETURNING id, date
+	`
+
+	SelectCart = `
+		SELECT m.name, c.quantity, m.price, c.price, m.available
+		FROM cart_items c
+		JOIN merch m ON m.id = c.merch_id
+		WHERE c.user_id = @user_id
+		ORDER BY m.name
+	`
+
+	SelectCartForUpdate = `
+		SELECT m.name, c.quantity, c.price
+		FROM cart_items c
+		JOIN merch m ON m.id = c.merch_id
+		WHERE c.user_id = @user_id
+		ORDER BY m.name
+		FOR UPDATE OF c
+	`
+
+	UpsertCartItem = `
+		INSERT INTO cart_items (user_id, merch_id, quantity, price, date)
+		SELECT @user_id, id, @quantity, price, CURRENT_TIMESTAMP FROM merch WHERE name = @name
+		ON CONFLICT (user_id, merch_id) DO UPDATE SET
+			quantity = EXCLUDED.quantity,
+			price = EXCLUDED.price,
+			date = EXCLUDED.date
+	`
+
+	DeleteCartItem = `
+		DELETE FROM cart_items c USING merch m
+		WHERE c.merch_id = m.id AND c.user_id = @user_id AND m.name = @name
+	`
+
+	ClearCart = `
+		DELETE FROM cart_items WHERE user_id = @user_id
+	`
+
+	SelectUsersByLogin = `
+		SELECT login, id FROM users WHERE login = ANY(@logins)
+	`
+
+	SelectUserIDs = `
+		SELECT id FROM users WHERE login = ANY(@logins)
+	`
+
+	InsertGrants = `
+		INSERT INTO grants (id, date, user_id, amount, reason, period)
+		SELECT gen_random_uuid (), CURRENT_TIMESTAMP, a.user_id, @amount, @reason, @period
+		FROM accounts a
+		WHERE a.kind = 'user' AND (@all::boolean OR a.user_id = ANY(@user_ids))
+		ORDER BY a.user_id
+		ON CONFLICT (user_id, period) DO NOTHING
+		RETURNING id
+	`
+
+	InsertGrant = `
+		INSERT INTO grants (id, date, user_id, amount, reason, period)
+		VALUES (gen_random_uuid (), @date, @user_id, @amount, @reason, @period)
+		RETURNING id
+	`
+
+	InsertGrantPostings = `
+		INSERT INTO ledger_entries (id, date, operation, operation_id, account_id, amount)
+		SELECT gen_random_uuid (), g.date, 'grant', g.id, p.account_id, p.amount
+		FROM grants g
+		JOIN accounts a ON a.user_id = g.user_id
+		CROSS JOIN LATERAL (VALUES
+			(@issuance_account_id::uuid, -g.amount, 0),
+			(a.id, g.amount, 1)
+		) AS p (account_id, amount, side)
+		WHERE g.id = ANY(@grant_ids)
+		ORDER BY g.user_id, p.side
+	`
+
+	SelectPurchaseForUpdate = `
+		SELECT p.user_id, o.owner_id, p.merch_id, p.date, p.price_paid, p.promo_code, p.refunded_at
+		FROM purchases p
+		JOIN item_ownership o ON o.purchase_id = p.id
+		WHERE p.id = @id
+		FOR UPDATE OF p, o
+	`
+
+	SelectOwnedItemsForUpdate = `
+		SELECT p.id, p.merch_id
+		FROM item_ownership o
+		JOIN purchases p ON p.id = o.purchase_id
+		JOIN merch m ON m.id = p.merch_id
+		WHERE o.owner_id = @user_id AND m.name = @item AND p.refunded_at IS NULL
+		ORDER BY o.acquired_at, p.id
+		FOR UPDATE OF p, o
+	`
+
+	InsertItemTransfer = `
+		INSERT INTO item_transfers (id, date, from_user_id, to_user_id, merch_id, quantity)
+		VALUES (gen_random_uuid (), CURRENT_TIMESTAMP, @from_user_id, @to_user_id, @merch_id, @quantity)
+		RETURNING id, date
+	`
+
+	InsertItemTransferUnits = `
+		INSERT INTO item_transfer_units (transfer_id, purchase_id)
+		SELECT @transfer_id, unnest(@purchase_ids::uuid[])
+	`
+
+	UpdateItemOwner = `
+		UPDATE item_ownership SET owner_id = @owner_id, acquired_at = @acquired_at
+		WHERE purchase_id = ANY(@purchase_ids::uuid[])
+	`
+
+	SelectItemTransfers = `
+		SELECT t.id, t.date, f.login, r.login, m.name, t.quantity
+		FROM item_transfers t
+		JOIN users f ON f.id = t.from_user_id
+		JOIN users r ON r.id = t.to_user_id
+		JOIN merch m ON m.id = t.merch_id
+		WHERE t.from_user_id = @user_id OR t.to_user_id = @user_id
+		ORDER BY t.date DESC, t.id
+	`
+
+	RefundPurchase = `
+		UPDATE purchases SET refunded_at = @refunded_at, refund_id = @refund_id WHERE id = @id
+	`
+
+	IncrementStock = `
+		UPDATE merch SET stock = stock + 1 WHERE id = @id AND stock IS NOT NULL
+	`
+
+	SelectTransactions = `
+		SELECT login, sum(amount)::bigint, type,
+			array_remove(array_agg(message ORDER BY seq), NULL) AS messages
+		FROM (
+			SELECT u.login,
+				abs(own.amount) AS amount,
+				CASE WHEN own.amount > 0 THEN 'received' ELSE 'sent' END AS type,
+				t.message,
+				own.seq
+			FROM accounts a
+			JOIN ledger_entries own ON own.account_id = a.id AND own.operation = 'transfer'
+			JOIN ledger_entries other ON other.operation_id = own.operation_id AND other.id <> own.id
+			JOIN accounts oa ON oa.id = other.account_id
+			JOIN users u ON u.id = oa.user_id
+			LEFT JOIN transactions t ON t.id = own.operation_id
+			WHERE a.user_id = @user_id
+		) transfers
+		GROUP BY login, type
+		ORDER BY type, login;
+	`
+
+	AcquireIdempotencyKey = `
+		INSERT INTO idempotency_keys (user_id, key, request, date)
+		VALUES (@user_id, @key, @request, @date)
+		ON CONFLICT (user_id, key) DO UPDATE
+			SET request = EXCLUDED.request, date = EXCLUDED.date, status = NULL, content_type = NULL, body = NULL
+			WHERE idempotency_keys.date < @expired_before
+		RETURNING user_id
+	`
+
+	SelectIdempotencyKey = `
+		SELECT request, status, content_type, body
+		FROM idempotency_keys
+		WHERE user_id = @user_id AND key = @key
+	`
+
+	UpdateIdempotentResponse = `
+		UPDATE idempotency_keys
+		SET status = @status, content_type = @content_type, body = @body
+		WHERE user_id = @user_id AND key = @key
+	`
+
+	DeleteIdempotencyKey = `
+		DELETE FROM idempotency_keys
+		WHERE user_id = @user_id AND key = @key AND status IS NULL
+	`
+
+	InsertCoinRequest = `
+		WITH inserted AS (
+			INSERT INTO coin_requests (id, date, requester_id, payer_id, amount, message, expires_at)
+			VALUES (gen_random_uuid (), @date, @requester_id, @payer_id, @amount, @message, @expires_at)
+			RETURNING *
+		)
+		SELECT cr.id, cr.date, ru.login, pu.login, cr.amount, COALESCE(cr.message, ''), cr.status, cr.expires_at, cr.resolved_at
+		FROM inserted cr
+		JOIN users ru ON ru.id = cr.requester_id
+		JOIN users pu ON pu.id = cr.payer_id
+	`
+
+	SelectCoinRequests = `
+		SELECT cr.id, cr.date, ru.login, pu.login, cr.amount, COALESCE(cr.message, ''), cr.status, cr.expires_at, cr.resolved_at
+		FROM coin_requests cr
+		JOIN users ru ON ru.id = cr.requester_id
+		JOIN users pu ON pu.id = cr.payer_id
+		WHERE cr.payer_id = @user_id AND cr.status = 'pending' AND cr.expires_at > @now
+		ORDER BY cr.date, cr.id
+	`
+
+	SelectCoinRequestForUpdate = `
+		SELECT cr.id, cr.date, ru.login, pu.login, cr.amount, COALESCE(cr.message, ''), cr.status, cr.expires_at, cr.resolved_at
+		FROM coin_requests cr
+		JOIN users ru ON ru.id = cr.requester_id
+		JOIN users pu ON pu.id = cr.payer_id
+		WHERE cr.id = @id AND cr.payer_id = @user_id
+		FOR UPDATE OF cr
+	`
+
+	ResolveCoinRequest = `
+		UPDATE coin_requests
+		SET status = @status, resolved_at = @resolved_at, transaction_id = @transaction_id
+		WHERE id = @id
+	`
+
+	SelectTransferLimits = `
+		SELECT per_transfer, daily FROM transfer_limits WHERE user_id = @user_id
+	`
+
+	SelectSentSince = `
+		SELECT COALESCE(sum(amount), 0)::bigint FROM transactions
+		WHERE from_user_id = @user_id AND date > @since
+	`
+
+	UpsertTransferLimits = `
+		INSERT INTO transfer_limits (user_id, date, per_transfer, daily)
+		VALUES (@user_id, @date, @per_transfer, @daily)
+		ON CONFLICT (user_id) DO UPDATE
+		SET date = EXCLUDED.date, per_transfer = EXCLUDED.per_transfer, daily = EXCLUDED.daily
+	`
+
+	DeleteTransferLimits = `
+		DELETE FROM transfer_limits WHERE user_id = @user_id
+	`
+
+	SelectTopReceivers = `
+		SELECT u.login, sum(t.amount)::bigint AS amount
+		FROM transactions t
+		JOIN users u ON u.id = t.to_user_id
+		WHERE (@since::timestamptz IS NULL OR t.date >= @since) AND NOT u.leaderboard_hidden
 		GROUP BY u.login
+		ORDER BY amount DESC, u.login
+		LIMIT @limit
 	`
-	SelectSentCoins = `
-		SELECT u.login, sum(t.amount)::money::numeric
-		FROM users u 
-		RIGHT JOIN transactions t ON u.id = t.to_user_id 
-		WHERE t.from_user_id = @user_id
+
+	SelectTopSenders = `
+		SELECT u.login, sum(t.amount)::bigint AS amount
+		FROM transactions t
+		JOIN users u ON u.id = t.from_user_id
+		WHERE (@since::timestamptz IS NULL OR t.date >= @since) AND NOT u.leaderboard_hidden
 		GROUP BY u.login
+		ORDER BY amount DESC, u.login
+		LIMIT @limit
 	`
-	SelectTransactions = `
-		SELECT u.login, sum(t.amount)::money::numeric AS amount, 'received' AS type
-			FROM users u 
-			RIGHT JOIN transactions t ON u.id = t.from_user_id 
-			WHERE t.to_user_id = @user_id
-			GROUP BY u.login
-		UNION ALL
-		SELECT u.login, sum(t.amount)::money::numeric AS amount, 'sent' AS type
-			FROM users u 
-			RIGHT JOIN transactions t ON u.id = t.to_user_id 
-			WHERE t.from_user_id = @user_id
-			GROUP BY u.login;
+
+	SelectTopPurchasers = `
+		SELECT u.login, sum(-l.amount)::bigint AS amount
+		FROM purchases p
+		JOIN ledger_entries l ON l.operation_id = p.id AND l.operation = 'purchase' AND l.amount < 0
+		JOIN users u ON u.id = p.user_id
+		WHERE p.refunded_at IS NULL AND (@since::timestamptz IS NULL OR p.date >= @since) AND NOT u.leaderboard_hidden
+		GROUP BY u.login
+		ORDER BY amount DESC, u.login
+		LIMIT @limit
+	`
+
+	UpdateLeaderboardHidden = `
+		UPDATE users SET leaderboard_hidden = @hidden WHERE id = @user_id
+	`
+
+	SelectWishlist = `
+		SELECT m.name, m.price, m.available, m.stock
+		FROM wishlist w
+		JOIN merch m ON m.id = w.merch_id
+		WHERE w.user_id = @user_id
+		ORDER BY w.date, m.name
+	`
+
+	InsertWishlistItem = `
+		INSERT INTO wishlist (user_id, merch_id, date)
+		SELECT @user_id, id, CURRENT_TIMESTAMP FROM merch WHERE name = @name
+		ON CONFLICT (user_id, merch_id) DO NOTHING
+	`
+
+	DeleteWishlistItem = `
+		DELETE FROM wishlist w
+		USING merch m
+		WHERE m.id = w.merch_id AND w.user_id = @user_id AND m.name = @name
+	`
+
+	SelectNotifications = `
+		SELECT n.id, n.date, n.type, m.name, COALESCE(n.old_price, 0), n.price, n.read_at IS NOT NULL
+		FROM notifications n
+		JOIN merch m ON m.id = n.merch_id
+		WHERE n.user_id = @user_id
+		ORDER BY n.date DESC, n.id
+		LIMIT @limit
+	`
+
+	UpdateNotificationsRead = `
+		UPDATE notifications SET read_at = @read_at WHERE user_id = @user_id AND read_at IS NULL
+	`
+
+	SelectHistory = `
+		WITH entries AS (
+			SELECT l.id, l.operation_id, l.seq, l.date, l.operation, l.amount
+			FROM accounts a
+			JOIN ledger_entries l ON l.account_id = a.id
+			WHERE a.user_id = @user_id
+			UNION ALL
+			-- gifts paid by other users appear in the owner's history with zero amount
+			SELECT l.id, l.operation_id, l.seq, l.date, 'gift', 0
+			FROM purchases p
+			JOIN ledger_entries l ON l.operation_id = p.id AND l.operation = 'purchase' AND l.amount < 0
+			WHERE p.owner_id = @user_id AND p.user_id <> @user_id
+		), history AS (
+			SELECT e.*, sum(e.amount) OVER (ORDER BY e.seq) AS balance_after
+			FROM entries e
+		)
+		SELECT h.id, h.operation_id, h.seq, h.date, h.operation, h.amount, h.balance_after::bigint,
+			COALESCE(u.login, pu.login, m.name, '') AS counterpart, COALESCE(g.reason, t.message, p.message, '') AS description,
+			CASE WHEN h.operation = 'gift' THEN m.name ELSE '' END AS item
+		FROM history h
+		LEFT JOIN ledger_entries other ON other.operation_id = h.operation_id AND other.id <> h.id AND h.operation = 'transfer'
+		LEFT JOIN accounts oa ON oa.id = other.account_id
+		LEFT JOIN users u ON u.id = oa.user_id
+		LEFT JOIN transactions t ON t.id = h.operation_id AND h.operation = 'transfer'
+		LEFT JOIN purchases p ON (p.id = h.operation_id AND h.operation IN ('purchase', 'gift'))
+			OR (p.refund_id = h.operation_id AND h.operation = 'refund')
+		LEFT JOIN merch m ON m.id = p.merch_id
+		LEFT JOIN users pu ON pu.id = p.user_id AND h.operation = 'gift'
+		LEFT JOIN grants g ON g.id = h.operation_id AND h.operation IN ('grant', 'welcome')
+		WHERE (@before::bigint = 0 OR h.seq < @before)
+			AND (@direction::text = '' OR (@direction = 'in' AND h.amount >= 0) OR (@direction = 'out' AND h.amount < 0))
+			AND (@counterpart::text = '' OR COALESCE(u.login, pu.login, m.name) = @counterpart)
+			AND (@from::timestamptz IS NULL OR h.date >= @from)
+			AND (@to::timestamptz IS NULL OR h.date < @to)
+		ORDER BY h.seq DESC
+		LIMIT @limit
+	`
+
+	InsertPromoCode = `
+		INSERT INTO promo_codes (code, date, type, value, merch_id, category, valid_from, valid_to, per_user_limit, total_limit)
+		VALUES (@code, CURRENT_TIMESTAMP, @type, @value, (SELECT id FROM merch WHERE name = @item),
+			@category, @valid_from, @valid_to, @per_user_limit, @total_limit)
+	`
+
+	SelectPromoCodes = `
+		SELECT p.code, p.type, p.value, COALESCE(m.name, ''), COALESCE(p.category, ''), p.valid_from, p.valid_to,
+			p.per_user_limit, p.total_limit, (SELECT count(*) FROM promo_redemptions r WHERE r.code = p.code)
+		FROM promo_codes p
+		LEFT JOIN merch m ON m.id = p.merch_id
+		ORDER BY p.date DESC, p.code
+	`
+
+	SelectPromoCodeForUpdate = `
+		SELECT p.code, p.type, p.value, COALESCE(m.name, ''), COALESCE(p.category, ''), p.valid_from, p.valid_to,
+			p.per_user_limit, p.total_limit,
+			(SELECT count(*) FROM promo_redemptions r WHERE r.code = p.code),
+			(SELECT count(*) FROM promo_redemptions r WHERE r.code = p.code AND r.user_id = @user_id)
+		FROM promo_codes p
+		LEFT JOIN merch m ON m.id = p.merch_id
+		WHERE p.code = @code
+		FOR UPDATE OF p
+	`
+
+	InsertPromoRedemption = `
+		INSERT INTO promo_redemptions (id, date, code, user_id, purchase_id, order_id)
+		VALUES (gen_random_uuid (), CURRENT_TIMESTAMP, @code, @user_id, @purchase_id, @order_id)
+	`
+
+	LockPromoCode = `
+		SELECT code FROM promo_codes WHERE code = @code FOR UPDATE
+	`
+
+	DeletePromoRedemption = `
+		DELETE FROM promo_redemptions r
+		USING purchases p
+		WHERE p.id = @purchase_id
+			AND (r.purchase_id = p.id
+				-- an order's redemption is released with the last unit the promo code was applied to
+				OR (r.order_id = p.order_id AND NOT EXISTS (
+					SELECT 1 FROM purchases op
+					WHERE op.order_id = p.order_id AND op.promo_code IS NOT NULL AND op.refunded_at IS NULL
+				)))
+	`
+
+	SelectUserPurchases = `
+		SELECT p.id, p.date, m.name, p.price_paid, p.discount, COALESCE(p.promo_code, ''), p.order_id,
+			CASE WHEN p.owner_id <> p.user_id THEN u.login ELSE '' END, p.refunded_at
+		FROM purchases p
+		JOIN merch m ON m.id = p.merch_id
+		JOIN users u ON u.id = p.owner_id
+		WHERE p.user_id = @user_id
+		ORDER BY p.date DESC, p.id
+		LIMIT @limit
+	`
+
+	SelectPriceHistory = `
+		SELECT h.date, h.price
+		FROM merch_price_history h
+		JOIN merch m ON m.id = h.merch_id
+		WHERE m.name = @name
+		ORDER BY h.date, h.id
 	`
 )
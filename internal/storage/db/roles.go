@@ -0,0 +1,44 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gofrs/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/plasmatrip/avito_merch/internal/apperr"
+	"github.com/plasmatrip/avito_merch/internal/storage/db/queries"
+)
+
+// UserRole возвращает роль пользователя, сохраненную в учетной записи
+func (r PostgresDB) UserRole(ctx context.Context, userID uuid.UUID) (string, error) {
+	var role string
+
+	err := r.DB.QueryRow(ctx, queries.SelectUserRole, pgx.NamedArgs{"user_id": userID}).Scan(&role)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", apperr.ErrUserNotFound
+		}
+		return "", err
+	}
+
+	return role, nil
+}
+
+// SetUserRole назначает роль существующему пользователю
+func (r PostgresDB) SetUserRole(ctx context.Context, login, role string) error {
+	tag, err := r.DB.Exec(ctx, queries.UpdateUserRole, pgx.NamedArgs{
+		"login": login,
+		"role":  role,
+	})
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return apperr.ErrUserNotFound
+	}
+
+	return nil
+}
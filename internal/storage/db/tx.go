@@ -0,0 +1,79 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/rgurov/pgerrors"
+
+	"github.com/plasmatrip/avito_merch/internal/apperr"
+)
+
+// параметры повтора транзакций
+const (
+	txMaxAttempts = 5                      // максимальное количество попыток
+	txBaseBackoff = 10 * time.Millisecond  // пауза перед первым повтором
+	txMaxBackoff  = 200 * time.Millisecond // максимальная пауза между повторами
+)
+
+// inTx выполняет fn в транзакции и повторяет ее при ошибках сериализации
+// и взаимных блокировках с экспоненциально растущей паузой
+func (r PostgresDB) inTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	backoff := txBaseBackoff
+
+	var err error
+	for attempt := 1; attempt <= txMaxAttempts; attempt++ {
+		err = r.execTx(ctx, fn)
+		if !isRetryable(err) {
+			return err
+		}
+		if attempt == txMaxAttempts {
+			break
+		}
+
+		r.Log.Sugar.Debugw("transaction conflict, retrying", "attempt", attempt, "error", err)
+
+		// добавляем случайную составляющую, чтобы конкурирующие транзакции не повторялись синхронно
+		pause := backoff/2 + rand.N(backoff/2+1)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(pause):
+		}
+
+		backoff = min(backoff*2, txMaxBackoff)
+	}
+
+	return &apperr.RetryError{Attempts: txMaxAttempts, Err: err}
+}
+
+// execTx выполняет fn в одной транзакции, при ошибке транзакция откатывается
+func (r PostgresDB) execTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	tx, err := r.DB.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.ReadCommitted, AccessMode: pgx.ReadWrite})
+	if err != nil {
+		return err
+	}
+
+	// после коммита откат ничего не делает
+	defer tx.Rollback(ctx)
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// isRetryable проверяет, что транзакцию можно безопасно повторить
+func isRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+
+	return pgErr.Code == pgerrors.SerializationFailure || pgErr.Code == pgerrors.DeadlockDetected
+}
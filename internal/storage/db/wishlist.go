@@ -0,0 +1,113 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/plasmatrip/avito_merch/internal/apperr"
+	"github.com/plasmatrip/avito_merch/internal/model"
+	"github.com/plasmatrip/avito_merch/internal/storage/db/queries"
+)
+
+// Wishlist возвращает список желаний пользователя с недостающими при текущем балансе монетами
+func (r PostgresDB) Wishlist(ctx context.Context, userID uuid.UUID) (model.Wishlist, error) {
+	var balance int64
+	err := r.DB.QueryRow(ctx, queries.SelectAccount, pgx.NamedArgs{
+		"user_id": userID,
+	}).Scan(&balance)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return model.Wishlist{}, apperr.ErrAccountNotFound
+		}
+		return model.Wishlist{}, err
+	}
+
+	rows, err := r.DB.Query(ctx, queries.SelectWishlist, pgx.NamedArgs{
+		"user_id": userID,
+	})
+	if err != nil {
+		return model.Wishlist{}, err
+	}
+	defer rows.Close()
+
+	items := make([]model.WishlistItem, 0)
+	for rows.Next() {
+		item := model.WishlistItem{}
+
+		err := rows.Scan(
+			&item.Name,
+			&item.Price,
+			&item.Available,
+			&item.Stock,
+		)
+		if err != nil {
+			return model.Wishlist{}, err
+		}
+
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return model.Wishlist{}, err
+	}
+
+	return model.NewWishlist(balance, items), nil
+}
+
+// AddWishlistItem добавляет мерч в список желаний, снятый с продажи мерч тоже можно добавить
+func (r PostgresDB) AddWishlistItem(ctx context.Context, userID uuid.UUID, name string) (model.Wishlist, error) {
+	ct, err := r.DB.Exec(ctx, queries.InsertWishlistItem, pgx.NamedArgs{
+		"user_id": userID,
+		"name":    name,
+	})
+	if err != nil {
+		return model.Wishlist{}, err
+	}
+
+	// мерч уже в списке или не найден
+	if ct.RowsAffected() == 0 {
+		if _, err := r.MerchItem(ctx, name); err != nil {
+			return model.Wishlist{}, err
+		}
+	}
+
+	return r.Wishlist(ctx, userID)
+}
+
+// RemoveWishlistItem убирает мерч из списка желаний
+func (r PostgresDB) RemoveWishlistItem(ctx context.Context, userID uuid.UUID, name string) (model.Wishlist, error) {
+	_, err := r.DB.Exec(ctx, queries.DeleteWishlistItem, pgx.NamedArgs{
+		"user_id": userID,
+		"name":    name,
+	})
+	if err != nil {
+		return model.Wishlist{}, err
+	}
+
+	return r.Wishlist(ctx, userID)
+}
+
+// Notifications возвращает последние limit уведомлений пользователя, от новых к старым.
+// Уведомления о мерче из списка желаний создаются триггером при изменении каталога
+func (r PostgresDB) Notifications(ctx context.Context, userID uuid.UUID, limit int) ([]model.Notification, error) {
+	rows, err := r.DB.Query(ctx, queries.SelectNotifications, pgx.NamedArgs{
+		"user_id": userID,
+		"limit":   limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByPos[model.Notification])
+}
+
+// ReadNotifications отмечает все уведомления пользователя прочитанными
+func (r PostgresDB) ReadNotifications(ctx context.Context, userID uuid.UUID) error {
+	_, err := r.DB.Exec(ctx, queries.UpdateNotificationsRead, pgx.NamedArgs{
+		"user_id": userID,
+		"read_at": time.Now(),
+	})
+	return err
+}
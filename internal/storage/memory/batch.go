@@ -0,0 +1,66 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/plasmatrip/avito_merch/internal/apperr"
+	"github.com/plasmatrip/avito_merch/internal/model"
+)
+
+// SendCoinBatch переводит монеты нескольким пользователям атомарно: выполняются все переводы или ни одного.
+// Если отдельные переводы не прошли проверку, возвращается *apperr.BatchValidationError
+func (m *MemoryDB) SendCoinBatch(ctx context.Context, fromUser uuid.UUID, transfers []model.SendCoinRequest) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	//проверяем наличие счета отправителя
+	from, ok := m.accounts[fromUser]
+	if !ok {
+		return apperr.ErrSenderNotFound
+	}
+
+	// проверяем каждого получателя
+	errs := make([]error, len(transfers))
+	failed := false
+	var total int64
+	for i, t := range transfers {
+		toUser, ok := m.logins[t.ToUser]
+		switch {
+		case !ok:
+			errs[i] = apperr.ErrRecipientNotFound
+		case toUser == fromUser:
+			errs[i] = apperr.ErrSenderAndRecipientAreTheSame
+		}
+		failed = failed || errs[i] != nil
+		total += t.Amount
+	}
+	if failed {
+		return &apperr.BatchValidationError{Errors: errs}
+	}
+
+	// проверяем баланс отправителя на всю сумму пакета
+	if from.amount < total {
+		return apperr.ErrInsufficientFunds
+	}
+
+	// проверяем лимиты для каждого перевода с учетом предыдущих переводов пакета
+	limits, sent := m.transferLimits(fromUser)
+	batchSent := sent
+	for _, t := range transfers {
+		if !limits.Allows(batchSent, t.Amount) {
+			return &apperr.TransferLimitError{Remaining: limits.Remaining(sent)}
+		}
+		batchSent += t.Amount
+	}
+
+	// после проверок переводы не могут завершиться ошибкой
+	for _, t := range transfers {
+		if _, err := m.transfer(fromUser, t); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
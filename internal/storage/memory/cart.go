@@ -0,0 +1,130 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/plasmatrip/avito_merch/internal/apperr"
+	"github.com/plasmatrip/avito_merch/internal/model"
+)
+
+type cartItem struct {
+	quantity int
+	price    int64 // цена мерча при добавлении в корзину
+}
+
+// Cart возвращает корзину пользователя со стоимостью по текущим ценам
+func (m *MemoryDB) Cart(ctx context.Context, userID uuid.UUID) (model.Cart, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.cart(userID), nil
+}
+
+// SetCartItem кладет мерч в корзину или изменяет его количество, запоминая текущую цену
+func (m *MemoryDB) SetCartItem(ctx context.Context, userID uuid.UUID, name string, quantity int) (model.Cart, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	it, ok := m.merch[name]
+	if !ok {
+		return model.Cart{}, apperr.ErrItemNotFound
+	}
+
+	if !it.available {
+		return model.Cart{}, apperr.ErrItemNotAvailable
+	}
+
+	if m.carts[userID] == nil {
+		m.carts[userID] = make(map[string]*cartItem)
+	}
+	m.carts[userID][name] = &cartItem{
+		quantity: quantity,
+		price:    it.price,
+	}
+
+	return m.cart(userID), nil
+}
+
+// RemoveCartItem убирает мерч из корзины
+func (m *MemoryDB) RemoveCartItem(ctx context.Context, userID uuid.UUID, name string) (model.Cart, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.carts[userID], name)
+
+	return m.cart(userID), nil
+}
+
+// ClearCart очищает корзину
+func (m *MemoryDB) ClearCart(ctx context.Context, userID uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.carts, userID)
+
+	return nil
+}
+
+// Checkout оформляет заказ из корзины и очищает ее. Если цена мерча изменилась
+// после добавления в корзину, заказ оформляется только с acceptPriceChanges,
+// promo - необязательный промокод
+func (m *MemoryDB) Checkout(ctx context.Context, userID uuid.UUID, acceptPriceChanges bool, promo string) (model.Order, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.carts[userID]) == 0 {
+		return model.Order{}, apperr.ErrCartEmpty
+	}
+
+	items := make([]model.OrderItem, 0, len(m.carts[userID]))
+	prices := make(map[string]int64, len(m.carts[userID]))
+	for name, item := range m.carts[userID] {
+		items = append(items, model.OrderItem{Name: name, Quantity: item.quantity})
+		prices[name] = item.price
+	}
+
+	if acceptPriceChanges {
+		prices = nil
+	}
+
+	order, err := m.placeOrder(userID, items, prices, promo)
+	if err != nil {
+		return model.Order{}, err
+	}
+
+	delete(m.carts, userID)
+
+	return order, nil
+}
+
+// cart собирает корзину пользователя, вызывается под блокировкой
+func (m *MemoryDB) cart(userID uuid.UUID) model.Cart {
+	cart := model.Cart{Items: make([]model.CartItem, 0, len(m.carts[userID]))}
+
+	for name, c := range m.carts[userID] {
+		it := m.merch[name]
+
+		item := model.CartItem{
+			Name:         name,
+			Quantity:     c.quantity,
+			Price:        it.price,
+			AddedPrice:   c.price,
+			PriceChanged: it.price != c.price,
+			Available:    it.available,
+			Total:        it.price * int64(c.quantity),
+		}
+
+		cart.Items = append(cart.Items, item)
+		cart.Total += item.Total
+		cart.PriceChanged = cart.PriceChanged || item.PriceChanged
+	}
+
+	sort.Slice(cart.Items, func(i, j int) bool {
+		return cart.Items[i].Name < cart.Items[j].Name
+	})
+
+	return cart
+}
@@ -0,0 +1,149 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/plasmatrip/avito_merch/internal/apperr"
+	"github.com/plasmatrip/avito_merch/internal/model"
+)
+
+type coinRequest struct {
+	id          uuid.UUID
+	date        time.Time
+	requesterID uuid.UUID
+	payerID     uuid.UUID
+	amount      int64
+	message     string
+	status      string
+	expiresAt   time.Time
+	resolvedAt  *time.Time
+}
+
+// CreateCoinRequest создает запрос монет пользователя userID у пользователя req.FromUser, действующий в течение ttl
+func (m *MemoryDB) CreateCoinRequest(ctx context.Context, userID uuid.UUID, req model.NewCoinRequest, ttl time.Duration) (model.CoinRequest, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.accounts[userID]; !ok {
+		return model.CoinRequest{}, apperr.ErrAccountNotFound
+	}
+
+	payerID, ok := m.logins[req.FromUser]
+	if !ok {
+		return model.CoinRequest{}, apperr.ErrUserNotFound
+	}
+
+	if payerID == userID {
+		return model.CoinRequest{}, apperr.ErrSenderAndRecipientAreTheSame
+	}
+
+	date := time.Now()
+	request := &coinRequest{
+		id:          uuid.Must(uuid.NewV4()),
+		date:        date,
+		requesterID: userID,
+		payerID:     payerID,
+		amount:      req.Amount,
+		message:     req.Message,
+		status:      model.CoinRequestPending,
+		expiresAt:   date.Add(ttl),
+	}
+	m.coinRequests[request.id] = request
+
+	return m.coinRequestModel(request), nil
+}
+
+// CoinRequests возвращает ожидающие решения запросы монет у пользователя, от старых к новым
+func (m *MemoryDB) CoinRequests(ctx context.Context, userID uuid.UUID) ([]model.CoinRequest, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	requests := make([]model.CoinRequest, 0)
+	for _, cr := range m.coinRequests {
+		if cr.payerID != userID {
+			continue
+		}
+		if request := m.coinRequestModel(cr); request.Status == model.CoinRequestPending {
+			requests = append(requests, request)
+		}
+	}
+
+	sort.Slice(requests, func(i, j int) bool {
+		return requests[i].Date.Before(requests[j].Date)
+	})
+
+	return requests, nil
+}
+
+// ApproveCoinRequest одобряет запрос монет и переводит монеты запросившему пользователю
+func (m *MemoryDB) ApproveCoinRequest(ctx context.Context, userID, requestID uuid.UUID) (model.CoinRequest, error) {
+	return m.resolveCoinRequest(userID, requestID, model.CoinRequestApproved)
+}
+
+// DeclineCoinRequest отклоняет запрос монет
+func (m *MemoryDB) DeclineCoinRequest(ctx context.Context, userID, requestID uuid.UUID) (model.CoinRequest, error) {
+	return m.resolveCoinRequest(userID, requestID, model.CoinRequestDeclined)
+}
+
+// resolveCoinRequest переводит ожидающий запрос монет в состояние status
+func (m *MemoryDB) resolveCoinRequest(userID, requestID uuid.UUID, status string) (model.CoinRequest, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cr, ok := m.coinRequests[requestID]
+	if !ok || cr.payerID != userID {
+		return model.CoinRequest{}, apperr.ErrCoinRequestNotFound
+	}
+
+	request := m.coinRequestModel(cr)
+	switch request.Status {
+	case model.CoinRequestPending:
+	case model.CoinRequestExpired:
+		return model.CoinRequest{}, apperr.ErrCoinRequestExpired
+	default:
+		return model.CoinRequest{}, apperr.ErrCoinRequestResolved
+	}
+
+	// одобренный запрос исполняется обычным переводом
+	if status == model.CoinRequestApproved {
+		_, err := m.transfer(userID, model.SendCoinRequest{
+			ToUser:  request.Requester,
+			Amount:  cr.amount,
+			Message: cr.message,
+		})
+		if err != nil {
+			return model.CoinRequest{}, err
+		}
+	}
+
+	resolvedAt := time.Now()
+	cr.status = status
+	cr.resolvedAt = &resolvedAt
+
+	return m.coinRequestModel(cr), nil
+}
+
+// coinRequestModel возвращает запрос монет, ожидающий запрос с истекшим сроком считается просроченным
+func (m *MemoryDB) coinRequestModel(cr *coinRequest) model.CoinRequest {
+	request := model.CoinRequest{
+		ID:         cr.id,
+		Date:       cr.date,
+		Requester:  m.users[cr.requesterID].login,
+		Payer:      m.users[cr.payerID].login,
+		Amount:     cr.amount,
+		Message:    cr.message,
+		Status:     cr.status,
+		ExpiresAt:  cr.expiresAt,
+		ResolvedAt: cr.resolvedAt,
+	}
+
+	if request.Status == model.CoinRequestPending && !time.Now().Before(request.ExpiresAt) {
+		request.Status = model.CoinRequestExpired
+	}
+
+	return request
+}
@@ -0,0 +1,79 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/plasmatrip/avito_merch/internal/apperr"
+	"github.com/plasmatrip/avito_merch/internal/model"
+)
+
+type grant struct {
+	date   time.Time
+	userID uuid.UUID
+	amount int64
+	reason string
+	period string
+}
+
+type grantPeriod struct {
+	userID uuid.UUID
+	period string
+}
+
+// GrantCoins начисляет монеты со счета выпуска перечисленным пользователям или всем сразу.
+// Пользователи, уже получившие начисление за указанный период, пропускаются,
+// возвращается количество получателей
+func (m *MemoryDB) GrantCoins(ctx context.Context, req model.GrantRequest) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var userIDs []uuid.UUID
+	if req.All {
+		userIDs = make([]uuid.UUID, 0, len(m.accounts))
+		for userID := range m.accounts {
+			userIDs = append(userIDs, userID)
+		}
+	} else {
+		unique := make(map[uuid.UUID]struct{}, len(req.Users))
+		for _, login := range req.Users {
+			userID, ok := m.logins[login]
+			if !ok {
+				return 0, apperr.ErrRecipientNotFound
+			}
+			if _, ok := unique[userID]; !ok {
+				unique[userID] = struct{}{}
+				userIDs = append(userIDs, userID)
+			}
+		}
+	}
+
+	granted := 0
+	date := time.Now()
+	for _, userID := range userIDs {
+		if req.Period != "" {
+			key := grantPeriod{userID: userID, period: req.Period}
+			if _, ok := m.grantPeriods[key]; ok {
+				continue
+			}
+			m.grantPeriods[key] = struct{}{}
+		}
+
+		id := uuid.Must(uuid.NewV4())
+		m.grants[id] = grant{
+			date:   date,
+			userID: userID,
+			amount: req.Amount,
+			reason: req.Reason,
+			period: req.Period,
+		}
+
+		// начисляем монеты со счета выпуска
+		m.post(model.OperationGrant, id, m.issuance, m.accounts[userID], req.Amount)
+		granted++
+	}
+
+	return granted, nil
+}
@@ -0,0 +1,135 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/plasmatrip/avito_merch/internal/apperr"
+	"github.com/plasmatrip/avito_merch/internal/model"
+)
+
+// TransactionHistory возвращает страницу истории операций пользователя, от новых к старым
+func (m *MemoryDB) TransactionHistory(ctx context.Context, userID uuid.UUID, filter model.HistoryFilter) (model.HistoryPage, error) {
+	var page model.HistoryPage
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	//проверяем наличие счета пользователя
+	acc, ok := m.accounts[userID]
+	if !ok {
+		return page, apperr.ErrAccountNotFound
+	}
+
+	names := make(map[uuid.UUID]string, len(m.merch))
+	for _, it := range m.merch {
+		names[it.id] = it.name
+	}
+
+	purchased := make(map[uuid.UUID]purchase)
+	gifts := make(map[uuid.UUID]purchase) // подарки, оплаченные другими пользователями
+	for _, p := range m.purchases {
+		switch {
+		case p.userID == userID:
+			purchased[p.id] = p
+			// возврат записан в журнал своей операцией
+			if p.refundedAt != nil {
+				purchased[p.refundID] = p
+			}
+		case p.ownerID == userID:
+			gifts[p.id] = p
+		}
+	}
+
+	messages := m.transferMessages()
+
+	// собираем проводки по счету с остатком после каждой из них
+	var entries []model.HistoryEntry
+	var balance int64
+	for _, e := range m.ledger {
+		// подарок попадает в историю получателя с нулевой суммой
+		if p, ok := gifts[e.operationID]; ok && e.operation == model.OperationPurchase && e.amount < 0 {
+			entry := model.HistoryEntry{
+				ID:           e.id,
+				OperationID:  e.operationID,
+				Seq:          e.seq,
+				Date:         e.date,
+				Type:         model.OperationGift,
+				Direction:    model.DirectionIn,
+				Counterpart:  m.users[p.userID].login,
+				Description:  p.message,
+				Item:         names[p.merchID],
+				BalanceAfter: balance,
+			}
+			if matchHistoryFilter(entry, filter) {
+				entries = append(entries, entry)
+			}
+			continue
+		}
+
+		if e.accountID != acc.id {
+			continue
+		}
+
+		balance += e.amount
+
+		entry := model.HistoryEntry{
+			ID:           e.id,
+			OperationID:  e.operationID,
+			Seq:          e.seq,
+			Date:         e.date,
+			Type:         e.operation,
+			Direction:    model.DirectionIn,
+			Amount:       e.amount,
+			BalanceAfter: balance,
+		}
+		if e.amount < 0 {
+			entry.Direction = model.DirectionOut
+			entry.Amount = -e.amount
+		}
+
+		switch e.operation {
+		case model.OperationTransfer:
+			entry.Counterpart = m.users[m.counterpart(e).userID].login
+			entry.Description = messages[e.operationID]
+		case model.OperationPurchase, model.OperationRefund:
+			entry.Counterpart = names[purchased[e.operationID].merchID]
+			entry.Description = purchased[e.operationID].message
+		case model.OperationGrant, model.OperationWelcome:
+			entry.Description = m.grants[e.operationID].reason
+		}
+
+		if matchHistoryFilter(entry, filter) {
+			entries = append(entries, entry)
+		}
+	}
+
+	page.Entries = make([]model.HistoryEntry, 0, filter.Limit)
+	for i := len(entries) - 1; i >= 0; i-- {
+		if len(page.Entries) == filter.Limit {
+			page.Next = page.Entries[len(page.Entries)-1].Seq
+			break
+		}
+		page.Entries = append(page.Entries, entries[i])
+	}
+
+	return page, nil
+}
+
+// matchHistoryFilter проверяет, что запись истории подходит под фильтр
+func matchHistoryFilter(e model.HistoryEntry, filter model.HistoryFilter) bool {
+	switch {
+	case filter.Before != 0 && e.Seq >= filter.Before:
+		return false
+	case filter.Direction != "" && e.Direction != filter.Direction:
+		return false
+	case filter.Counterpart != "" && e.Counterpart != filter.Counterpart:
+		return false
+	case !filter.From.IsZero() && e.Date.Before(filter.From):
+		return false
+	case !filter.To.IsZero() && !e.Date.Before(filter.To):
+		return false
+	}
+	return true
+}
@@ -0,0 +1,76 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/plasmatrip/avito_merch/internal/apperr"
+	"github.com/plasmatrip/avito_merch/internal/model"
+)
+
+type idempotencyKey struct {
+	userID uuid.UUID
+	key    string
+}
+
+type idempotentRequest struct {
+	request string
+	date    time.Time
+	resp    *model.IdempotentResponse // nil, пока запрос выполняется
+}
+
+// AcquireIdempotencyKey резервирует ключ идемпотентности за запросом, ключи старше ttl резервируются заново
+func (m *MemoryDB) AcquireIdempotencyKey(ctx context.Context, userID uuid.UUID, key, request string, ttl time.Duration) (*model.IdempotentResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	k := idempotencyKey{userID: userID, key: key}
+
+	saved, ok := m.idempotency[k]
+	if !ok || saved.date.Before(now.Add(-ttl)) {
+		m.idempotency[k] = &idempotentRequest{
+			request: request,
+			date:    now,
+		}
+		return nil, nil
+	}
+
+	if saved.request != request {
+		return nil, apperr.ErrIdempotencyKeyReused
+	}
+
+	if saved.resp == nil {
+		return nil, apperr.ErrIdempotencyKeyInProgress
+	}
+
+	resp := *saved.resp
+	return &resp, nil
+}
+
+// SaveIdempotentResponse сохраняет ответ на запрос с ключом идемпотентности
+func (m *MemoryDB) SaveIdempotentResponse(ctx context.Context, userID uuid.UUID, key string, resp model.IdempotentResponse) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if saved, ok := m.idempotency[idempotencyKey{userID: userID, key: key}]; ok {
+		saved.resp = &resp
+	}
+
+	return nil
+}
+
+// ReleaseIdempotencyKey освобождает ключ незавершенного запроса, чтобы его можно было повторить
+func (m *MemoryDB) ReleaseIdempotencyKey(ctx context.Context, userID uuid.UUID, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	k := idempotencyKey{userID: userID, key: key}
+	if saved, ok := m.idempotency[k]; ok && saved.resp == nil {
+		delete(m.idempotency, k)
+	}
+
+	return nil
+}
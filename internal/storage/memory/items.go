@@ -0,0 +1,126 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/plasmatrip/avito_merch/internal/apperr"
+	"github.com/plasmatrip/avito_merch/internal/model"
+)
+
+type itemOwner struct {
+	ownerID    uuid.UUID
+	acquiredAt time.Time
+}
+
+type itemTransfer struct {
+	id          uuid.UUID
+	date        time.Time
+	fromUserID  uuid.UUID
+	toUserID    uuid.UUID
+	merchID     uuid.UUID
+	purchaseIDs []uuid.UUID // переданные единицы мерча
+}
+
+// addPurchase сохраняет покупку и передает мерч во владение ее владельцу, вызывается под блокировкой
+func (m *MemoryDB) addPurchase(p purchase) {
+	m.purchases = append(m.purchases, p)
+	m.ownership[p.id] = &itemOwner{ownerID: p.ownerID, acquiredAt: p.date}
+}
+
+// TransferItems передает req.Quantity единиц мерча из инвентаря пользователя другому пользователю,
+// передаются единицы, полученные раньше остальных
+func (m *MemoryDB) TransferItems(ctx context.Context, userID uuid.UUID, req model.ItemTransferRequest) (model.ItemTransfer, error) {
+	if req.Quantity <= 0 {
+		return model.ItemTransfer{}, apperr.ErrInvalidQuantity
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	toUser, ok := m.logins[req.ToUser]
+	if !ok {
+		return model.ItemTransfer{}, apperr.ErrRecipientNotFound
+	}
+
+	if toUser == userID {
+		return model.ItemTransfer{}, apperr.ErrSenderAndRecipientAreTheSame
+	}
+
+	fromUser, ok := m.users[userID]
+	if !ok {
+		return model.ItemTransfer{}, apperr.ErrSenderNotFound
+	}
+
+	var owned []purchase
+	if it, ok := m.merch[req.Item]; ok {
+		for _, p := range m.purchases {
+			if p.merchID == it.id && p.refundedAt == nil && m.ownership[p.id].ownerID == userID {
+				owned = append(owned, p)
+			}
+		}
+	}
+
+	if len(owned) < req.Quantity {
+		return model.ItemTransfer{}, apperr.ErrNotEnoughItems
+	}
+
+	sort.SliceStable(owned, func(i, j int) bool {
+		return m.ownership[owned[i].id].acquiredAt.Before(m.ownership[owned[j].id].acquiredAt)
+	})
+
+	t := itemTransfer{
+		id:         uuid.Must(uuid.NewV4()),
+		date:       time.Now(),
+		fromUserID: userID,
+		toUserID:   toUser,
+		merchID:    owned[0].merchID,
+	}
+	for _, p := range owned[:req.Quantity] {
+		t.purchaseIDs = append(t.purchaseIDs, p.id)
+		m.ownership[p.id] = &itemOwner{ownerID: toUser, acquiredAt: t.date}
+	}
+	m.handovers = append(m.handovers, t)
+
+	return model.ItemTransfer{
+		ID:       t.id,
+		Date:     t.date,
+		FromUser: fromUser.login,
+		ToUser:   req.ToUser,
+		Item:     req.Item,
+		Quantity: req.Quantity,
+	}, nil
+}
+
+// ItemTransfers возвращает передачи мерча, в которых участвовал пользователь, от новых к старым
+func (m *MemoryDB) ItemTransfers(ctx context.Context, userID uuid.UUID) ([]model.ItemTransfer, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	names := make(map[uuid.UUID]string, len(m.merch))
+	for _, it := range m.merch {
+		names[it.id] = it.name
+	}
+
+	transfers := make([]model.ItemTransfer, 0)
+	for i := len(m.handovers) - 1; i >= 0; i-- {
+		t := m.handovers[i]
+		if t.fromUserID != userID && t.toUserID != userID {
+			continue
+		}
+
+		transfers = append(transfers, model.ItemTransfer{
+			ID:       t.id,
+			Date:     t.date,
+			FromUser: m.users[t.fromUserID].login,
+			ToUser:   m.users[t.toUserID].login,
+			Item:     names[t.merchID],
+			Quantity: len(t.purchaseIDs),
+		})
+	}
+
+	return transfers, nil
+}
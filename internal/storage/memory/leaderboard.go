@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/plasmatrip/avito_merch/internal/apperr"
+	"github.com/plasmatrip/avito_merch/internal/model"
+)
+
+// Leaderboard возвращает рейтинги пользователей за период, пользователи, отказавшиеся от участия, не учитываются
+func (m *MemoryDB) Leaderboard(ctx context.Context, filter model.LeaderboardFilter) (model.Leaderboard, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	received := make(map[uuid.UUID]int64)
+	sent := make(map[uuid.UUID]int64)
+	spent := make(map[uuid.UUID]int64)
+
+	for _, t := range m.transactions {
+		if t.date.Before(filter.Since) {
+			continue
+		}
+		received[t.toUserID] += t.amount
+		sent[t.fromUserID] += t.amount
+	}
+
+	for _, p := range m.purchases {
+		if p.refundedAt != nil || p.date.Before(filter.Since) {
+			continue
+		}
+		for _, i := range m.operations[p.id] {
+			if e := m.ledger[i]; e.operation == model.OperationPurchase && e.amount < 0 {
+				spent[p.userID] -= e.amount
+			}
+		}
+	}
+
+	return model.Leaderboard{
+		Receivers:  m.leaderboardEntries(received, filter.Limit),
+		Senders:    m.leaderboardEntries(sent, filter.Limit),
+		Purchasers: m.leaderboardEntries(spent, filter.Limit),
+	}, nil
+}
+
+// SetLeaderboardHidden скрывает пользователя из рейтинга или возвращает его в рейтинг
+func (m *MemoryDB) SetLeaderboardHidden(ctx context.Context, userID uuid.UUID, hidden bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[userID]
+	if !ok {
+		return apperr.ErrUserNotFound
+	}
+
+	u.leaderboardHidden = hidden
+
+	return nil
+}
+
+// leaderboardEntries сортирует суммы пользователей по убыванию и оставляет первые limit, вызывается под блокировкой
+func (m *MemoryDB) leaderboardEntries(amounts map[uuid.UUID]int64, limit int) []model.LeaderboardEntry {
+	entries := make([]model.LeaderboardEntry, 0, len(amounts))
+	for userID, amount := range amounts {
+		u, ok := m.users[userID]
+		if !ok || u.leaderboardHidden {
+			continue
+		}
+		entries = append(entries, model.LeaderboardEntry{Login: u.login, Amount: amount})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Amount != entries[j].Amount {
+			return entries[i].Amount > entries[j].Amount
+		}
+		return entries[i].Login < entries[j].Login
+	})
+
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+
+	return entries
+}
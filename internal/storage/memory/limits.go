@@ -0,0 +1,60 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/plasmatrip/avito_merch/internal/apperr"
+	"github.com/plasmatrip/avito_merch/internal/model"
+)
+
+// SetTransferLimits устанавливает индивидуальные лимиты переводов пользователя и возвращает действующие лимиты
+func (m *MemoryDB) SetTransferLimits(ctx context.Context, login string, req model.TransferLimitsRequest) (model.TransferLimits, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	userID, ok := m.logins[login]
+	if !ok {
+		return model.TransferLimits{}, apperr.ErrUserNotFound
+	}
+
+	m.limits[userID] = req
+
+	return m.Limits.Override(req), nil
+}
+
+// ResetTransferLimits удаляет индивидуальные лимиты переводов пользователя и возвращает лимиты по умолчанию
+func (m *MemoryDB) ResetTransferLimits(ctx context.Context, login string) (model.TransferLimits, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	userID, ok := m.logins[login]
+	if !ok {
+		return model.TransferLimits{}, apperr.ErrUserNotFound
+	}
+
+	delete(m.limits, userID)
+
+	return m.Limits, nil
+}
+
+// transferLimits возвращает действующие лимиты переводов пользователя и сумму его переводов за последние 24 часа,
+// вызывается под блокировкой
+func (m *MemoryDB) transferLimits(userID uuid.UUID) (model.TransferLimits, int64) {
+	limits := m.Limits.Override(m.limits[userID])
+	if limits.Daily == 0 {
+		return limits, 0
+	}
+
+	var sent int64
+	since := time.Now().Add(-model.TransferLimitWindow)
+	for _, t := range m.transactions {
+		if t.fromUserID == userID && t.date.After(since) {
+			sent += t.amount
+		}
+	}
+
+	return limits, sent
+}
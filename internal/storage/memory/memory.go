@@ -0,0 +1,601 @@
+package memory
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/plasmatrip/avito_merch/internal/apperr"
+	"github.com/plasmatrip/avito_merch/internal/logger"
+	"github.com/plasmatrip/avito_merch/internal/model"
+)
+
+// каталог мерча, аналогичный миграциям 0001_init и 0005_merch_catalog
+var defaultMerch = []struct {
+	name        string
+	price       int64
+	description string
+	category    string
+}{
+	{"t-shirt", 80, "T-shirt with the Avito logo", "clothing"},
+	{"cup", 20, "Ceramic cup", "accessories"},
+	{"book", 50, "Notebook for ideas", "stationery"},
+	{"pen", 10, "Ballpoint pen", "stationery"},
+	{"powerbank", 200, "Portable charger", "accessories"},
+	{"hoody", 300, "Warm hoody", "clothing"},
+	{"umbrella", 200, "Folding umbrella", "accessories"},
+	{"socks", 10, "Pair of socks", "clothing"},
+	{"wallet", 50, "Leather wallet", "accessories"},
+	{"pink-hoody", 500, "Pink hoody", "clothing"},
+}
+
+type user struct {
+	id                uuid.UUID
+	date              time.Time
+	login             string
+	password          string
+	role              string
+	leaderboardHidden bool // пользователь отказался от участия в рейтинге
+}
+
+type merch struct {
+	id          uuid.UUID
+	name        string
+	price       int64
+	description string
+	available   bool
+	stock       *int // остаток, nil - без ограничения
+	category    string
+}
+
+type purchase struct {
+	id         uuid.UUID
+	date       time.Time
+	userID     uuid.UUID
+	ownerID    uuid.UUID // владелец мерча, отличается от userID для подарка
+	merchID    uuid.UUID
+	orderID    uuid.UUID // uuid.Nil для покупки одного мерча
+	message    string    // поздравление к подарку
+	discount   int64     // скидка по промокоду
+	promoCode  string    // примененный промокод
+	pricePaid  int64     // списанная цена с учетом скидки
+	refundedAt *time.Time
+	refundID   uuid.UUID // операция возврата в журнале
+}
+
+type transaction struct {
+	id         uuid.UUID
+	date       time.Time
+	fromUserID uuid.UUID
+	toUserID   uuid.UUID
+	amount     int64
+	message    string
+}
+
+type account struct {
+	id     uuid.UUID
+	userID uuid.UUID
+	kind   string
+	amount int64 // производный баланс, изменяется только проводками
+}
+
+type ledgerEntry struct {
+	id          uuid.UUID
+	seq         int64
+	date        time.Time
+	operation   string
+	operationID uuid.UUID
+	accountID   uuid.UUID
+	amount      int64 // зачисление положительное, списание отрицательное
+}
+
+// MemoryDB - хранилище в памяти процесса, используется в режиме разработки и в тестах
+type MemoryDB struct {
+	mu     sync.RWMutex
+	Log    logger.Logger
+	Bonus  model.BonusPolicy    // начисления новому пользователю
+	Limits model.TransferLimits // лимиты переводов по умолчанию
+
+	users        map[uuid.UUID]*user
+	logins       map[string]uuid.UUID
+	accounts     map[uuid.UUID]*account // счета пользователей по id пользователя
+	accountsByID map[uuid.UUID]*account // все счета по id счета
+	issuance     *account               // системный счет выпуска монет
+	storeRevenue *account               // системный счет выручки магазина
+	merch        map[string]*merch
+	purchases    []purchase
+	transactions []transaction
+	ledger       []ledgerEntry
+	operations   map[uuid.UUID][]int // индексы проводок в ledger по id операции
+	idempotency  map[idempotencyKey]*idempotentRequest
+	carts        map[uuid.UUID]map[string]*cartItem        // корзины по id пользователя и названию мерча
+	grants       map[uuid.UUID]grant                       // начисления по id
+	grantPeriods map[grantPeriod]struct{}                  // периоды, за которые пользователи получили начисления
+	coinRequests map[uuid.UUID]*coinRequest                // запросы монет по id
+	limits       map[uuid.UUID]model.TransferLimitsRequest // индивидуальные лимиты переводов по id пользователя
+	ownership    map[uuid.UUID]*itemOwner                  // текущие владельцы мерча по id покупки
+	handovers    []itemTransfer                            // передачи мерча между пользователями
+	wishlists    map[uuid.UUID]map[uuid.UUID]wishlistItem  // списки желаний по id пользователя и id мерча
+	notices      []notification                            // уведомления пользователей
+	promos       map[string]*promoCode                     // промокоды по коду
+	prices       map[uuid.UUID][]model.PricePoint          // история цен по id мерча
+}
+
+// NewRepository создает хранилище в памяти с каталогом мерча по умолчанию
+func NewRepository(log logger.Logger) *MemoryDB {
+	m := &MemoryDB{
+		Log:          log,
+		Bonus:        model.BonusPolicy{StartingBalance: model.DefaultStartingBalance},
+		users:        make(map[uuid.UUID]*user),
+		logins:       make(map[string]uuid.UUID),
+		accounts:     make(map[uuid.UUID]*account),
+		accountsByID: make(map[uuid.UUID]*account),
+		merch:        make(map[string]*merch),
+		operations:   make(map[uuid.UUID][]int),
+		idempotency:  make(map[idempotencyKey]*idempotentRequest),
+		carts:        make(map[uuid.UUID]map[string]*cartItem),
+		grants:       make(map[uuid.UUID]grant),
+		grantPeriods: make(map[grantPeriod]struct{}),
+		coinRequests: make(map[uuid.UUID]*coinRequest),
+		limits:       make(map[uuid.UUID]model.TransferLimitsRequest),
+		ownership:    make(map[uuid.UUID]*itemOwner),
+		wishlists:    make(map[uuid.UUID]map[uuid.UUID]wishlistItem),
+		promos:       make(map[string]*promoCode),
+		prices:       make(map[uuid.UUID][]model.PricePoint),
+	}
+
+	m.issuance = m.newAccount(uuid.Nil, model.AccountIssuance)
+	m.storeRevenue = m.newAccount(uuid.Nil, model.AccountStoreRevenue)
+
+	for _, item := range defaultMerch {
+		m.merch[item.name] = &merch{
+			id:          uuid.Must(uuid.NewV4()),
+			name:        item.name,
+			price:       item.price,
+			description: item.description,
+			available:   true,
+			category:    item.category,
+		}
+		m.recordPrice(m.merch[item.name])
+	}
+
+	return m
+}
+
+// Ping проверяет доступность хранилища
+func (m *MemoryDB) Ping(ctx context.Context) error {
+	return ctx.Err()
+}
+
+// Close освобождает ресурсы хранилища
+func (m *MemoryDB) Close() {
+}
+
+// UserAuth проверка аутентификационных данных, в случае отсутсвия пользователя - регистрация
+func (m *MemoryDB) UserAuth(ctx context.Context, userLogin model.AuthRequest) (uuid.UUID, error) {
+	h := sha256.New()
+	h.Write([]byte(userLogin.Password))
+	hash := hex.EncodeToString(h.Sum(nil))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if id, ok := m.logins[userLogin.UserName]; ok {
+		if m.users[id].password != hash {
+			return uuid.Nil, apperr.ErrAuthenticationError
+		}
+		return id, nil
+	}
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	u := &user{
+		id:       id,
+		date:     time.Now(),
+		login:    userLogin.UserName,
+		password: hash,
+		role:     model.RoleUser,
+	}
+	m.users[id] = u
+	m.logins[userLogin.UserName] = id
+	acc := m.newAccount(id, model.AccountUser)
+	m.accounts[id] = acc
+
+	// начисляем стартовые монеты со счета выпуска
+	if m.Bonus.StartingBalance > 0 {
+		m.post(model.OperationBonus, uuid.Must(uuid.NewV4()), m.issuance, acc, m.Bonus.StartingBalance)
+	}
+
+	// начисляем бонусы по правилам, название правила сохраняется как причина начисления
+	for _, rule := range m.Bonus.Bonuses(u.date) {
+		grantID := uuid.Must(uuid.NewV4())
+		m.grants[grantID] = grant{
+			date:   u.date,
+			userID: id,
+			amount: rule.Amount,
+			reason: rule.Name,
+			period: rule.Period(),
+		}
+		m.grantPeriods[grantPeriod{userID: id, period: rule.Period()}] = struct{}{}
+		m.post(model.OperationWelcome, grantID, m.issuance, acc, rule.Amount)
+	}
+
+	return id, nil
+}
+
+// BuyItem обработка запороса покупки мерча
+func (m *MemoryDB) BuyItem(ctx context.Context, userID uuid.UUID, item, promo string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.buyItem(userID, userID, item, "", promo)
+}
+
+// GiftItem покупает мерч за счет пользователя и передает его в инвентарь получателя
+func (m *MemoryDB) GiftItem(ctx context.Context, userID uuid.UUID, req model.GiftRequest) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ownerID, ok := m.logins[req.ToUser]
+	if !ok {
+		return apperr.ErrRecipientNotFound
+	}
+
+	if ownerID == userID {
+		return apperr.ErrSenderAndRecipientAreTheSame
+	}
+
+	return m.buyItem(userID, ownerID, req.Item, req.Message, "")
+}
+
+// buyItem покупает мерч за счет userID в инвентарь ownerID, вызывается под блокировкой
+func (m *MemoryDB) buyItem(userID, ownerID uuid.UUID, item, message, code string) error {
+	promo, err := m.promo(userID, code)
+	if err != nil {
+		return err
+	}
+
+	it, ok := m.merch[item]
+	if !ok {
+		return apperr.ErrItemNotFound
+	}
+
+	if !it.available {
+		return apperr.ErrItemNotAvailable
+	}
+
+	if it.stock != nil && *it.stock == 0 {
+		return apperr.ErrOutOfStock
+	}
+
+	var discount int64
+	if promo != nil {
+		if !promo.Applies(item, it.category) {
+			return apperr.ErrPromoNotApplicable
+		}
+		discount = promo.Discount(it.price)
+	}
+	price := it.price - discount
+
+	acc, ok := m.accounts[userID]
+	if !ok {
+		return apperr.ErrAccountNotFound
+	}
+
+	if acc.amount < price {
+		return apperr.ErrInsufficientFunds
+	}
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		return err
+	}
+
+	m.addPurchase(purchase{
+		id:        id,
+		date:      time.Now(),
+		userID:    userID,
+		ownerID:   ownerID,
+		merchID:   it.id,
+		message:   message,
+		discount:  discount,
+		promoCode: promo.code(),
+		pricePaid: price,
+	})
+
+	if promo != nil {
+		m.redeemPromo(promo, userID)
+	}
+
+	// уменьшаем остаток ограниченного мерча
+	if it.stock != nil {
+		*it.stock--
+	}
+
+	// списываем стоимость мерча со скидкой в выручку магазина
+	m.post(model.OperationPurchase, id, acc, m.storeRevenue, price)
+
+	return nil
+}
+
+// RefundPurchase возвращает покупку, совершенную не раньше window назад:
+// уплаченная цена возвращается на счет, остаток мерча восстанавливается
+func (m *MemoryDB) RefundPurchase(ctx context.Context, userID, purchaseID uuid.UUID, window time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var p *purchase
+	for i := range m.purchases {
+		if m.purchases[i].id == purchaseID {
+			p = &m.purchases[i]
+			break
+		}
+	}
+
+	// чужую покупку не раскрываем
+	if p == nil || p.userID != userID {
+		return apperr.ErrPurchaseNotFound
+	}
+
+	if p.refundedAt != nil {
+		return apperr.ErrPurchaseAlreadyRefunded
+	}
+
+	// вернуть можно только мерч, который остался у покупателя
+	if m.ownership[p.id].ownerID != userID {
+		return apperr.ErrPurchaseNotOwned
+	}
+
+	now := time.Now()
+	if now.Sub(p.date) > window {
+		return apperr.ErrRefundWindowExpired
+	}
+
+	acc, ok := m.accounts[userID]
+	if !ok {
+		return apperr.ErrAccountNotFound
+	}
+
+	p.refundedAt = &now
+	p.refundID = uuid.Must(uuid.NewV4())
+
+	// возвращаем использование промокода, чтобы восстановились его лимиты
+	m.releasePromo(p)
+
+	for _, it := range m.merch {
+		if it.id == p.merchID && it.stock != nil {
+			wasBuyable := it.buyable()
+			*it.stock++
+			m.notifyWishlist(it, it.price, wasBuyable)
+		}
+	}
+
+	// возвращаем цену, списанную при покупке, а не текущую цену мерча
+	m.post(model.OperationRefund, p.refundID, m.storeRevenue, acc, p.pricePaid)
+
+	return nil
+}
+
+// SendCoin обработка запороса отправки монет
+func (m *MemoryDB) SendCoin(ctx context.Context, fromUser uuid.UUID, sendCoin model.SendCoinRequest) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, err := m.transfer(fromUser, sendCoin)
+	return err
+}
+
+// transfer переводит монеты и возвращает id перевода, вызывается под блокировкой
+func (m *MemoryDB) transfer(fromUser uuid.UUID, sendCoin model.SendCoinRequest) (uuid.UUID, error) {
+	//проверяем наличие счета отправителя
+	from, ok := m.accounts[fromUser]
+	if !ok {
+		return uuid.Nil, apperr.ErrSenderNotFound
+	}
+
+	// проверяем баланс у отправителя
+	if from.amount < sendCoin.Amount {
+		return uuid.Nil, apperr.ErrInsufficientFunds
+	}
+
+	//проверяем наличие счета получателя
+	toUser, ok := m.logins[sendCoin.ToUser]
+	if !ok {
+		return uuid.Nil, apperr.ErrRecipientNotFound
+	}
+
+	// проверяем, что отправитель и получатель не один пользователь
+	if fromUser == toUser {
+		return uuid.Nil, apperr.ErrSenderAndRecipientAreTheSame
+	}
+
+	// проверяем лимиты переводов
+	limits, sent := m.transferLimits(fromUser)
+	if !limits.Allows(sent, sendCoin.Amount) {
+		return uuid.Nil, &apperr.TransferLimitError{Remaining: limits.Remaining(sent)}
+	}
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	m.transactions = append(m.transactions, transaction{
+		id:         id,
+		date:       time.Now(),
+		fromUserID: fromUser,
+		toUserID:   toUser,
+		amount:     sendCoin.Amount,
+		message:    sendCoin.Message,
+	})
+
+	// переводим монеты со счета отправителя на счет получателя
+	m.post(model.OperationTransfer, id, from, m.accounts[toUser], sendCoin.Amount)
+
+	return id, nil
+}
+
+// Info возвращает информацию о монетах, инвентаре и истории транзакций
+func (m *MemoryDB) Info(ctx context.Context, userID uuid.UUID) (model.InfoResponse, error) {
+	var infoResponse model.InfoResponse
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	//проверяем наличие счета пользователя
+	acc, ok := m.accounts[userID]
+	if !ok {
+		return infoResponse, apperr.ErrAccountNotFound
+	}
+
+	messages := m.transferMessages()
+
+	// считаем баланс и историю переводов по журналу проводок
+	received := make(map[string]int64)
+	sent := make(map[string]int64)
+	receivedMessages := make(map[string][]string)
+	sentMessages := make(map[string][]string)
+	for _, e := range m.ledger {
+		if e.accountID != acc.id {
+			continue
+		}
+
+		infoResponse.Coins += e.amount
+
+		if e.operation != model.OperationTransfer {
+			continue
+		}
+
+		counterpart := m.users[m.counterpart(e).userID].login
+		message, hasMessage := messages[e.operationID]
+		if e.amount > 0 {
+			received[counterpart] += e.amount
+			if hasMessage {
+				receivedMessages[counterpart] = append(receivedMessages[counterpart], message)
+			}
+		} else {
+			sent[counterpart] -= e.amount
+			if hasMessage {
+				sentMessages[counterpart] = append(sentMessages[counterpart], message)
+			}
+		}
+	}
+
+	//получаем купленный мерч
+	names := make(map[uuid.UUID]string, len(m.merch))
+	for _, it := range m.merch {
+		names[it.id] = it.name
+	}
+
+	quantities := make(map[string]int)
+	for _, p := range m.purchases {
+		if m.ownership[p.id].ownerID == userID && p.refundedAt == nil {
+			quantities[names[p.merchID]]++
+		}
+	}
+
+	infoResponse.Inventory = make([]model.Inventory, 0, len(quantities))
+	for name, quantity := range quantities {
+		infoResponse.Inventory = append(infoResponse.Inventory, model.Inventory{
+			Type:     name,
+			Quantity: quantity,
+		})
+	}
+	sort.Slice(infoResponse.Inventory, func(i, j int) bool {
+		return infoResponse.Inventory[i].Type < infoResponse.Inventory[j].Type
+	})
+
+	for _, login := range sortedKeys(received) {
+		infoResponse.CoinHistory.Received = append(infoResponse.CoinHistory.Received, model.Received{
+			FromUser: login,
+			Amount:   received[login],
+			Messages: receivedMessages[login],
+		})
+	}
+
+	for _, login := range sortedKeys(sent) {
+		infoResponse.CoinHistory.Sent = append(infoResponse.CoinHistory.Sent, model.Sent{
+			ToUser:   login,
+			Amount:   sent[login],
+			Messages: sentMessages[login],
+		})
+	}
+
+	return infoResponse, nil
+}
+
+// newAccount создает счет указанного вида
+func (m *MemoryDB) newAccount(userID uuid.UUID, kind string) *account {
+	acc := &account{
+		id:     uuid.Must(uuid.NewV4()),
+		userID: userID,
+		kind:   kind,
+	}
+	m.accountsByID[acc.id] = acc
+	return acc
+}
+
+// post записывает в журнал сбалансированную пару проводок:
+// списание amount со счета debit и зачисление на счет credit
+func (m *MemoryDB) post(operation string, operationID uuid.UUID, debit, credit *account, amount int64) {
+	date := time.Now()
+
+	for _, p := range []struct {
+		acc    *account
+		amount int64
+	}{
+		{debit, -amount},
+		{credit, amount},
+	} {
+		m.operations[operationID] = append(m.operations[operationID], len(m.ledger))
+		m.ledger = append(m.ledger, ledgerEntry{
+			id:          uuid.Must(uuid.NewV4()),
+			seq:         int64(len(m.ledger) + 1),
+			date:        date,
+			operation:   operation,
+			operationID: operationID,
+			accountID:   p.acc.id,
+			amount:      p.amount,
+		})
+		p.acc.amount += p.amount
+	}
+}
+
+// counterpart возвращает счет второй проводки той же операции
+func (m *MemoryDB) counterpart(e ledgerEntry) *account {
+	for _, i := range m.operations[e.operationID] {
+		if m.ledger[i].id != e.id {
+			return m.accountsByID[m.ledger[i].accountID]
+		}
+	}
+	return nil
+}
+
+// transferMessages возвращает непустые сообщения переводов по id перевода
+func (m *MemoryDB) transferMessages() map[uuid.UUID]string {
+	messages := make(map[uuid.UUID]string)
+	for _, t := range m.transactions {
+		if t.message != "" {
+			messages[t.id] = t.message
+		}
+	}
+	return messages
+}
+
+// sortedKeys возвращает ключи в лексикографическом порядке
+func sortedKeys(m map[string]int64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
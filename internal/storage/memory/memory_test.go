@@ -0,0 +1,30 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/plasmatrip/avito_merch/internal/logger"
+	"github.com/plasmatrip/avito_merch/internal/storage/memory"
+	"github.com/plasmatrip/avito_merch/internal/storage/storagetest"
+)
+
+func TestConformance(t *testing.T) {
+	logger, err := logger.NewLogger(logger.LogLevelDebug)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	storagetest.Run(t, memory.NewRepository(*logger))
+}
+
+func TestBonusPolicy(t *testing.T) {
+	logger, err := logger.NewLogger(logger.LogLevelDebug)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stor := memory.NewRepository(*logger)
+	stor.Bonus = storagetest.BonusPolicy
+
+	storagetest.RunBonusPolicy(t, stor)
+}
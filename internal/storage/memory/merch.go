@@ -0,0 +1,183 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/plasmatrip/avito_merch/internal/apperr"
+	"github.com/plasmatrip/avito_merch/internal/model"
+)
+
+// Merch возвращает каталог мерча с учетом фильтра
+func (m *MemoryDB) Merch(ctx context.Context, filter model.MerchFilter) ([]model.MerchItem, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	items := make([]model.MerchItem, 0, len(m.merch))
+	for _, it := range m.merch {
+		if filter.MinPrice != 0 && it.price < filter.MinPrice {
+			continue
+		}
+		if filter.MaxPrice != 0 && it.price > filter.MaxPrice {
+			continue
+		}
+		items = append(items, it.item())
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if filter.Desc {
+			a, b = b, a
+		}
+		if filter.Sort == model.MerchSortPrice && a.Price != b.Price {
+			return a.Price < b.Price
+		}
+		return a.Name < b.Name
+	})
+
+	return items, nil
+}
+
+// MerchItem возвращает товар каталога по названию
+func (m *MemoryDB) MerchItem(ctx context.Context, name string) (model.MerchItem, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	it, ok := m.merch[name]
+	if !ok {
+		return model.MerchItem{}, apperr.ErrItemNotFound
+	}
+
+	return it.item(), nil
+}
+
+// CreateMerch добавляет товар в каталог
+func (m *MemoryDB) CreateMerch(ctx context.Context, item model.MerchItem) (model.MerchItem, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.merch[item.Name]; ok {
+		return model.MerchItem{}, apperr.ErrItemAlreadyExists
+	}
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		return model.MerchItem{}, err
+	}
+
+	it := &merch{
+		id:          id,
+		name:        item.Name,
+		price:       item.Price,
+		description: item.Description,
+		available:   item.Available,
+		stock:       copyStock(item.Stock),
+		category:    item.Category,
+	}
+	m.merch[it.name] = it
+	m.recordPrice(it)
+
+	return it.item(), nil
+}
+
+// UpdateMerch изменяет заполненные в запросе поля товара,
+// снятый с продажи товар остается в каталоге для истории покупок
+func (m *MemoryDB) UpdateMerch(ctx context.Context, name string, req model.MerchRequest) (model.MerchItem, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	it, ok := m.merch[name]
+	if !ok {
+		return model.MerchItem{}, apperr.ErrItemNotFound
+	}
+
+	oldPrice, wasBuyable := it.price, it.buyable()
+
+	if req.Price != nil {
+		it.price = *req.Price
+	}
+	if req.Description != nil {
+		it.description = *req.Description
+	}
+	if req.Available != nil {
+		it.available = *req.Available
+	}
+	if req.Stock != nil {
+		it.stock = copyStock(req.Stock)
+	}
+	if req.Category != nil {
+		it.category = *req.Category
+	}
+
+	if it.price != oldPrice {
+		m.recordPrice(it)
+	}
+	m.notifyWishlist(it, oldPrice, wasBuyable)
+
+	return it.item(), nil
+}
+
+// SetStock устанавливает остаток товара, nil - без ограничения
+func (m *MemoryDB) SetStock(ctx context.Context, name string, stock *int) (model.MerchItem, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	it, ok := m.merch[name]
+	if !ok {
+		return model.MerchItem{}, apperr.ErrItemNotFound
+	}
+
+	wasBuyable := it.buyable()
+	it.stock = copyStock(stock)
+	m.notifyWishlist(it, it.price, wasBuyable)
+
+	return it.item(), nil
+}
+
+// Restock пополняет остаток ограниченного товара
+func (m *MemoryDB) Restock(ctx context.Context, name string, quantity int) (model.MerchItem, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	it, ok := m.merch[name]
+	if !ok {
+		return model.MerchItem{}, apperr.ErrItemNotFound
+	}
+
+	if it.stock == nil {
+		return model.MerchItem{}, apperr.ErrStockIsUnlimited
+	}
+
+	if *it.stock > model.MaxStock-quantity {
+		return model.MerchItem{}, apperr.ErrInvalidStock
+	}
+
+	wasBuyable := it.buyable()
+	*it.stock += quantity
+	m.notifyWishlist(it, it.price, wasBuyable)
+
+	return it.item(), nil
+}
+
+// item преобразует мерч в товар каталога
+func (it *merch) item() model.MerchItem {
+	return model.MerchItem{
+		Name:        it.name,
+		Price:       it.price,
+		Description: it.description,
+		Available:   it.available,
+		Stock:       copyStock(it.stock),
+		Category:    it.category,
+	}
+}
+
+// copyStock копирует остаток, чтобы он не изменялся через возвращенный товар
+func copyStock(stock *int) *int {
+	if stock == nil {
+		return nil
+	}
+	v := *stock
+	return &v
+}
@@ -0,0 +1,137 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/plasmatrip/avito_merch/internal/apperr"
+	"github.com/plasmatrip/avito_merch/internal/model"
+)
+
+// PlaceOrder оформляет заказ из нескольких позиций: заказ оплачивается
+// целиком или не оплачивается вовсе, названия позиций не повторяются,
+// promo - необязательный промокод на подходящие позиции
+func (m *MemoryDB) PlaceOrder(ctx context.Context, userID uuid.UUID, items []model.OrderItem, promo string) (model.Order, error) {
+	// позиции заказа упорядочены по названию, как и в хранилище PostgreSQL
+	items = append([]model.OrderItem(nil), items...)
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Name < items[j].Name
+	})
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.placeOrder(userID, items, nil, promo)
+}
+
+// placeOrder оформляет заказ, если переданы ожидаемые цены позиций, заказ
+// с изменившейся ценой не оформляется, скидка по промокоду code действует
+// на подходящие позиции, вызывается под блокировкой
+func (m *MemoryDB) placeOrder(userID uuid.UUID, items []model.OrderItem, prices map[string]int64, code string) (model.Order, error) {
+	promo, err := m.promo(userID, code)
+	if err != nil {
+		return model.Order{}, err
+	}
+
+	order := model.Order{Items: make([]model.OrderLine, 0, len(items)), Promo: code}
+	merch := make([]*merch, 0, len(items))
+	promoLines := make([]bool, 0, len(items)) // позиции, к которым применен промокод
+	applied := false
+
+	for _, item := range items {
+		it, ok := m.merch[item.Name]
+		if !ok {
+			return model.Order{}, apperr.ErrItemNotFound
+		}
+
+		if !it.available {
+			return model.Order{}, apperr.ErrItemNotAvailable
+		}
+
+		if it.stock != nil && *it.stock < item.Quantity {
+			return model.Order{}, apperr.ErrOutOfStock
+		}
+
+		if price, ok := prices[item.Name]; ok && price != it.price {
+			return model.Order{}, apperr.ErrCartPriceChanged
+		}
+
+		line := model.OrderLine{
+			Name:     item.Name,
+			Quantity: item.Quantity,
+			Price:    it.price,
+		}
+		applies := promo != nil && promo.Applies(item.Name, it.category)
+		if applies {
+			line.Discount = promo.Discount(it.price)
+			applied = true
+		}
+		line.Total = (line.Price - line.Discount) * int64(item.Quantity)
+
+		order.Items = append(order.Items, line)
+		order.Total += line.Total
+		merch = append(merch, it)
+		promoLines = append(promoLines, applies)
+	}
+
+	// промокод должен подойти хотя бы к одной позиции заказа
+	if promo != nil && !applied {
+		return model.Order{}, apperr.ErrPromoNotApplicable
+	}
+
+	acc, ok := m.accounts[userID]
+	if !ok {
+		return model.Order{}, apperr.ErrAccountNotFound
+	}
+
+	if acc.amount < order.Total {
+		return model.Order{}, apperr.ErrInsufficientFunds
+	}
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		return model.Order{}, err
+	}
+	order.ID = id
+	order.Date = time.Now()
+
+	if promo != nil {
+		m.redeemPromo(promo, userID)
+	}
+
+	// каждая единица мерча - отдельная покупка, чтобы ее можно было вернуть
+	for i, line := range order.Items {
+		// промокод записываем во все покупки, к которым он применен, даже если скидка
+		// округлилась до нуля, иначе возврат заказа не отменит его использование
+		var linePromo string
+		if promoLines[i] {
+			linePromo = promo.code()
+		}
+
+		for range line.Quantity {
+			purchaseID := uuid.Must(uuid.NewV4())
+			m.addPurchase(purchase{
+				id:        purchaseID,
+				date:      order.Date,
+				userID:    userID,
+				ownerID:   userID,
+				merchID:   merch[i].id,
+				orderID:   order.ID,
+				discount:  line.Discount,
+				promoCode: linePromo,
+				pricePaid: line.Price - line.Discount,
+			})
+
+			m.post(model.OperationPurchase, purchaseID, acc, m.storeRevenue, line.Price-line.Discount)
+		}
+
+		if merch[i].stock != nil {
+			*merch[i].stock -= line.Quantity
+		}
+	}
+
+	return order, nil
+}
@@ -0,0 +1,126 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/plasmatrip/avito_merch/internal/apperr"
+	"github.com/plasmatrip/avito_merch/internal/model"
+)
+
+type promoCode struct {
+	model.PromoCode
+	date   time.Time
+	byUser map[uuid.UUID]int // использования по id пользователя
+}
+
+// CreatePromoCode добавляет промокод, мерч промокода должен быть в каталоге
+func (m *MemoryDB) CreatePromoCode(ctx context.Context, promo model.PromoCode) (model.PromoCode, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if promo.Item != "" {
+		if _, ok := m.merch[promo.Item]; !ok {
+			return model.PromoCode{}, apperr.ErrItemNotFound
+		}
+	}
+
+	if _, ok := m.promos[promo.Code]; ok {
+		return model.PromoCode{}, apperr.ErrPromoAlreadyExists
+	}
+
+	promo.Used = 0
+	m.promos[promo.Code] = &promoCode{
+		PromoCode: promo,
+		date:      time.Now(),
+		byUser:    make(map[uuid.UUID]int),
+	}
+
+	return promo, nil
+}
+
+// PromoCodes возвращает промокоды с количеством использований, новые первыми
+func (m *MemoryDB) PromoCodes(ctx context.Context) ([]model.PromoCode, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	promos := make([]*promoCode, 0, len(m.promos))
+	for _, p := range m.promos {
+		promos = append(promos, p)
+	}
+
+	sort.Slice(promos, func(i, j int) bool {
+		if !promos[i].date.Equal(promos[j].date) {
+			return promos[i].date.After(promos[j].date)
+		}
+		return promos[i].Code < promos[j].Code
+	})
+
+	result := make([]model.PromoCode, 0, len(promos))
+	for _, p := range promos {
+		result = append(result, p.PromoCode)
+	}
+
+	return result, nil
+}
+
+// promo проверяет, что пользователь может использовать промокод,
+// пустой промокод не применяется, вызывается под блокировкой
+func (m *MemoryDB) promo(userID uuid.UUID, code string) (*promoCode, error) {
+	if code == "" {
+		return nil, nil
+	}
+
+	p, ok := m.promos[code]
+	if !ok {
+		return nil, apperr.ErrPromoNotFound
+	}
+
+	if !p.Active(time.Now()) {
+		return nil, apperr.ErrPromoNotActive
+	}
+
+	if (p.TotalLimit > 0 && p.Used >= p.TotalLimit) ||
+		(p.PerUserLimit > 0 && p.byUser[userID] >= p.PerUserLimit) {
+		return nil, apperr.ErrPromoLimitReached
+	}
+
+	return p, nil
+}
+
+// redeemPromo учитывает использование промокода, вызывается под блокировкой
+func (m *MemoryDB) redeemPromo(p *promoCode, userID uuid.UUID) {
+	p.Used++
+	p.byUser[userID]++
+}
+
+// releasePromo отменяет использование промокода возвращенной покупкой p, использование заказом
+// отменяется вместе с возвратом последней покупки заказа с промокодом, вызывается под блокировкой
+func (m *MemoryDB) releasePromo(p *purchase) {
+	promo, ok := m.promos[p.promoCode]
+	if p.promoCode == "" || !ok {
+		return
+	}
+
+	if p.orderID != uuid.Nil {
+		for _, other := range m.purchases {
+			if other.orderID == p.orderID && other.promoCode != "" && other.refundedAt == nil {
+				return
+			}
+		}
+	}
+
+	promo.Used--
+	promo.byUser[p.userID]--
+}
+
+// code возвращает код примененного промокода, пустая строка - покупка без промокода
+func (p *promoCode) code() string {
+	if p == nil {
+		return ""
+	}
+	return p.Code
+}
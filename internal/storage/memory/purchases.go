@@ -0,0 +1,87 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/plasmatrip/avito_merch/internal/apperr"
+	"github.com/plasmatrip/avito_merch/internal/model"
+)
+
+// Purchases возвращает последние limit покупок пользователя от новых к старым
+// с ценой, списанной при покупке
+func (m *MemoryDB) Purchases(ctx context.Context, userID uuid.UUID, limit int) ([]model.Purchase, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	names := make(map[uuid.UUID]string, len(m.merch))
+	for _, it := range m.merch {
+		names[it.id] = it.name
+	}
+
+	purchases := make([]model.Purchase, 0)
+	for _, p := range m.purchases {
+		if p.userID != userID {
+			continue
+		}
+
+		purchase := model.Purchase{
+			ID:        p.id,
+			Date:      p.date,
+			Item:      names[p.merchID],
+			PricePaid: p.pricePaid,
+			Discount:  p.discount,
+			Promo:     p.promoCode,
+		}
+		if p.orderID != uuid.Nil {
+			orderID := p.orderID
+			purchase.OrderID = &orderID
+		}
+		if p.ownerID != p.userID {
+			purchase.ToUser = m.users[p.ownerID].login
+		}
+		if p.refundedAt != nil {
+			refundedAt := *p.refundedAt
+			purchase.RefundedAt = &refundedAt
+		}
+
+		purchases = append(purchases, purchase)
+	}
+
+	sort.SliceStable(purchases, func(i, j int) bool {
+		if !purchases[i].Date.Equal(purchases[j].Date) {
+			return purchases[i].Date.After(purchases[j].Date)
+		}
+		return purchases[i].ID.String() < purchases[j].ID.String()
+	})
+
+	if len(purchases) > limit {
+		purchases = purchases[:limit]
+	}
+
+	return purchases, nil
+}
+
+// PriceHistory возвращает цены мерча от старых к новым, первая цена - цена при добавлении в каталог
+func (m *MemoryDB) PriceHistory(ctx context.Context, name string) ([]model.PricePoint, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	it, ok := m.merch[name]
+	if !ok {
+		return nil, apperr.ErrItemNotFound
+	}
+
+	return append([]model.PricePoint{}, m.prices[it.id]...), nil
+}
+
+// recordPrice добавляет текущую цену мерча в историю цен, вызывается под блокировкой
+func (m *MemoryDB) recordPrice(it *merch) {
+	m.prices[it.id] = append(m.prices[it.id], model.PricePoint{
+		Date:  time.Now(),
+		Price: it.price,
+	})
+}
@@ -0,0 +1,37 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/plasmatrip/avito_merch/internal/apperr"
+)
+
+// UserRole возвращает роль пользователя, сохраненную в учетной записи
+func (m *MemoryDB) UserRole(ctx context.Context, userID uuid.UUID) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[userID]
+	if !ok {
+		return "", apperr.ErrUserNotFound
+	}
+
+	return u.role, nil
+}
+
+// SetUserRole назначает роль существующему пользователю
+func (m *MemoryDB) SetUserRole(ctx context.Context, login, role string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id, ok := m.logins[login]
+	if !ok {
+		return apperr.ErrUserNotFound
+	}
+
+	m.users[id].role = role
+
+	return nil
+}
@@ -0,0 +1,191 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/plasmatrip/avito_merch/internal/apperr"
+	"github.com/plasmatrip/avito_merch/internal/model"
+)
+
+type wishlistItem struct {
+	merchID uuid.UUID
+	date    time.Time
+}
+
+type notification struct {
+	id       uuid.UUID
+	date     time.Time
+	userID   uuid.UUID
+	merchID  uuid.UUID
+	kind     string
+	oldPrice int64
+	price    int64
+	readAt   *time.Time
+}
+
+// Wishlist возвращает список желаний пользователя с недостающими при текущем балансе монетами
+func (m *MemoryDB) Wishlist(ctx context.Context, userID uuid.UUID) (model.Wishlist, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.wishlist(userID)
+}
+
+// AddWishlistItem добавляет мерч в список желаний, снятый с продажи мерч тоже можно добавить
+func (m *MemoryDB) AddWishlistItem(ctx context.Context, userID uuid.UUID, name string) (model.Wishlist, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	it, ok := m.merch[name]
+	if !ok {
+		return model.Wishlist{}, apperr.ErrItemNotFound
+	}
+
+	if m.wishlists[userID] == nil {
+		m.wishlists[userID] = make(map[uuid.UUID]wishlistItem)
+	}
+	if _, ok := m.wishlists[userID][it.id]; !ok {
+		m.wishlists[userID][it.id] = wishlistItem{merchID: it.id, date: time.Now()}
+	}
+
+	return m.wishlist(userID)
+}
+
+// RemoveWishlistItem убирает мерч из списка желаний
+func (m *MemoryDB) RemoveWishlistItem(ctx context.Context, userID uuid.UUID, name string) (model.Wishlist, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if it, ok := m.merch[name]; ok {
+		delete(m.wishlists[userID], it.id)
+	}
+
+	return m.wishlist(userID)
+}
+
+// Notifications возвращает последние limit уведомлений пользователя, от новых к старым
+func (m *MemoryDB) Notifications(ctx context.Context, userID uuid.UUID, limit int) ([]model.Notification, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	names := make(map[uuid.UUID]string, len(m.merch))
+	for _, it := range m.merch {
+		names[it.id] = it.name
+	}
+
+	notifications := make([]model.Notification, 0)
+	for i := len(m.notices) - 1; i >= 0 && len(notifications) < limit; i-- {
+		n := m.notices[i]
+		if n.userID != userID {
+			continue
+		}
+
+		notifications = append(notifications, model.Notification{
+			ID:       n.id,
+			Date:     n.date,
+			Type:     n.kind,
+			Item:     names[n.merchID],
+			OldPrice: n.oldPrice,
+			Price:    n.price,
+			Read:     n.readAt != nil,
+		})
+	}
+
+	return notifications, nil
+}
+
+// ReadNotifications отмечает все уведомления пользователя прочитанными
+func (m *MemoryDB) ReadNotifications(ctx context.Context, userID uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for i := range m.notices {
+		if n := &m.notices[i]; n.userID == userID && n.readAt == nil {
+			n.readAt = &now
+		}
+	}
+
+	return nil
+}
+
+// wishlist собирает список желаний пользователя, вызывается под блокировкой
+func (m *MemoryDB) wishlist(userID uuid.UUID) (model.Wishlist, error) {
+	acc, ok := m.accounts[userID]
+	if !ok {
+		return model.Wishlist{}, apperr.ErrAccountNotFound
+	}
+
+	entries := make([]wishlistItem, 0, len(m.wishlists[userID]))
+	for _, e := range m.wishlists[userID] {
+		entries = append(entries, e)
+	}
+
+	byID := make(map[uuid.UUID]*merch, len(m.merch))
+	for _, it := range m.merch {
+		byID[it.id] = it
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].date.Equal(entries[j].date) {
+			return entries[i].date.Before(entries[j].date)
+		}
+		return byID[entries[i].merchID].name < byID[entries[j].merchID].name
+	})
+
+	items := make([]model.WishlistItem, 0, len(entries))
+	for _, e := range entries {
+		it := byID[e.merchID]
+		items = append(items, model.WishlistItem{
+			Name:      it.name,
+			Price:     it.price,
+			Available: it.available,
+			Stock:     copyStock(it.stock),
+		})
+	}
+
+	return model.NewWishlist(acc.amount, items), nil
+}
+
+// notifyWishlist уведомляет пользователей, добавивших мерч в список желаний, о снижении цены
+// и о том, что мерч снова можно купить, как триггер merch_notify_wishlist, вызывается под блокировкой
+func (m *MemoryDB) notifyWishlist(it *merch, oldPrice int64, wasBuyable bool) {
+	var kinds []string
+	if it.price < oldPrice {
+		kinds = append(kinds, model.NotificationPriceDrop)
+	}
+	if !wasBuyable && it.buyable() {
+		kinds = append(kinds, model.NotificationRestock)
+	}
+
+	now := time.Now()
+	for _, kind := range kinds {
+		for userID, items := range m.wishlists {
+			if _, ok := items[it.id]; !ok {
+				continue
+			}
+
+			n := notification{
+				id:      uuid.Must(uuid.NewV4()),
+				date:    now,
+				userID:  userID,
+				merchID: it.id,
+				kind:    kind,
+				price:   it.price,
+			}
+			if kind == model.NotificationPriceDrop {
+				n.oldPrice = oldPrice
+			}
+			m.notices = append(m.notices, n)
+		}
+	}
+}
+
+// buyable проверяет, что мерч можно купить
+func (it *merch) buyable() bool {
+	return it.available && (it.stock == nil || *it.stock > 0)
+}
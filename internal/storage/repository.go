@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"time"
 
 	"github.com/gofrs/uuid"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
@@ -11,7 +12,51 @@ import (
 type Repository interface {
 	Ping(ctx context.Context) error
 	UserAuth(ctx context.Context, userLogin model.AuthRequest) (uuid.UUID, error)
-	BuyItem(ctx context.Context, userID uuid.UUID, item string) error
+	UserRole(ctx context.Context, userID uuid.UUID) (string, error)
+	SetUserRole(ctx context.Context, login, role string) error
+	BuyItem(ctx context.Context, userID uuid.UUID, item, promo string) error
+	GiftItem(ctx context.Context, userID uuid.UUID, req model.GiftRequest) error
+	TransferItems(ctx context.Context, userID uuid.UUID, req model.ItemTransferRequest) (model.ItemTransfer, error)
+	ItemTransfers(ctx context.Context, userID uuid.UUID) ([]model.ItemTransfer, error)
+	PlaceOrder(ctx context.Context, userID uuid.UUID, items []model.OrderItem, promo string) (model.Order, error)
+	Cart(ctx context.Context, userID uuid.UUID) (model.Cart, error)
+	SetCartItem(ctx context.Context, userID uuid.UUID, name string, quantity int) (model.Cart, error)
+	RemoveCartItem(ctx context.Context, userID uuid.UUID, name string) (model.Cart, error)
+	ClearCart(ctx context.Context, userID uuid.UUID) error
+	Checkout(ctx context.Context, userID uuid.UUID, acceptPriceChanges bool, promo string) (model.Order, error)
+	Merch(ctx context.Context, filter model.MerchFilter) ([]model.MerchItem, error)
+	MerchItem(ctx context.Context, name string) (model.MerchItem, error)
+	CreateMerch(ctx context.Context, item model.MerchItem) (model.MerchItem, error)
+	UpdateMerch(ctx context.Context, name string, req model.MerchRequest) (model.MerchItem, error)
+	SetStock(ctx context.Context, name string, stock *int) (model.MerchItem, error)
+	Restock(ctx context.Context, name string, quantity int) (model.MerchItem, error)
+	CreatePromoCode(ctx context.Context, promo model.PromoCode) (model.PromoCode, error)
+	PromoCodes(ctx context.Context) ([]model.PromoCode, error)
+	PriceHistory(ctx context.Context, name string) ([]model.PricePoint, error)
+	Purchases(ctx context.Context, userID uuid.UUID, limit int) ([]model.Purchase, error)
+	RefundPurchase(ctx context.Context, userID, purchaseID uuid.UUID, window time.Duration) error
+	GrantCoins(ctx context.Context, grant model.GrantRequest) (int, error)
+	SetTransferLimits(ctx context.Context, login string, req model.TransferLimitsRequest) (model.TransferLimits, error)
+	ResetTransferLimits(ctx context.Context, login string) (model.TransferLimits, error)
 	SendCoin(ctx context.Context, fromUser uuid.UUID, userSendCoin model.SendCoinRequest) error
+	SendCoinBatch(ctx context.Context, fromUser uuid.UUID, transfers []model.SendCoinRequest) error
+	CreateCoinRequest(ctx context.Context, userID uuid.UUID, req model.NewCoinRequest, ttl time.Duration) (model.CoinRequest, error)
+	CoinRequests(ctx context.Context, userID uuid.UUID) ([]model.CoinRequest, error)
+	ApproveCoinRequest(ctx context.Context, userID, requestID uuid.UUID) (model.CoinRequest, error)
+	DeclineCoinRequest(ctx context.Context, userID, requestID uuid.UUID) (model.CoinRequest, error)
 	Info(ctx context.Context, userID uuid.UUID) (model.InfoResponse, error)
+	TransactionHistory(ctx context.Context, userID uuid.UUID, filter model.HistoryFilter) (model.HistoryPage, error)
+	Wishlist(ctx context.Context, userID uuid.UUID) (model.Wishlist, error)
+	AddWishlistItem(ctx context.Context, userID uuid.UUID, name string) (model.Wishlist, error)
+	RemoveWishlistItem(ctx context.Context, userID uuid.UUID, name string) (model.Wishlist, error)
+	Notifications(ctx context.Context, userID uuid.UUID, limit int) ([]model.Notification, error)
+	ReadNotifications(ctx context.Context, userID uuid.UUID) error
+	Leaderboard(ctx context.Context, filter model.LeaderboardFilter) (model.Leaderboard, error)
+	SetLeaderboardHidden(ctx context.Context, userID uuid.UUID, hidden bool) error
+
+	// AcquireIdempotencyKey резервирует ключ идемпотентности за запросом. Возвращает nil, если ключ
+	// зарезервирован, или сохраненный ответ, если запрос с этим ключом уже выполнен
+	AcquireIdempotencyKey(ctx context.Context, userID uuid.UUID, key, request string, ttl time.Duration) (*model.IdempotentResponse, error)
+	SaveIdempotentResponse(ctx context.Context, userID uuid.UUID, key string, resp model.IdempotentResponse) error
+	ReleaseIdempotencyKey(ctx context.Context, userID uuid.UUID, key string) error
 }
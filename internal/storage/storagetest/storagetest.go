@@ -0,0 +1,1830 @@
+// Package storagetest содержит общий набор тестов на соответствие контракту
+// storage.Repository, который запускается для каждой реализации хранилища
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/plasmatrip/avito_merch/internal/apperr"
+	"github.com/plasmatrip/avito_merch/internal/model"
+	"github.com/plasmatrip/avito_merch/internal/storage"
+)
+
+// товары из каталога по умолчанию
+const (
+	InitialAmount  int64 = 1000
+	CheapItem            = "pen"
+	CheapItemPrice int64 = 10
+	DearItem             = "pink-hoody"
+	DearItemPrice  int64 = 500
+)
+
+// RepositorySuite - набор тестов, общий для всех реализаций хранилища
+type RepositorySuite struct {
+	suite.Suite
+	Repo storage.Repository
+}
+
+// Run запускает набор тестов для переданной реализации хранилища
+func Run(t *testing.T, repo storage.Repository) {
+	suite.Run(t, &RepositorySuite{Repo: repo})
+}
+
+// newUser регистрирует пользователя с уникальным логином
+func (s *RepositorySuite) newUser() (uuid.UUID, string) {
+	login := "user-" + uuid.Must(uuid.NewV4()).String()
+
+	id, err := s.Repo.UserAuth(context.Background(), model.AuthRequest{
+		UserName: login,
+		Password: login,
+	})
+	require.NoError(s.T(), err, "an error occurred during user registration")
+	require.NotEqual(s.T(), uuid.Nil, id, "zero id was returned")
+
+	return id, login
+}
+
+// lastOperation возвращает id последней операции пользователя указанного типа
+func (s *RepositorySuite) lastOperation(userID uuid.UUID, operation string) uuid.UUID {
+	page, err := s.Repo.TransactionHistory(context.Background(), userID, model.HistoryFilter{Limit: 100})
+	require.NoError(s.T(), err, "an error occurred while getting transaction history")
+
+	for _, e := range page.Entries {
+		if e.Type == operation {
+			return e.OperationID
+		}
+	}
+
+	s.T().Fatalf("no %s in the history", operation)
+	return uuid.Nil
+}
+
+// operations возвращает идентификаторы операций пользователя заданного типа от новых к старым
+func (s *RepositorySuite) operations(userID uuid.UUID, operation string) []uuid.UUID {
+	page, err := s.Repo.TransactionHistory(context.Background(), userID, model.HistoryFilter{Limit: 100})
+	require.NoError(s.T(), err, "an error occurred while getting transaction history")
+
+	var ids []uuid.UUID
+	for _, e := range page.Entries {
+		if e.Type == operation {
+			ids = append(ids, e.OperationID)
+		}
+	}
+	return ids
+}
+
+// ptr возвращает указатель на значение
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// coins возвращает баланс пользователя
+func (s *RepositorySuite) coins(userID uuid.UUID) int64 {
+	info, err := s.Repo.Info(context.Background(), userID)
+	require.NoError(s.T(), err, "an error occurred while getting information about a user")
+	return info.Coins
+}
+
+func (s *RepositorySuite) TestPing() {
+	assert.NoError(s.T(), s.Repo.Ping(context.Background()), "storage availability check failed")
+}
+
+func (s *RepositorySuite) TestUserAuth() {
+	ctx := context.Background()
+
+	id, login := s.newUser()
+
+	s.T().Run("existing user logs in", func(t *testing.T) {
+		got, err := s.Repo.UserAuth(ctx, model.AuthRequest{UserName: login, Password: login})
+		assert.NoError(t, err, "an error occurred during user authorization")
+		assert.Equal(t, id, got, "unexpected id of an existing user")
+	})
+
+	s.T().Run("wrong password", func(t *testing.T) {
+		_, err := s.Repo.UserAuth(ctx, model.AuthRequest{UserName: login, Password: "wrong"})
+		assert.ErrorIs(t, err, apperr.ErrAuthenticationError, "unexpected error when logging in with a wrong password")
+	})
+
+	s.T().Run("new user gets initial coins", func(t *testing.T) {
+		assert.Equal(t, InitialAmount, s.coins(id), "unexpected initial amount of coins")
+	})
+}
+
+func (s *RepositorySuite) TestUserRole() {
+	ctx := context.Background()
+
+	id, login := s.newUser()
+
+	s.T().Run("new user gets the user role", func(t *testing.T) {
+		role, err := s.Repo.UserRole(ctx, id)
+		require.NoError(t, err, "an error occurred while getting a role")
+		assert.Equal(t, model.RoleUser, role, "unexpected role of a new user")
+	})
+
+	s.T().Run("role is stored in the account", func(t *testing.T) {
+		require.NoError(t, s.Repo.SetUserRole(ctx, login, model.RoleAdmin), "an error occurred while setting a role")
+
+		_, err := s.Repo.UserAuth(ctx, model.AuthRequest{UserName: login, Password: login})
+		require.NoError(t, err, "an error occurred during user authorization")
+
+		role, err := s.Repo.UserRole(ctx, id)
+		require.NoError(t, err, "an error occurred while getting a role")
+		assert.Equal(t, model.RoleAdmin, role, "role must survive a new login")
+	})
+
+	s.T().Run("user not found", func(t *testing.T) {
+		err := s.Repo.SetUserRole(ctx, "nobody-"+uuid.Must(uuid.NewV4()).String(), model.RoleAdmin)
+		assert.ErrorIs(t, err, apperr.ErrUserNotFound, "unexpected error when setting a role of a non-existent user")
+
+		_, err = s.Repo.UserRole(ctx, uuid.Must(uuid.NewV4()))
+		assert.ErrorIs(t, err, apperr.ErrUserNotFound, "unexpected error when getting a role of a non-existent user")
+	})
+}
+
+func (s *RepositorySuite) TestBuyItem() {
+	ctx := context.Background()
+
+	userID, _ := s.newUser()
+
+	s.T().Run("item bought successfully", func(t *testing.T) {
+		err := s.Repo.BuyItem(ctx, userID, CheapItem, "")
+		assert.NoError(t, err, "an error occurred when buying an existing item")
+		assert.Equal(t, InitialAmount-CheapItemPrice, s.coins(userID), "unexpected amount of coins after purchase")
+	})
+
+	s.T().Run("item not found", func(t *testing.T) {
+		err := s.Repo.BuyItem(ctx, userID, "hummer", "")
+		assert.ErrorIs(t, err, apperr.ErrItemNotFound, "unexpected error when buying a non-existent item")
+	})
+
+	s.T().Run("account not found", func(t *testing.T) {
+		err := s.Repo.BuyItem(ctx, uuid.Must(uuid.NewV4()), CheapItem, "")
+		assert.ErrorIs(t, err, apperr.ErrAccountNotFound, "unexpected error when buying by a non-existent user")
+	})
+
+	s.T().Run("insufficient funds", func(t *testing.T) {
+		assert.NoError(t, s.Repo.BuyItem(ctx, userID, DearItem, ""))
+		err := s.Repo.BuyItem(ctx, userID, DearItem, "")
+		assert.ErrorIs(t, err, apperr.ErrInsufficientFunds, "unexpected error when buying an item the user cannot afford")
+		assert.Equal(t, InitialAmount-CheapItemPrice-DearItemPrice, s.coins(userID), "balance changed after a failed purchase")
+	})
+}
+
+// подарок оплачивается отправителем и попадает в инвентарь получателя
+func (s *RepositorySuite) TestGiftItem() {
+	ctx := context.Background()
+
+	buyerID, buyer := s.newUser()
+	ownerID, owner := s.newUser()
+
+	gift := model.GiftRequest{ToUser: owner, Item: CheapItem, Message: "happy birthday"}
+	require.NoError(s.T(), s.Repo.GiftItem(ctx, buyerID, gift), "an error occurred while gifting an item")
+
+	s.T().Run("item in the owner's inventory", func(t *testing.T) {
+		info, err := s.Repo.Info(ctx, ownerID)
+		require.NoError(t, err, "an error occurred while getting information about a user")
+		assert.Equal(t, InitialAmount, info.Coins, "the owner must not pay for a gift")
+		assert.Equal(t, []model.Inventory{{Type: CheapItem, Quantity: 1}}, info.Inventory, "unexpected owner's inventory")
+
+		info, err = s.Repo.Info(ctx, buyerID)
+		require.NoError(t, err, "an error occurred while getting information about a user")
+		assert.Equal(t, InitialAmount-CheapItemPrice, info.Coins, "the buyer must pay for a gift")
+		assert.Empty(t, info.Inventory, "a gift must not be in the buyer's inventory")
+	})
+
+	s.T().Run("gift in both histories", func(t *testing.T) {
+		page, err := s.Repo.TransactionHistory(ctx, buyerID, model.HistoryFilter{Limit: 1})
+		require.NoError(t, err, "an error occurred while getting transaction history")
+		require.Len(t, page.Entries, 1, "purchase must be in the buyer's history")
+		assert.Equal(t, model.OperationPurchase, page.Entries[0].Type, "unexpected type of the buyer's entry")
+		assert.Equal(t, model.DirectionOut, page.Entries[0].Direction, "unexpected direction of the buyer's entry")
+		assert.Equal(t, CheapItemPrice, page.Entries[0].Amount, "unexpected amount of the buyer's entry")
+		assert.Equal(t, gift.Message, page.Entries[0].Description, "unexpected description of the buyer's entry")
+		purchaseID := page.Entries[0].OperationID
+
+		page, err = s.Repo.TransactionHistory(ctx, ownerID, model.HistoryFilter{Limit: 100})
+		require.NoError(t, err, "an error occurred while getting transaction history")
+		require.Len(t, page.Entries, 2, "gift must be in the owner's history")
+		entry := page.Entries[0]
+		assert.Equal(t, model.OperationGift, entry.Type, "unexpected type of the owner's entry")
+		assert.Equal(t, purchaseID, entry.OperationID, "the owner's entry must refer to the purchase")
+		assert.Equal(t, model.DirectionIn, entry.Direction, "unexpected direction of the owner's entry")
+		assert.Equal(t, int64(0), entry.Amount, "the owner's entry must have zero amount")
+		assert.Equal(t, InitialAmount, entry.BalanceAfter, "a gift must not change the owner's balance")
+		assert.Equal(t, buyer, entry.Counterpart, "unexpected counterpart of the owner's entry")
+		assert.Equal(t, CheapItem, entry.Item, "unexpected item of the owner's entry")
+		assert.Equal(t, gift.Message, entry.Description, "unexpected description of the owner's entry")
+
+		page, err = s.Repo.TransactionHistory(ctx, ownerID, model.HistoryFilter{Limit: 100, Direction: model.DirectionIn, Counterpart: buyer})
+		require.NoError(t, err, "an error occurred while getting transaction history")
+		assert.Len(t, page.Entries, 1, "gift must match the history filter")
+	})
+
+	s.T().Run("invalid gifts", func(t *testing.T) {
+		err := s.Repo.GiftItem(ctx, buyerID, model.GiftRequest{ToUser: "nobody-" + owner, Item: CheapItem})
+		assert.ErrorIs(t, err, apperr.ErrRecipientNotFound, "unexpected error when gifting to a non-existent user")
+
+		err = s.Repo.GiftItem(ctx, buyerID, model.GiftRequest{ToUser: buyer, Item: CheapItem})
+		assert.ErrorIs(t, err, apperr.ErrSenderAndRecipientAreTheSame, "unexpected error when gifting to oneself")
+
+		err = s.Repo.GiftItem(ctx, buyerID, model.GiftRequest{ToUser: owner, Item: "unknown-item"})
+		assert.ErrorIs(t, err, apperr.ErrItemNotFound, "unexpected error when gifting a non-existent item")
+
+		assert.Equal(t, InitialAmount-CheapItemPrice, s.coins(buyerID), "failed gifts changed the balance")
+	})
+}
+
+func (s *RepositorySuite) TestTransferItems() {
+	ctx := context.Background()
+
+	fromID, from := s.newUser()
+	toID, to := s.newUser()
+
+	for range 3 {
+		require.NoError(s.T(), s.Repo.BuyItem(ctx, fromID, CheapItem, ""), "an error occurred while buying an item")
+	}
+
+	inventory := func(t *testing.T, userID uuid.UUID) []model.Inventory {
+		info, err := s.Repo.Info(ctx, userID)
+		require.NoError(t, err, "an error occurred while getting information about a user")
+		return info.Inventory
+	}
+
+	s.T().Run("items handed over", func(t *testing.T) {
+		transfer, err := s.Repo.TransferItems(ctx, fromID, model.ItemTransferRequest{ToUser: to, Item: CheapItem, Quantity: 2})
+		require.NoError(t, err, "an error occurred while handing over items")
+		assert.NotEqual(t, uuid.Nil, transfer.ID, "zero id was returned")
+		assert.Equal(t, from, transfer.FromUser, "unexpected previous owner")
+		assert.Equal(t, to, transfer.ToUser, "unexpected new owner")
+		assert.Equal(t, 2, transfer.Quantity, "unexpected quantity")
+
+		assert.Equal(t, []model.Inventory{{Type: CheapItem, Quantity: 1}}, inventory(t, fromID), "unexpected previous owner's inventory")
+		assert.Equal(t, []model.Inventory{{Type: CheapItem, Quantity: 2}}, inventory(t, toID), "unexpected new owner's inventory")
+		assert.Equal(t, InitialAmount, s.coins(toID), "handing over must not change balances")
+	})
+
+	s.T().Run("ownership history", func(t *testing.T) {
+		for _, userID := range []uuid.UUID{fromID, toID} {
+			transfers, err := s.Repo.ItemTransfers(ctx, userID)
+			require.NoError(t, err, "an error occurred while getting item transfers")
+			require.Len(t, transfers, 1, "handover must be in the history of both users")
+			assert.Equal(t, CheapItem, transfers[0].Item, "unexpected item")
+			assert.Equal(t, 2, transfers[0].Quantity, "unexpected quantity")
+		}
+	})
+
+	s.T().Run("items are handed over further", func(t *testing.T) {
+		_, err := s.Repo.TransferItems(ctx, toID, model.ItemTransferRequest{ToUser: from, Item: CheapItem, Quantity: 1})
+		require.NoError(t, err, "an error occurred while handing over items")
+		assert.Equal(t, []model.Inventory{{Type: CheapItem, Quantity: 2}}, inventory(t, fromID), "unexpected inventory")
+
+		transfers, err := s.Repo.ItemTransfers(ctx, toID)
+		require.NoError(t, err, "an error occurred while getting item transfers")
+		require.Len(t, transfers, 2, "unexpected number of handovers")
+		assert.Equal(t, to, transfers[0].FromUser, "the newest handover must be the first")
+	})
+
+	s.T().Run("handed over purchase cannot be refunded", func(t *testing.T) {
+		buyerID, buyer := s.newUser()
+		require.NoError(t, s.Repo.BuyItem(ctx, buyerID, CheapItem, ""), "an error occurred while buying an item")
+		purchaseID := s.lastOperation(buyerID, model.OperationPurchase)
+
+		_, err := s.Repo.TransferItems(ctx, buyerID, model.ItemTransferRequest{ToUser: to, Item: CheapItem, Quantity: 1})
+		require.NoError(t, err, "an error occurred while handing over items")
+
+		err = s.Repo.RefundPurchase(ctx, buyerID, purchaseID, time.Hour)
+		assert.ErrorIs(t, err, apperr.ErrPurchaseNotOwned, "unexpected error when refunding a handed over purchase")
+
+		// возвращенную покупателю единицу снова можно вернуть в магазин
+		_, err = s.Repo.TransferItems(ctx, toID, model.ItemTransferRequest{ToUser: buyer, Item: CheapItem, Quantity: 2})
+		require.NoError(t, err, "an error occurred while handing over items")
+		assert.NoError(t, s.Repo.RefundPurchase(ctx, buyerID, purchaseID, time.Hour), "an error occurred while refunding a purchase")
+	})
+
+	s.T().Run("invalid handovers", func(t *testing.T) {
+		_, err := s.Repo.TransferItems(ctx, toID, model.ItemTransferRequest{ToUser: from, Item: CheapItem, Quantity: 1})
+		assert.ErrorIs(t, err, apperr.ErrNotEnoughItems, "unexpected error when handing over more than owned")
+
+		_, err = s.Repo.TransferItems(ctx, fromID, model.ItemTransferRequest{ToUser: to, Item: DearItem, Quantity: 1})
+		assert.ErrorIs(t, err, apperr.ErrNotEnoughItems, "unexpected error when handing over an item not owned")
+
+		_, err = s.Repo.TransferItems(ctx, fromID, model.ItemTransferRequest{ToUser: "nobody-" + to, Item: CheapItem, Quantity: 1})
+		assert.ErrorIs(t, err, apperr.ErrRecipientNotFound, "unexpected error when handing over to a non-existent user")
+
+		_, err = s.Repo.TransferItems(ctx, fromID, model.ItemTransferRequest{ToUser: from, Item: CheapItem, Quantity: 1})
+		assert.ErrorIs(t, err, apperr.ErrSenderAndRecipientAreTheSame, "unexpected error when handing over to oneself")
+
+		_, err = s.Repo.TransferItems(ctx, fromID, model.ItemTransferRequest{ToUser: to, Item: CheapItem, Quantity: 0})
+		assert.ErrorIs(t, err, apperr.ErrInvalidQuantity, "unexpected error when handing over zero items")
+	})
+}
+
+// параллельные покупки не должны уводить баланс в минус
+func (s *RepositorySuite) TestBuyItemConcurrent() {
+	ctx := context.Background()
+
+	userID, _ := s.newUser()
+
+	const purchases = 300
+
+	var bought, rejected atomic.Int64
+	var wg sync.WaitGroup
+	for range purchases {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			err := s.Repo.BuyItem(ctx, userID, CheapItem, "")
+			switch {
+			case err == nil:
+				bought.Add(1)
+			case errors.Is(err, apperr.ErrInsufficientFunds):
+				rejected.Add(1)
+			default:
+				assert.NoError(s.T(), err, "unexpected error during parallel purchases")
+			}
+		}()
+	}
+	wg.Wait()
+
+	info, err := s.Repo.Info(ctx, userID)
+	require.NoError(s.T(), err, "an error occurred while getting information about a user")
+	require.Len(s.T(), info.Inventory, 1, "unexpected inventory after parallel purchases")
+
+	assert.GreaterOrEqual(s.T(), info.Coins, int64(0), "balance went below zero")
+	assert.Equal(s.T(), InitialAmount/CheapItemPrice, bought.Load(), "unexpected number of successful purchases")
+	assert.Equal(s.T(), int64(purchases), bought.Load()+rejected.Load(), "every purchase must either succeed or be rejected")
+	assert.Equal(s.T(), InitialAmount-bought.Load()*CheapItemPrice, info.Coins, "balance does not match successful purchases")
+	assert.Equal(s.T(), int(bought.Load()), info.Inventory[0].Quantity, "inventory does not match successful purchases")
+}
+
+func (s *RepositorySuite) TestSendCoin() {
+	ctx := context.Background()
+
+	fromUserID, fromUser := s.newUser()
+	toUserID, toUser := s.newUser()
+
+	testCases := []struct {
+		testName   string
+		fromUserID uuid.UUID
+		toUser     string
+		amount     int64
+		wantErr    error
+	}{
+		{
+			testName:   "coin sent successfully",
+			fromUserID: fromUserID,
+			toUser:     toUser,
+			amount:     10,
+		},
+		{
+			testName:   "sender not found",
+			fromUserID: uuid.Nil,
+			toUser:     toUser,
+			amount:     10,
+			wantErr:    apperr.ErrSenderNotFound,
+		},
+		{
+			testName:   "recipient not found",
+			fromUserID: fromUserID,
+			toUser:     "",
+			amount:     10,
+			wantErr:    apperr.ErrRecipientNotFound,
+		},
+		{
+			testName:   "insufficient funds",
+			fromUserID: fromUserID,
+			toUser:     toUser,
+			amount:     1000000,
+			wantErr:    apperr.ErrInsufficientFunds,
+		},
+		{
+			testName:   "sender and recipient are the same",
+			fromUserID: fromUserID,
+			toUser:     fromUser,
+			amount:     10,
+			wantErr:    apperr.ErrSenderAndRecipientAreTheSame,
+		},
+	}
+
+	for _, test := range testCases {
+		s.T().Run(test.testName, func(t *testing.T) {
+			err := s.Repo.SendCoin(ctx, test.fromUserID, model.SendCoinRequest{
+				ToUser: test.toUser,
+				Amount: test.amount,
+			})
+			if test.wantErr == nil {
+				assert.NoError(t, err, "an error occurred while sending coins between existing users")
+				return
+			}
+			assert.ErrorIs(t, err, test.wantErr, "unexpected error while sending coins")
+		})
+	}
+
+	assert.Equal(s.T(), InitialAmount-10, s.coins(fromUserID), "unexpected amount of coins of the sender")
+	assert.Equal(s.T(), InitialAmount+10, s.coins(toUserID), "unexpected amount of coins of the recipient")
+}
+
+// встречные параллельные переводы не должны менять общее количество монет
+func (s *RepositorySuite) TestSendCoinConcurrent() {
+	ctx := context.Background()
+
+	const (
+		users     = 4
+		transfers = 400
+	)
+
+	ids := make([]uuid.UUID, users)
+	logins := make([]string, users)
+	for i := range users {
+		ids[i], logins[i] = s.newUser()
+	}
+
+	var wg sync.WaitGroup
+	for i := range transfers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			from, to := i%users, (i+1+i/users)%users
+			if from == to {
+				to = (to + 1) % users
+			}
+
+			err := s.Repo.SendCoin(ctx, ids[from], model.SendCoinRequest{
+				ToUser: logins[to],
+				Amount: int64(1 + i%50),
+			})
+			if err != nil && !errors.Is(err, apperr.ErrInsufficientFunds) {
+				assert.NoError(s.T(), err, "unexpected error during parallel transfers")
+			}
+		}()
+	}
+	wg.Wait()
+
+	var total int64
+	for _, id := range ids {
+		coins := s.coins(id)
+		assert.GreaterOrEqual(s.T(), coins, int64(0), "balance went below zero")
+		total += coins
+	}
+	assert.Equal(s.T(), users*InitialAmount, total, "total amount of coins changed")
+}
+
+func (s *RepositorySuite) TestTransferMessage() {
+	ctx := context.Background()
+
+	fromID, fromLogin := s.newUser()
+	toID, toLogin := s.newUser()
+
+	for _, req := range []model.SendCoinRequest{
+		{ToUser: toLogin, Amount: 10, Message: "thanks for the review"},
+		{ToUser: toLogin, Amount: 20},
+		{ToUser: toLogin, Amount: 30, Message: "спасибо за помощь"},
+	} {
+		require.NoError(s.T(), s.Repo.SendCoin(ctx, fromID, req), "an error occurred while sending coins")
+	}
+
+	s.T().Run("messages are shown in info", func(t *testing.T) {
+		info, err := s.Repo.Info(ctx, toID)
+		require.NoError(t, err, "an error occurred while getting information about a user")
+		assert.Equal(t, []model.Received{{
+			FromUser: fromLogin,
+			Amount:   60,
+			Messages: []string{"thanks for the review", "спасибо за помощь"},
+		}}, info.CoinHistory.Received, "unexpected received coins")
+
+		info, err = s.Repo.Info(ctx, fromID)
+		require.NoError(t, err, "an error occurred while getting information about a user")
+		require.Len(t, info.CoinHistory.Sent, 1, "unexpected number of recipients")
+		assert.Equal(t, []string{"thanks for the review", "спасибо за помощь"}, info.CoinHistory.Sent[0].Messages, "unexpected sent messages")
+	})
+
+	s.T().Run("messages are shown in the history", func(t *testing.T) {
+		page, err := s.Repo.TransactionHistory(ctx, toID, model.HistoryFilter{Limit: 3, Direction: model.DirectionIn, Counterpart: fromLogin})
+		require.NoError(t, err, "an error occurred while getting transaction history")
+		require.Len(t, page.Entries, 3, "unexpected number of history entries")
+
+		descriptions := make([]string, 0, len(page.Entries))
+		for _, e := range page.Entries {
+			descriptions = append(descriptions, e.Description)
+		}
+		assert.Equal(t, []string{"спасибо за помощь", "", "thanks for the review"}, descriptions, "unexpected transfer messages")
+	})
+}
+
+func (s *RepositorySuite) TestCoinRequests() {
+	ctx := context.Background()
+
+	requesterID, requesterLogin := s.newUser()
+	payerID, payerLogin := s.newUser()
+
+	create := func(t *testing.T, amount int64, ttl time.Duration) model.CoinRequest {
+		request, err := s.Repo.CreateCoinRequest(ctx, requesterID, model.NewCoinRequest{FromUser: payerLogin, Amount: amount, Message: "for pizza"}, ttl)
+		require.NoError(t, err, "an error occurred while creating a coin request")
+		return request
+	}
+
+	s.T().Run("request is listed for the payer", func(t *testing.T) {
+		request := create(t, 40, time.Hour)
+		assert.Equal(t, requesterLogin, request.Requester, "unexpected requester")
+		assert.Equal(t, payerLogin, request.Payer, "unexpected payer")
+		assert.Equal(t, model.CoinRequestPending, request.Status, "unexpected status of a new request")
+
+		requests, err := s.Repo.CoinRequests(ctx, payerID)
+		require.NoError(t, err, "an error occurred while getting coin requests")
+		require.Len(t, requests, 1, "unexpected number of pending requests")
+		assert.Equal(t, request.ID, requests[0].ID, "unexpected pending request")
+
+		requests, err = s.Repo.CoinRequests(ctx, requesterID)
+		require.NoError(t, err, "an error occurred while getting coin requests")
+		assert.Empty(t, requests, "requester must not see own requests as pending")
+
+		_, err = s.Repo.ApproveCoinRequest(ctx, requesterID, request.ID)
+		assert.ErrorIs(t, err, apperr.ErrCoinRequestNotFound, "requester must not approve own request")
+	})
+
+	s.T().Run("approved request transfers coins", func(t *testing.T) {
+		requests, err := s.Repo.CoinRequests(ctx, payerID)
+		require.NoError(t, err, "an error occurred while getting coin requests")
+		require.Len(t, requests, 1, "unexpected number of pending requests")
+
+		request, err := s.Repo.ApproveCoinRequest(ctx, payerID, requests[0].ID)
+		require.NoError(t, err, "an error occurred while approving a coin request")
+		assert.Equal(t, model.CoinRequestApproved, request.Status, "unexpected status of an approved request")
+		assert.NotNil(t, request.ResolvedAt, "approval date is not set")
+		assert.Equal(t, InitialAmount+40, s.coins(requesterID), "unexpected balance of the requester")
+		assert.Equal(t, InitialAmount-40, s.coins(payerID), "unexpected balance of the payer")
+
+		page, err := s.Repo.TransactionHistory(ctx, requesterID, model.HistoryFilter{Limit: 1})
+		require.NoError(t, err, "an error occurred while getting transaction history")
+		require.Len(t, page.Entries, 1, "unexpected number of history entries")
+		assert.Equal(t, model.OperationTransfer, page.Entries[0].Type, "approval must be a transfer")
+		assert.Equal(t, "for pizza", page.Entries[0].Description, "unexpected transfer message")
+
+		_, err = s.Repo.ApproveCoinRequest(ctx, payerID, request.ID)
+		assert.ErrorIs(t, err, apperr.ErrCoinRequestResolved, "unexpected error when approving a request twice")
+		assert.Equal(t, InitialAmount+40, s.coins(requesterID), "coins were transferred twice")
+	})
+
+	s.T().Run("declined request does not transfer coins", func(t *testing.T) {
+		request := create(t, 10, time.Hour)
+
+		request, err := s.Repo.DeclineCoinRequest(ctx, payerID, request.ID)
+		require.NoError(t, err, "an error occurred while declining a coin request")
+		assert.Equal(t, model.CoinRequestDeclined, request.Status, "unexpected status of a declined request")
+
+		_, err = s.Repo.ApproveCoinRequest(ctx, payerID, request.ID)
+		assert.ErrorIs(t, err, apperr.ErrCoinRequestResolved, "unexpected error when approving a declined request")
+		assert.Equal(t, InitialAmount-40, s.coins(payerID), "declined request changed the balance")
+	})
+
+	s.T().Run("expired request cannot be approved", func(t *testing.T) {
+		request := create(t, 10, 0)
+		assert.Equal(t, model.CoinRequestExpired, request.Status, "unexpected status of an expired request")
+
+		requests, err := s.Repo.CoinRequests(ctx, payerID)
+		require.NoError(t, err, "an error occurred while getting coin requests")
+		assert.Empty(t, requests, "expired request must not be listed")
+
+		_, err = s.Repo.ApproveCoinRequest(ctx, payerID, request.ID)
+		assert.ErrorIs(t, err, apperr.ErrCoinRequestExpired, "unexpected error when approving an expired request")
+	})
+
+	s.T().Run("approval with insufficient funds keeps the request pending", func(t *testing.T) {
+		request := create(t, InitialAmount, time.Hour)
+
+		_, err := s.Repo.ApproveCoinRequest(ctx, payerID, request.ID)
+		assert.ErrorIs(t, err, apperr.ErrInsufficientFunds, "unexpected error when the payer cannot afford the request")
+
+		requests, err := s.Repo.CoinRequests(ctx, payerID)
+		require.NoError(t, err, "an error occurred while getting coin requests")
+		require.Len(t, requests, 1, "request must stay pending")
+		assert.Equal(t, request.ID, requests[0].ID, "unexpected pending request")
+	})
+
+	s.T().Run("invalid requests", func(t *testing.T) {
+		_, err := s.Repo.CreateCoinRequest(ctx, requesterID, model.NewCoinRequest{FromUser: "nobody-" + payerLogin, Amount: 10}, time.Hour)
+		assert.ErrorIs(t, err, apperr.ErrUserNotFound, "unexpected error when requesting coins from a non-existent user")
+
+		_, err = s.Repo.CreateCoinRequest(ctx, requesterID, model.NewCoinRequest{FromUser: requesterLogin, Amount: 10}, time.Hour)
+		assert.ErrorIs(t, err, apperr.ErrSenderAndRecipientAreTheSame, "unexpected error when requesting coins from oneself")
+
+		_, err = s.Repo.DeclineCoinRequest(ctx, payerID, uuid.Must(uuid.NewV4()))
+		assert.ErrorIs(t, err, apperr.ErrCoinRequestNotFound, "unexpected error when declining a non-existent request")
+	})
+}
+
+func (s *RepositorySuite) TestSendCoinBatch() {
+	ctx := context.Background()
+
+	fromID, fromLogin := s.newUser()
+	firstID, firstLogin := s.newUser()
+	secondID, secondLogin := s.newUser()
+
+	s.T().Run("all transfers are made", func(t *testing.T) {
+		err := s.Repo.SendCoinBatch(ctx, fromID, []model.SendCoinRequest{
+			{ToUser: firstLogin, Amount: 100, Message: "great release"},
+			{ToUser: secondLogin, Amount: 50},
+			{ToUser: firstLogin, Amount: 25},
+		})
+		require.NoError(t, err, "an error occurred while sending coins in a batch")
+		assert.Equal(t, InitialAmount-175, s.coins(fromID), "unexpected balance of the sender")
+		assert.Equal(t, InitialAmount+125, s.coins(firstID), "unexpected balance of the first recipient")
+		assert.Equal(t, InitialAmount+50, s.coins(secondID), "unexpected balance of the second recipient")
+	})
+
+	s.T().Run("invalid recipients fail the whole batch", func(t *testing.T) {
+		err := s.Repo.SendCoinBatch(ctx, fromID, []model.SendCoinRequest{
+			{ToUser: firstLogin, Amount: 10},
+			{ToUser: "nobody-" + firstLogin, Amount: 10},
+			{ToUser: fromLogin, Amount: 10},
+		})
+
+		var batchErr *apperr.BatchValidationError
+		require.ErrorAs(t, err, &batchErr, "unexpected error for a batch with invalid recipients")
+		assert.ErrorIs(t, err, apperr.ErrBatchValidationFailed, "batch error must match ErrBatchValidationFailed")
+		require.Len(t, batchErr.Errors, 3, "unexpected number of transfer results")
+		assert.NoError(t, batchErr.Errors[0], "valid transfer must not have an error")
+		assert.ErrorIs(t, batchErr.Errors[1], apperr.ErrRecipientNotFound, "unexpected error for a non-existent recipient")
+		assert.ErrorIs(t, batchErr.Errors[2], apperr.ErrSenderAndRecipientAreTheSame, "unexpected error for a transfer to oneself")
+		assert.Equal(t, InitialAmount+125, s.coins(firstID), "failed batch changed the balance")
+	})
+
+	s.T().Run("total is checked against the balance", func(t *testing.T) {
+		err := s.Repo.SendCoinBatch(ctx, fromID, []model.SendCoinRequest{
+			{ToUser: firstLogin, Amount: 500},
+			{ToUser: secondLogin, Amount: 500},
+		})
+		assert.ErrorIs(t, err, apperr.ErrInsufficientFunds, "unexpected error when the batch total exceeds the balance")
+		assert.Equal(t, InitialAmount-175, s.coins(fromID), "failed batch changed the balance")
+		assert.Equal(t, InitialAmount+125, s.coins(firstID), "failed batch changed the balance")
+	})
+}
+
+func (s *RepositorySuite) TestTransferLimits() {
+	ctx := context.Background()
+
+	fromID, fromLogin := s.newUser()
+	toID, toLogin := s.newUser()
+
+	send := func(amount int64) error {
+		return s.Repo.SendCoin(ctx, fromID, model.SendCoinRequest{ToUser: toLogin, Amount: amount})
+	}
+
+	assertLimit := func(t *testing.T, err error, remaining int64) {
+		var limitErr *apperr.TransferLimitError
+		if assert.ErrorAs(t, err, &limitErr, "unexpected error when the transfer limit is exceeded") {
+			assert.Equal(t, remaining, limitErr.Remaining, "unexpected remaining allowance")
+		}
+		assert.ErrorIs(t, err, apperr.ErrTransferLimitExceeded, "limit error must match ErrTransferLimitExceeded")
+	}
+
+	limits, err := s.Repo.SetTransferLimits(ctx, fromLogin, model.TransferLimitsRequest{PerTransfer: ptr[int64](100), Daily: ptr[int64](150)})
+	require.NoError(s.T(), err, "an error occurred while setting transfer limits")
+	assert.Equal(s.T(), model.TransferLimits{PerTransfer: 100, Daily: 150}, limits, "unexpected transfer limits")
+
+	s.T().Run("per-transfer limit", func(t *testing.T) {
+		assertLimit(t, send(120), 100)
+		assert.NoError(t, send(100), "transfer within the limits failed")
+	})
+
+	s.T().Run("rolling daily limit", func(t *testing.T) {
+		assertLimit(t, send(60), 50)
+		assert.NoError(t, send(50), "transfer within the limits failed")
+		assertLimit(t, send(1), 0)
+
+		err := s.Repo.SendCoinBatch(ctx, fromID, []model.SendCoinRequest{{ToUser: toLogin, Amount: 1}})
+		assertLimit(t, err, 0)
+
+		assert.Equal(t, InitialAmount+150, s.coins(toID), "rejected transfers changed the balance")
+	})
+
+	s.T().Run("limits are reset to defaults", func(t *testing.T) {
+		_, err := s.Repo.ResetTransferLimits(ctx, fromLogin)
+		require.NoError(t, err, "an error occurred while resetting transfer limits")
+		assert.NoError(t, send(200), "transfer without limits failed")
+	})
+
+	s.T().Run("unknown user", func(t *testing.T) {
+		_, err := s.Repo.SetTransferLimits(ctx, "nobody-"+fromLogin, model.TransferLimitsRequest{Daily: ptr[int64](10)})
+		assert.ErrorIs(t, err, apperr.ErrUserNotFound, "unexpected error when setting limits of a non-existent user")
+	})
+}
+
+func (s *RepositorySuite) TestInfo() {
+	ctx := context.Background()
+
+	user1ID, user1 := s.newUser()
+	user2ID, user2 := s.newUser()
+	_, user3 := s.newUser()
+
+	user2Amount := int64(10)
+	user3Amount := int64(25)
+
+	require.NoError(s.T(), s.Repo.BuyItem(ctx, user1ID, CheapItem, ""))
+	require.NoError(s.T(), s.Repo.BuyItem(ctx, user1ID, CheapItem, ""))
+	require.NoError(s.T(), s.Repo.SendCoin(ctx, user1ID, model.SendCoinRequest{ToUser: user3, Amount: user3Amount}))
+	require.NoError(s.T(), s.Repo.SendCoin(ctx, user1ID, model.SendCoinRequest{ToUser: user3, Amount: user3Amount}))
+	require.NoError(s.T(), s.Repo.SendCoin(ctx, user2ID, model.SendCoinRequest{ToUser: user1, Amount: user2Amount}))
+
+	s.T().Run("info received successfully", func(t *testing.T) {
+		expected := model.InfoResponse{
+			Coins: InitialAmount - 2*CheapItemPrice + user2Amount - 2*user3Amount,
+			Inventory: []model.Inventory{
+				{Type: CheapItem, Quantity: 2},
+			},
+			CoinHistory: model.CoinHistory{
+				Received: []model.Received{
+					{FromUser: user2, Amount: user2Amount},
+				},
+				Sent: []model.Sent{
+					{ToUser: user3, Amount: 2 * user3Amount},
+				},
+			},
+		}
+
+		info, err := s.Repo.Info(ctx, user1ID)
+		assert.NoError(t, err, "an error occurred while getting information about a user")
+		assert.Equal(t, expected, info, "info does not match expected")
+	})
+
+	s.T().Run("empty inventory", func(t *testing.T) {
+		info, err := s.Repo.Info(ctx, user2ID)
+		assert.NoError(t, err, "an error occurred while getting information about a user")
+		assert.NotNil(t, info.Inventory, "inventory must be an empty list")
+		assert.Empty(t, info.Inventory, "inventory must be empty")
+	})
+
+	s.T().Run("account not found", func(t *testing.T) {
+		info, err := s.Repo.Info(ctx, uuid.Must(uuid.NewV4()))
+		assert.ErrorIs(t, err, apperr.ErrAccountNotFound, "unexpected error when getting information about a non-existent user")
+		assert.Equal(t, model.InfoResponse{}, info, "unexpected response when getting information about a non-existent user")
+	})
+}
+
+func (s *RepositorySuite) TestMerch() {
+	ctx := context.Background()
+
+	s.T().Run("whole catalog sorted by name", func(t *testing.T) {
+		items, err := s.Repo.Merch(ctx, model.MerchFilter{Sort: model.MerchSortName})
+		require.NoError(t, err, "an error occurred while getting the catalog")
+		require.NotEmpty(t, items, "catalog must not be empty")
+
+		names := make([]string, 0, len(items))
+		for _, item := range items {
+			names = append(names, item.Name)
+		}
+		assert.IsNonDecreasing(t, names, "items must be sorted by name")
+		assert.Contains(t, names, CheapItem, "catalog must contain the cheap item")
+		assert.Contains(t, names, DearItem, "catalog must contain the dear item")
+	})
+
+	s.T().Run("price range sorted by price descending", func(t *testing.T) {
+		items, err := s.Repo.Merch(ctx, model.MerchFilter{
+			Sort:     model.MerchSortPrice,
+			Desc:     true,
+			MinPrice: CheapItemPrice + 1,
+			MaxPrice: DearItemPrice - 1,
+		})
+		require.NoError(t, err, "an error occurred while getting the catalog")
+		require.NotEmpty(t, items, "no items in the price range")
+
+		for i, item := range items {
+			assert.Greater(t, item.Price, CheapItemPrice, "price below the range")
+			assert.Less(t, item.Price, DearItemPrice, "price above the range")
+			if i > 0 {
+				assert.LessOrEqual(t, item.Price, items[i-1].Price, "items must be sorted by price descending")
+			}
+		}
+	})
+
+	s.T().Run("item found", func(t *testing.T) {
+		item, err := s.Repo.MerchItem(ctx, DearItem)
+		assert.NoError(t, err, "an error occurred while getting an item")
+		assert.Equal(t, DearItem, item.Name, "unexpected item name")
+		assert.Equal(t, DearItemPrice, item.Price, "unexpected item price")
+		assert.True(t, item.Available, "item must be available")
+	})
+
+	s.T().Run("item not found", func(t *testing.T) {
+		_, err := s.Repo.MerchItem(ctx, "hummer")
+		assert.ErrorIs(t, err, apperr.ErrItemNotFound, "unexpected error for a non-existent item")
+	})
+}
+
+func (s *RepositorySuite) TestManageMerch() {
+	ctx := context.Background()
+
+	userID, _ := s.newUser()
+	name := "sticker-" + uuid.Must(uuid.NewV4()).String()[:8]
+
+	s.T().Run("item created", func(t *testing.T) {
+		item, err := s.Repo.CreateMerch(ctx, model.MerchItem{Name: name, Price: 5, Description: "Sticker", Available: true})
+		require.NoError(t, err, "an error occurred while creating an item")
+		assert.Equal(t, model.MerchItem{Name: name, Price: 5, Description: "Sticker", Available: true}, item, "unexpected created item")
+
+		_, err = s.Repo.CreateMerch(ctx, model.MerchItem{Name: name, Price: 5, Available: true})
+		assert.ErrorIs(t, err, apperr.ErrItemAlreadyExists, "unexpected error when creating a duplicate item")
+	})
+
+	s.T().Run("item repriced", func(t *testing.T) {
+		price := int64(7)
+		item, err := s.Repo.UpdateMerch(ctx, name, model.MerchRequest{Price: &price})
+		require.NoError(t, err, "an error occurred while updating an item")
+		assert.Equal(t, price, item.Price, "price was not updated")
+		assert.Equal(t, "Sticker", item.Description, "description must stay the same")
+
+		require.NoError(t, s.Repo.BuyItem(ctx, userID, name, ""), "an error occurred while buying an item")
+		assert.Equal(t, InitialAmount-price, s.coins(userID), "purchase must use the new price")
+	})
+
+	s.T().Run("retired item cannot be bought but stays in inventory", func(t *testing.T) {
+		available := false
+		item, err := s.Repo.UpdateMerch(ctx, name, model.MerchRequest{Available: &available})
+		require.NoError(t, err, "an error occurred while retiring an item")
+		assert.False(t, item.Available, "item must not be available")
+
+		err = s.Repo.BuyItem(ctx, userID, name, "")
+		assert.ErrorIs(t, err, apperr.ErrItemNotAvailable, "unexpected error when buying a retired item")
+
+		info, err := s.Repo.Info(ctx, userID)
+		require.NoError(t, err, "an error occurred while getting information about a user")
+		assert.Contains(t, info.Inventory, model.Inventory{Type: name, Quantity: 1}, "retired item must stay in inventory")
+	})
+
+	s.T().Run("item not found", func(t *testing.T) {
+		available := false
+		_, err := s.Repo.UpdateMerch(ctx, "hummer", model.MerchRequest{Available: &available})
+		assert.ErrorIs(t, err, apperr.ErrItemNotFound, "unexpected error when updating a non-existent item")
+	})
+}
+
+func (s *RepositorySuite) TestStock() {
+	ctx := context.Background()
+
+	const stock = 3
+	name := "limited-" + uuid.Must(uuid.NewV4()).String()[:8]
+	_, err := s.Repo.CreateMerch(ctx, model.MerchItem{Name: name, Price: 1, Available: true, Stock: ptr(stock)})
+	require.NoError(s.T(), err, "an error occurred while creating an item")
+
+	s.T().Run("concurrent purchases do not oversell", func(t *testing.T) {
+		const buyers = 10
+
+		users := make([]uuid.UUID, buyers)
+		for i := range users {
+			users[i], _ = s.newUser()
+		}
+
+		var bought, outOfStock atomic.Int64
+		var wg sync.WaitGroup
+		for _, userID := range users {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := s.Repo.BuyItem(ctx, userID, name, "")
+				switch {
+				case err == nil:
+					bought.Add(1)
+				case errors.Is(err, apperr.ErrOutOfStock):
+					outOfStock.Add(1)
+				default:
+					t.Errorf("unexpected error: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, int64(stock), bought.Load(), "unexpected number of purchases")
+		assert.Equal(t, int64(buyers-stock), outOfStock.Load(), "unexpected number of out of stock errors")
+
+		item, err := s.Repo.MerchItem(ctx, name)
+		require.NoError(t, err, "an error occurred while getting an item")
+		assert.Equal(t, ptr(0), item.Stock, "stock must be exhausted")
+	})
+
+	s.T().Run("restocked item can be bought", func(t *testing.T) {
+		item, err := s.Repo.Restock(ctx, name, 2)
+		require.NoError(t, err, "an error occurred while restocking an item")
+		assert.Equal(t, ptr(2), item.Stock, "unexpected stock after restocking")
+
+		userID, _ := s.newUser()
+		require.NoError(t, s.Repo.BuyItem(ctx, userID, name, ""), "an error occurred while buying a restocked item")
+
+		item, err = s.Repo.MerchItem(ctx, name)
+		require.NoError(t, err, "an error occurred while getting an item")
+		assert.Equal(t, ptr(1), item.Stock, "unexpected stock after purchase")
+
+		_, err = s.Repo.Restock(ctx, name, model.MaxStock)
+		assert.ErrorIs(t, err, apperr.ErrInvalidStock, "unexpected error when restocking over the maximum stock")
+	})
+
+	s.T().Run("stock set and removed", func(t *testing.T) {
+		item, err := s.Repo.SetStock(ctx, name, ptr(0))
+		require.NoError(t, err, "an error occurred while setting stock")
+		assert.Equal(t, ptr(0), item.Stock, "unexpected stock")
+
+		item, err = s.Repo.SetStock(ctx, name, nil)
+		require.NoError(t, err, "an error occurred while removing stock limit")
+		assert.Nil(t, item.Stock, "stock must be unlimited")
+
+		_, err = s.Repo.Restock(ctx, name, 1)
+		assert.ErrorIs(t, err, apperr.ErrStockIsUnlimited, "unexpected error when restocking an unlimited item")
+
+		_, err = s.Repo.Restock(ctx, "hummer", 1)
+		assert.ErrorIs(t, err, apperr.ErrItemNotFound, "unexpected error when restocking a non-existent item")
+	})
+}
+
+func (s *RepositorySuite) TestPlaceOrder() {
+	ctx := context.Background()
+
+	s.T().Run("order paid as a whole", func(t *testing.T) {
+		userID, _ := s.newUser()
+
+		order, err := s.Repo.PlaceOrder(ctx, userID, []model.OrderItem{
+			{Name: DearItem, Quantity: 1},
+			{Name: CheapItem, Quantity: 5},
+		}, "")
+		require.NoError(t, err, "an error occurred while placing an order")
+
+		total := DearItemPrice + 5*CheapItemPrice
+		assert.NotEqual(t, uuid.Nil, order.ID, "zero order id was returned")
+		assert.Equal(t, total, order.Total, "unexpected order total")
+		assert.Equal(t, []model.OrderLine{
+			{Name: CheapItem, Quantity: 5, Price: CheapItemPrice, Total: 5 * CheapItemPrice},
+			{Name: DearItem, Quantity: 1, Price: DearItemPrice, Total: DearItemPrice},
+		}, order.Items, "unexpected order lines")
+
+		info, err := s.Repo.Info(ctx, userID)
+		require.NoError(t, err, "an error occurred while getting information about a user")
+		assert.Equal(t, InitialAmount-total, info.Coins, "unexpected balance after the order")
+		assert.Equal(t, []model.Inventory{
+			{Type: CheapItem, Quantity: 5},
+			{Type: DearItem, Quantity: 1},
+		}, info.Inventory, "unexpected inventory after the order")
+	})
+
+	s.T().Run("insufficient funds for the whole order", func(t *testing.T) {
+		userID, _ := s.newUser()
+
+		_, err := s.Repo.PlaceOrder(ctx, userID, []model.OrderItem{
+			{Name: CheapItem, Quantity: 1},
+			{Name: DearItem, Quantity: 2},
+		}, "")
+		assert.ErrorIs(t, err, apperr.ErrInsufficientFunds, "unexpected error when the order exceeds the balance")
+
+		info, err := s.Repo.Info(ctx, userID)
+		require.NoError(t, err, "an error occurred while getting information about a user")
+		assert.Equal(t, InitialAmount, info.Coins, "balance must not change")
+		assert.Empty(t, info.Inventory, "nothing must be bought")
+	})
+
+	s.T().Run("order with an unknown item", func(t *testing.T) {
+		userID, _ := s.newUser()
+
+		_, err := s.Repo.PlaceOrder(ctx, userID, []model.OrderItem{
+			{Name: CheapItem, Quantity: 1},
+			{Name: "hummer", Quantity: 1},
+		}, "")
+		assert.ErrorIs(t, err, apperr.ErrItemNotFound, "unexpected error for an unknown item")
+		assert.Equal(t, InitialAmount, s.coins(userID), "balance must not change")
+	})
+
+	s.T().Run("order exceeding stock", func(t *testing.T) {
+		userID, _ := s.newUser()
+
+		name := "ordered-" + uuid.Must(uuid.NewV4()).String()[:8]
+		_, err := s.Repo.CreateMerch(ctx, model.MerchItem{Name: name, Price: 1, Available: true, Stock: ptr(2)})
+		require.NoError(t, err, "an error occurred while creating an item")
+
+		_, err = s.Repo.PlaceOrder(ctx, userID, []model.OrderItem{{Name: name, Quantity: 3}}, "")
+		assert.ErrorIs(t, err, apperr.ErrOutOfStock, "unexpected error when the order exceeds stock")
+
+		_, err = s.Repo.PlaceOrder(ctx, userID, []model.OrderItem{{Name: name, Quantity: 2}}, "")
+		require.NoError(t, err, "an error occurred while placing an order")
+
+		item, err := s.Repo.MerchItem(ctx, name)
+		require.NoError(t, err, "an error occurred while getting an item")
+		assert.Equal(t, ptr(0), item.Stock, "stock must be exhausted")
+	})
+}
+
+func (s *RepositorySuite) TestCart() {
+	ctx := context.Background()
+
+	userID, _ := s.newUser()
+
+	name := "carted-" + uuid.Must(uuid.NewV4()).String()[:8]
+	_, err := s.Repo.CreateMerch(ctx, model.MerchItem{Name: name, Price: 30, Available: true})
+	require.NoError(s.T(), err, "an error occurred while creating an item")
+
+	s.T().Run("items put in the cart", func(t *testing.T) {
+		_, err := s.Repo.SetCartItem(ctx, userID, CheapItem, 1)
+		require.NoError(t, err, "an error occurred while putting an item in the cart")
+		_, err = s.Repo.SetCartItem(ctx, userID, name, 2)
+		require.NoError(t, err, "an error occurred while putting an item in the cart")
+
+		cart, err := s.Repo.SetCartItem(ctx, userID, CheapItem, 3)
+		require.NoError(t, err, "an error occurred while changing quantity")
+		assert.Equal(t, model.Cart{
+			Items: []model.CartItem{
+				{Name: name, Quantity: 2, Price: 30, AddedPrice: 30, Available: true, Total: 60},
+				{Name: CheapItem, Quantity: 3, Price: CheapItemPrice, AddedPrice: CheapItemPrice, Available: true, Total: 3 * CheapItemPrice},
+			},
+			Total: 60 + 3*CheapItemPrice,
+		}, cart, "unexpected cart")
+
+		_, err = s.Repo.SetCartItem(ctx, userID, "hummer", 1)
+		assert.ErrorIs(t, err, apperr.ErrItemNotFound, "unexpected error for an unknown item")
+	})
+
+	s.T().Run("cart survives between requests", func(t *testing.T) {
+		cart, err := s.Repo.Cart(ctx, userID)
+		require.NoError(t, err, "an error occurred while getting the cart")
+		assert.Len(t, cart.Items, 2, "unexpected number of items in the cart")
+	})
+
+	s.T().Run("price change detected at checkout", func(t *testing.T) {
+		_, err := s.Repo.UpdateMerch(ctx, name, model.MerchRequest{Price: ptr(int64(40))})
+		require.NoError(t, err, "an error occurred while repricing an item")
+
+		cart, err := s.Repo.Cart(ctx, userID)
+		require.NoError(t, err, "an error occurred while getting the cart")
+		assert.True(t, cart.PriceChanged, "price change must be reported")
+		assert.Equal(t, 80+3*CheapItemPrice, cart.Total, "total must use current prices")
+
+		_, err = s.Repo.Checkout(ctx, userID, false, "")
+		assert.ErrorIs(t, err, apperr.ErrCartPriceChanged, "unexpected error when prices have changed")
+		assert.Equal(t, InitialAmount, s.coins(userID), "balance must not change")
+
+		cart, err = s.Repo.Cart(ctx, userID)
+		require.NoError(t, err, "an error occurred while getting the cart")
+		assert.Len(t, cart.Items, 2, "cart must stay intact")
+	})
+
+	s.T().Run("checkout with accepted prices", func(t *testing.T) {
+		order, err := s.Repo.Checkout(ctx, userID, true, "")
+		require.NoError(t, err, "an error occurred while checking out")
+		assert.Equal(t, 80+3*CheapItemPrice, order.Total, "unexpected order total")
+		assert.Equal(t, InitialAmount-order.Total, s.coins(userID), "unexpected balance after checkout")
+
+		cart, err := s.Repo.Cart(ctx, userID)
+		require.NoError(t, err, "an error occurred while getting the cart")
+		assert.Empty(t, cart.Items, "cart must be empty after checkout")
+
+		_, err = s.Repo.Checkout(ctx, userID, true, "")
+		assert.ErrorIs(t, err, apperr.ErrCartEmpty, "unexpected error for an empty cart")
+	})
+
+	s.T().Run("items removed from the cart", func(t *testing.T) {
+		_, err := s.Repo.SetCartItem(ctx, userID, CheapItem, 1)
+		require.NoError(t, err, "an error occurred while putting an item in the cart")
+		_, err = s.Repo.SetCartItem(ctx, userID, name, 1)
+		require.NoError(t, err, "an error occurred while putting an item in the cart")
+
+		cart, err := s.Repo.RemoveCartItem(ctx, userID, CheapItem)
+		require.NoError(t, err, "an error occurred while removing an item from the cart")
+		require.Len(t, cart.Items, 1, "unexpected number of items in the cart")
+		assert.False(t, cart.Items[0].PriceChanged, "putting an item again must remember the current price")
+
+		require.NoError(t, s.Repo.ClearCart(ctx, userID), "an error occurred while clearing the cart")
+		cart, err = s.Repo.Cart(ctx, userID)
+		require.NoError(t, err, "an error occurred while getting the cart")
+		assert.Empty(t, cart.Items, "cart must be empty")
+	})
+}
+
+func (s *RepositorySuite) TestWishlist() {
+	ctx := context.Background()
+
+	userID, _ := s.newUser()
+	otherID, _ := s.newUser()
+
+	name := "wished-" + uuid.Must(uuid.NewV4()).String()[:8]
+	_, err := s.Repo.CreateMerch(ctx, model.MerchItem{Name: name, Price: 1500, Available: true, Stock: ptr(0)})
+	require.NoError(s.T(), err, "an error occurred while creating an item")
+
+	s.T().Run("missing coins", func(t *testing.T) {
+		_, err := s.Repo.AddWishlistItem(ctx, userID, name)
+		require.NoError(t, err, "an error occurred while adding an item to the wishlist")
+		wishlist, err := s.Repo.AddWishlistItem(ctx, userID, CheapItem)
+		require.NoError(t, err, "an error occurred while adding an item to the wishlist")
+
+		assert.Equal(t, InitialAmount, wishlist.Balance, "unexpected balance")
+		require.Len(t, wishlist.Items, 2, "unexpected number of items")
+		assert.Equal(t, name, wishlist.Items[0].Name, "items must be in the order they were added")
+		assert.Equal(t, 1500-InitialAmount, wishlist.Items[0].Missing, "unexpected coins missing for an item")
+		assert.Equal(t, int64(0), wishlist.Items[1].Missing, "nothing is missing for an affordable item")
+		assert.Equal(t, 1500+CheapItemPrice-InitialAmount, wishlist.Missing, "unexpected coins missing for the wishlist")
+
+		wishlist, err = s.Repo.AddWishlistItem(ctx, userID, name)
+		require.NoError(t, err, "an error occurred while adding an item to the wishlist twice")
+		assert.Len(t, wishlist.Items, 2, "an item must be in the wishlist once")
+	})
+
+	s.T().Run("price drop and restock notifications", func(t *testing.T) {
+		_, err := s.Repo.UpdateMerch(ctx, name, model.MerchRequest{Price: ptr(int64(1200))})
+		require.NoError(t, err, "an error occurred while repricing an item")
+		_, err = s.Repo.UpdateMerch(ctx, name, model.MerchRequest{Price: ptr(int64(1300))})
+		require.NoError(t, err, "an error occurred while repricing an item")
+		_, err = s.Repo.Restock(ctx, name, 1)
+		require.NoError(t, err, "an error occurred while restocking an item")
+		_, err = s.Repo.Restock(ctx, name, 1)
+		require.NoError(t, err, "an error occurred while restocking an item")
+
+		notifications, err := s.Repo.Notifications(ctx, userID, 10)
+		require.NoError(t, err, "an error occurred while getting notifications")
+		require.Len(t, notifications, 2, "only a price drop and a restock must be notified")
+
+		assert.Equal(t, model.NotificationRestock, notifications[0].Type, "the newest notification must be the first")
+		assert.Equal(t, name, notifications[0].Item, "unexpected item of a notification")
+		assert.Equal(t, int64(1300), notifications[0].Price, "unexpected price of a restocked item")
+
+		assert.Equal(t, model.NotificationPriceDrop, notifications[1].Type, "unexpected type of a notification")
+		assert.Equal(t, int64(1500), notifications[1].OldPrice, "unexpected price before the drop")
+		assert.Equal(t, int64(1200), notifications[1].Price, "unexpected price after the drop")
+		assert.False(t, notifications[1].Read, "new notifications must be unread")
+
+		notifications, err = s.Repo.Notifications(ctx, otherID, 10)
+		require.NoError(t, err, "an error occurred while getting notifications")
+		assert.Empty(t, notifications, "users without the item in the wishlist must not be notified")
+	})
+
+	s.T().Run("notifications read", func(t *testing.T) {
+		require.NoError(t, s.Repo.ReadNotifications(ctx, userID), "an error occurred while reading notifications")
+
+		notifications, err := s.Repo.Notifications(ctx, userID, 1)
+		require.NoError(t, err, "an error occurred while getting notifications")
+		require.Len(t, notifications, 1, "unexpected number of notifications")
+		assert.True(t, notifications[0].Read, "notification must be read")
+	})
+
+	s.T().Run("item removed", func(t *testing.T) {
+		wishlist, err := s.Repo.RemoveWishlistItem(ctx, userID, name)
+		require.NoError(t, err, "an error occurred while removing an item from the wishlist")
+		require.Len(t, wishlist.Items, 1, "unexpected number of items")
+		assert.Equal(t, CheapItem, wishlist.Items[0].Name, "unexpected item left in the wishlist")
+	})
+
+	s.T().Run("unknown item", func(t *testing.T) {
+		_, err := s.Repo.AddWishlistItem(ctx, userID, "unknown-item")
+		assert.ErrorIs(t, err, apperr.ErrItemNotFound, "unexpected error when adding a non-existent item")
+	})
+}
+
+func (s *RepositorySuite) TestPromoCodes() {
+	ctx := context.Background()
+
+	suffix := uuid.Must(uuid.NewV4()).String()[:8]
+	name := "promoted-" + suffix
+	category := "promoted-" + suffix
+	_, err := s.Repo.CreateMerch(ctx, model.MerchItem{Name: name, Price: 200, Available: true, Category: category})
+	require.NoError(s.T(), err, "an error occurred while creating an item")
+
+	s.T().Run("percent discount on a category with usage limits", func(t *testing.T) {
+		code := "PCT-" + suffix
+		_, err := s.Repo.CreatePromoCode(ctx, model.PromoCode{
+			Code: code, Type: model.PromoPercent, Value: 25, Category: category, PerUserLimit: 1, TotalLimit: 2,
+		})
+		require.NoError(t, err, "an error occurred while creating a promo code")
+
+		userID, _ := s.newUser()
+		otherID, _ := s.newUser()
+		thirdID, _ := s.newUser()
+
+		assert.ErrorIs(t, s.Repo.BuyItem(ctx, userID, CheapItem, code), apperr.ErrPromoNotApplicable, "unexpected error for an item out of the category")
+
+		require.NoError(t, s.Repo.BuyItem(ctx, userID, name, code), "an error occurred while buying with a promo code")
+		assert.Equal(t, InitialAmount-150, s.coins(userID), "the discounted price must be charged")
+
+		assert.ErrorIs(t, s.Repo.BuyItem(ctx, userID, name, code), apperr.ErrPromoLimitReached, "unexpected error over the per-user limit")
+		require.NoError(t, s.Repo.BuyItem(ctx, otherID, name, code), "an error occurred while buying with a promo code")
+		assert.ErrorIs(t, s.Repo.BuyItem(ctx, thirdID, name, code), apperr.ErrPromoLimitReached, "unexpected error over the total limit")
+		assert.Equal(t, InitialAmount, s.coins(thirdID), "balance must not change")
+
+		promos, err := s.Repo.PromoCodes(ctx)
+		require.NoError(t, err, "an error occurred while getting promo codes")
+		i := slices.IndexFunc(promos, func(p model.PromoCode) bool { return p.Code == code })
+		require.NotEqual(t, -1, i, "promo code not found")
+		assert.Equal(t, 2, promos[i].Used, "unexpected number of uses")
+	})
+
+	s.T().Run("fixed discount on an order", func(t *testing.T) {
+		code := "FIX-" + suffix
+		_, err := s.Repo.CreatePromoCode(ctx, model.PromoCode{Code: code, Type: model.PromoFixed, Value: 30, Item: name})
+		require.NoError(t, err, "an error occurred while creating a promo code")
+
+		userID, _ := s.newUser()
+
+		order, err := s.Repo.PlaceOrder(ctx, userID, []model.OrderItem{
+			{Name: name, Quantity: 2},
+			{Name: CheapItem, Quantity: 1},
+		}, code)
+		require.NoError(t, err, "an error occurred while placing an order with a promo code")
+
+		total := 2*(200-30) + CheapItemPrice
+		assert.Equal(t, code, order.Promo, "unexpected promo code of the order")
+		assert.Equal(t, total, order.Total, "unexpected order total")
+		assert.Equal(t, []model.OrderLine{
+			{Name: CheapItem, Quantity: 1, Price: CheapItemPrice, Total: CheapItemPrice},
+			{Name: name, Quantity: 2, Price: 200, Discount: 30, Total: 2 * (200 - 30)},
+		}, order.Items, "unexpected order lines")
+		assert.Equal(t, InitialAmount-total, s.coins(userID), "the discounted total must be charged")
+
+		_, err = s.Repo.PlaceOrder(ctx, userID, []model.OrderItem{{Name: CheapItem, Quantity: 1}}, code)
+		assert.ErrorIs(t, err, apperr.ErrPromoNotApplicable, "unexpected error for an order without matching items")
+	})
+
+	s.T().Run("refund releases the promo code", func(t *testing.T) {
+		code := "BACK-" + suffix
+		_, err := s.Repo.CreatePromoCode(ctx, model.PromoCode{
+			Code: code, Type: model.PromoFixed, Value: 50, Item: name, PerUserLimit: 1, TotalLimit: 1,
+		})
+		require.NoError(t, err, "an error occurred while creating a promo code")
+
+		userID, _ := s.newUser()
+		require.NoError(t, s.Repo.BuyItem(ctx, userID, name, code), "an error occurred while buying with a promo code")
+		require.NoError(t, s.Repo.RefundPurchase(ctx, userID, s.lastOperation(userID, model.OperationPurchase), time.Hour),
+			"an error occurred while refunding a purchase")
+		require.NoError(t, s.Repo.BuyItem(ctx, userID, name, code), "the promo code must be usable again after a refund")
+		assert.Equal(t, InitialAmount-150, s.coins(userID), "unexpected balance")
+
+		assert.ErrorIs(t, s.Repo.BuyItem(ctx, userID, name, code), apperr.ErrPromoLimitReached, "unexpected error over the per-user limit")
+	})
+
+	s.T().Run("order refund releases the promo code with the last discounted unit", func(t *testing.T) {
+		code := "ORDER-" + suffix
+		_, err := s.Repo.CreatePromoCode(ctx, model.PromoCode{Code: code, Type: model.PromoFixed, Value: 50, Item: name, PerUserLimit: 1})
+		require.NoError(t, err, "an error occurred while creating a promo code")
+
+		userID, _ := s.newUser()
+		_, err = s.Repo.PlaceOrder(ctx, userID, []model.OrderItem{{Name: name, Quantity: 2}}, code)
+		require.NoError(t, err, "an error occurred while placing an order with a promo code")
+
+		purchases := s.operations(userID, model.OperationPurchase)
+		require.Len(t, purchases, 2, "unexpected number of purchases")
+
+		require.NoError(t, s.Repo.RefundPurchase(ctx, userID, purchases[0], time.Hour), "an error occurred while refunding a purchase")
+		assert.ErrorIs(t, s.Repo.BuyItem(ctx, userID, name, code), apperr.ErrPromoLimitReached, "the order still uses the promo code")
+
+		require.NoError(t, s.Repo.RefundPurchase(ctx, userID, purchases[1], time.Hour), "an error occurred while refunding a purchase")
+		assert.NoError(t, s.Repo.BuyItem(ctx, userID, name, code), "the promo code must be usable again after the order is refunded")
+	})
+
+	s.T().Run("order refund releases a promo code with zero discount", func(t *testing.T) {
+		// 1% от цены дешевого мерча округляется до нуля
+		code := "ZERO-" + suffix
+		_, err := s.Repo.CreatePromoCode(ctx, model.PromoCode{Code: code, Type: model.PromoPercent, Value: 1, Item: CheapItem, PerUserLimit: 1})
+		require.NoError(t, err, "an error occurred while creating a promo code")
+
+		userID, _ := s.newUser()
+		order, err := s.Repo.PlaceOrder(ctx, userID, []model.OrderItem{{Name: CheapItem, Quantity: 1}}, code)
+		require.NoError(t, err, "an error occurred while placing an order with a promo code")
+		assert.Equal(t, CheapItemPrice, order.Total, "unexpected total with zero discount")
+
+		purchases, err := s.Repo.Purchases(ctx, userID, 10)
+		require.NoError(t, err, "an error occurred while getting purchases")
+		require.Len(t, purchases, 1, "unexpected number of purchases")
+		assert.Equal(t, code, purchases[0].Promo, "the promo code must be recorded in the purchase")
+
+		require.NoError(t, s.Repo.RefundPurchase(ctx, userID, purchases[0].ID, time.Hour), "an error occurred while refunding a purchase")
+		_, err = s.Repo.PlaceOrder(ctx, userID, []model.OrderItem{{Name: CheapItem, Quantity: 1}}, code)
+		assert.NoError(t, err, "the promo code must be usable again after the order is refunded")
+	})
+
+	s.T().Run("discounted item costs at least one coin", func(t *testing.T) {
+		code := "ALL-" + suffix
+		_, err := s.Repo.CreatePromoCode(ctx, model.PromoCode{Code: code, Type: model.PromoFixed, Value: 1000})
+		require.NoError(t, err, "an error occurred while creating a promo code")
+
+		userID, _ := s.newUser()
+		require.NoError(t, s.Repo.BuyItem(ctx, userID, CheapItem, code), "an error occurred while buying with a promo code")
+		assert.Equal(t, InitialAmount-1, s.coins(userID), "unexpected discounted price")
+	})
+
+	s.T().Run("validity window", func(t *testing.T) {
+		userID, _ := s.newUser()
+
+		_, err := s.Repo.CreatePromoCode(ctx, model.PromoCode{
+			Code: "SOON-" + suffix, Type: model.PromoPercent, Value: 10, ValidFrom: time.Now().Add(time.Hour),
+		})
+		require.NoError(t, err, "an error occurred while creating a promo code")
+		assert.ErrorIs(t, s.Repo.BuyItem(ctx, userID, name, "SOON-"+suffix), apperr.ErrPromoNotActive, "unexpected error before the validity window")
+
+		_, err = s.Repo.CreatePromoCode(ctx, model.PromoCode{
+			Code: "OVER-" + suffix, Type: model.PromoPercent, Value: 10, ValidTo: time.Now().Add(-time.Hour),
+		})
+		require.NoError(t, err, "an error occurred while creating a promo code")
+		assert.ErrorIs(t, s.Repo.BuyItem(ctx, userID, name, "OVER-"+suffix), apperr.ErrPromoNotActive, "unexpected error after the validity window")
+
+		assert.Equal(t, InitialAmount, s.coins(userID), "balance must not change")
+	})
+
+	s.T().Run("unknown and duplicate promo codes", func(t *testing.T) {
+		userID, _ := s.newUser()
+		assert.ErrorIs(t, s.Repo.BuyItem(ctx, userID, name, "NONE-"+suffix), apperr.ErrPromoNotFound, "unexpected error for an unknown promo code")
+
+		_, err := s.Repo.CreatePromoCode(ctx, model.PromoCode{Code: "PCT-" + suffix, Type: model.PromoFixed, Value: 1})
+		assert.ErrorIs(t, err, apperr.ErrPromoAlreadyExists, "unexpected error for a duplicate promo code")
+
+		_, err = s.Repo.CreatePromoCode(ctx, model.PromoCode{Code: "ITEM-" + suffix, Type: model.PromoFixed, Value: 1, Item: "unknown-item"})
+		assert.ErrorIs(t, err, apperr.ErrItemNotFound, "unexpected error for a promo code on an unknown item")
+	})
+}
+
+func (s *RepositorySuite) TestPurchases() {
+	ctx := context.Background()
+
+	userID, _ := s.newUser()
+	_, friendLogin := s.newUser()
+
+	suffix := uuid.Must(uuid.NewV4()).String()[:8]
+	name := "bought-" + suffix
+	_, err := s.Repo.CreateMerch(ctx, model.MerchItem{Name: name, Price: 100, Available: true})
+	require.NoError(s.T(), err, "an error occurred while creating an item")
+	_, err = s.Repo.CreatePromoCode(ctx, model.PromoCode{Code: "PAID-" + suffix, Type: model.PromoFixed, Value: 20, Item: name})
+	require.NoError(s.T(), err, "an error occurred while creating a promo code")
+
+	require.NoError(s.T(), s.Repo.BuyItem(ctx, userID, name, ""), "an error occurred while buying an item")
+	_, err = s.Repo.UpdateMerch(ctx, name, model.MerchRequest{Price: ptr(int64(150))})
+	require.NoError(s.T(), err, "an error occurred while repricing an item")
+	require.NoError(s.T(), s.Repo.BuyItem(ctx, userID, name, "PAID-"+suffix), "an error occurred while buying with a promo code")
+	require.NoError(s.T(), s.Repo.GiftItem(ctx, userID, model.GiftRequest{ToUser: friendLogin, Item: name}), "an error occurred while gifting an item")
+
+	s.T().Run("price paid is kept after repricing", func(t *testing.T) {
+		purchases, err := s.Repo.Purchases(ctx, userID, 10)
+		require.NoError(t, err, "an error occurred while getting purchases")
+		require.Len(t, purchases, 3, "unexpected number of purchases")
+
+		assert.Equal(t, int64(150), purchases[0].PricePaid, "unexpected price of a gift")
+		assert.Equal(t, friendLogin, purchases[0].ToUser, "unexpected gift recipient")
+
+		assert.Equal(t, int64(130), purchases[1].PricePaid, "the discounted price must be recorded")
+		assert.Equal(t, int64(20), purchases[1].Discount, "unexpected discount")
+		assert.Equal(t, "PAID-"+suffix, purchases[1].Promo, "unexpected promo code")
+
+		assert.Equal(t, name, purchases[2].Item, "unexpected item")
+		assert.Equal(t, int64(100), purchases[2].PricePaid, "the price before repricing must be recorded")
+		assert.Empty(t, purchases[2].ToUser, "own purchase must not have a recipient")
+		assert.Nil(t, purchases[2].RefundedAt, "purchase must not be refunded")
+	})
+
+	s.T().Run("refund of the paid price", func(t *testing.T) {
+		purchases, err := s.Repo.Purchases(ctx, userID, 10)
+		require.NoError(t, err, "an error occurred while getting purchases")
+		require.Len(t, purchases, 3, "unexpected number of purchases")
+
+		before := s.coins(userID)
+		require.NoError(t, s.Repo.RefundPurchase(ctx, userID, purchases[1].ID, time.Hour), "an error occurred while refunding a purchase")
+		assert.Equal(t, before+130, s.coins(userID), "the paid price must be refunded")
+
+		purchases, err = s.Repo.Purchases(ctx, userID, 1)
+		require.NoError(t, err, "an error occurred while getting purchases")
+		assert.Len(t, purchases, 1, "purchases must be limited")
+	})
+
+	s.T().Run("order purchases", func(t *testing.T) {
+		buyerID, _ := s.newUser()
+
+		order, err := s.Repo.PlaceOrder(ctx, buyerID, []model.OrderItem{{Name: name, Quantity: 2}}, "")
+		require.NoError(t, err, "an error occurred while placing an order")
+
+		purchases, err := s.Repo.Purchases(ctx, buyerID, 10)
+		require.NoError(t, err, "an error occurred while getting purchases")
+		require.Len(t, purchases, 2, "every unit of an order is a purchase")
+		for _, p := range purchases {
+			require.NotNil(t, p.OrderID, "order purchase must refer to the order")
+			assert.Equal(t, order.ID, *p.OrderID, "unexpected order of a purchase")
+			assert.Equal(t, int64(150), p.PricePaid, "unexpected price of an order unit")
+		}
+	})
+}
+
+func (s *RepositorySuite) TestPriceHistory() {
+	ctx := context.Background()
+
+	name := "repriced-" + uuid.Must(uuid.NewV4()).String()[:8]
+	_, err := s.Repo.CreateMerch(ctx, model.MerchItem{Name: name, Price: 100, Available: true})
+	require.NoError(s.T(), err, "an error occurred while creating an item")
+
+	for _, req := range []model.MerchRequest{
+		{Price: ptr(int64(120))},
+		{Description: ptr("repriced"), Price: ptr(int64(120))},
+		{Price: ptr(int64(90))},
+	} {
+		_, err := s.Repo.UpdateMerch(ctx, name, req)
+		require.NoError(s.T(), err, "an error occurred while updating an item")
+	}
+
+	prices, err := s.Repo.PriceHistory(ctx, name)
+	require.NoError(s.T(), err, "an error occurred while getting the price history")
+	require.Len(s.T(), prices, 3, "only price changes must be recorded")
+	assert.Equal(s.T(), []int64{100, 120, 90}, []int64{prices[0].Price, prices[1].Price, prices[2].Price}, "unexpected price timeline")
+	assert.False(s.T(), prices[2].Date.Before(prices[0].Date), "prices must be ordered from old to new")
+
+	_, err = s.Repo.PriceHistory(ctx, "unknown-item")
+	assert.ErrorIs(s.T(), err, apperr.ErrItemNotFound, "unexpected error for a non-existent item")
+}
+
+func (s *RepositorySuite) TestRefundPurchase() {
+	ctx := context.Background()
+
+	userID, _ := s.newUser()
+	otherID, _ := s.newUser()
+
+	name := "refundable-" + uuid.Must(uuid.NewV4()).String()[:8]
+	_, err := s.Repo.CreateMerch(ctx, model.MerchItem{Name: name, Price: 20, Available: true, Stock: ptr(1)})
+	require.NoError(s.T(), err, "an error occurred while creating an item")
+
+	require.NoError(s.T(), s.Repo.BuyItem(ctx, userID, name, ""), "an error occurred while buying an item")
+	purchaseID := s.lastOperation(userID, model.OperationPurchase)
+
+	// возвращается уплаченная цена, а не текущая
+	_, err = s.Repo.UpdateMerch(ctx, name, model.MerchRequest{Price: ptr(int64(50))})
+	require.NoError(s.T(), err, "an error occurred while repricing an item")
+
+	s.T().Run("refund of another user's purchase", func(t *testing.T) {
+		err := s.Repo.RefundPurchase(ctx, otherID, purchaseID, time.Hour)
+		assert.ErrorIs(t, err, apperr.ErrPurchaseNotFound, "unexpected error when refunding another user's purchase")
+	})
+
+	s.T().Run("refund window expired", func(t *testing.T) {
+		err := s.Repo.RefundPurchase(ctx, userID, purchaseID, -time.Hour)
+		assert.ErrorIs(t, err, apperr.ErrRefundWindowExpired, "unexpected error when the refund window expired")
+	})
+
+	s.T().Run("purchase refunded", func(t *testing.T) {
+		require.NoError(t, s.Repo.RefundPurchase(ctx, userID, purchaseID, time.Hour), "an error occurred while refunding a purchase")
+
+		info, err := s.Repo.Info(ctx, userID)
+		require.NoError(t, err, "an error occurred while getting information about a user")
+		assert.Equal(t, InitialAmount, info.Coins, "the paid price must be returned")
+		assert.Empty(t, info.Inventory, "refunded item must leave the inventory")
+
+		item, err := s.Repo.MerchItem(ctx, name)
+		require.NoError(t, err, "an error occurred while getting an item")
+		assert.Equal(t, ptr(1), item.Stock, "stock must be restored")
+
+		page, err := s.Repo.TransactionHistory(ctx, userID, model.HistoryFilter{Limit: 1})
+		require.NoError(t, err, "an error occurred while getting transaction history")
+		require.Len(t, page.Entries, 1, "refund must be in the history")
+		assert.Equal(t, model.OperationRefund, page.Entries[0].Type, "unexpected type of the last entry")
+		assert.NotEqual(t, purchaseID, page.Entries[0].OperationID, "refund must be a separate operation")
+		assert.Equal(t, model.DirectionIn, page.Entries[0].Direction, "unexpected direction of a refund")
+		assert.Equal(t, name, page.Entries[0].Counterpart, "unexpected counterpart of a refund")
+		assert.Equal(t, int64(20), page.Entries[0].Amount, "unexpected amount of a refund")
+	})
+
+	s.T().Run("purchase already refunded", func(t *testing.T) {
+		err := s.Repo.RefundPurchase(ctx, userID, purchaseID, time.Hour)
+		assert.ErrorIs(t, err, apperr.ErrPurchaseAlreadyRefunded, "unexpected error when refunding twice")
+	})
+
+	s.T().Run("purchase not found", func(t *testing.T) {
+		err := s.Repo.RefundPurchase(ctx, userID, uuid.Must(uuid.NewV4()), time.Hour)
+		assert.ErrorIs(t, err, apperr.ErrPurchaseNotFound, "unexpected error when refunding a non-existent purchase")
+	})
+}
+
+func (s *RepositorySuite) TestTransactionHistory() {
+	ctx := context.Background()
+
+	userID, login := s.newUser()
+	friendID, friend := s.newUser()
+	_, stranger := s.newUser()
+
+	require.NoError(s.T(), s.Repo.BuyItem(ctx, userID, CheapItem, ""))
+	require.NoError(s.T(), s.Repo.SendCoin(ctx, userID, model.SendCoinRequest{ToUser: friend, Amount: 100}))
+	require.NoError(s.T(), s.Repo.SendCoin(ctx, friendID, model.SendCoinRequest{ToUser: stranger, Amount: 1}))
+	require.NoError(s.T(), s.Repo.SendCoin(ctx, friendID, model.SendCoinRequest{ToUser: login, Amount: 30}))
+
+	s.T().Run("newest first with balance after", func(t *testing.T) {
+		page, err := s.Repo.TransactionHistory(ctx, userID, model.HistoryFilter{Limit: 10})
+		require.NoError(t, err, "an error occurred while getting transaction history")
+		assert.Zero(t, page.Next, "unexpected next page")
+
+		expected := []struct {
+			typ, direction, counterpart string
+			amount, balance             int64
+		}{
+			{model.OperationTransfer, model.DirectionIn, friend, 30, InitialAmount - CheapItemPrice - 70},
+			{model.OperationTransfer, model.DirectionOut, friend, 100, InitialAmount - CheapItemPrice - 100},
+			{model.OperationPurchase, model.DirectionOut, CheapItem, CheapItemPrice, InitialAmount - CheapItemPrice},
+			{model.OperationBonus, model.DirectionIn, "", InitialAmount, InitialAmount},
+		}
+		require.Len(t, page.Entries, len(expected), "unexpected number of entries")
+		for i, e := range expected {
+			got := page.Entries[i]
+			assert.Equal(t, e.typ, got.Type, "unexpected type of entry %d", i)
+			assert.Equal(t, e.direction, got.Direction, "unexpected direction of entry %d", i)
+			assert.Equal(t, e.counterpart, got.Counterpart, "unexpected counterpart of entry %d", i)
+			assert.Equal(t, e.amount, got.Amount, "unexpected amount of entry %d", i)
+			assert.Equal(t, e.balance, got.BalanceAfter, "unexpected balance after entry %d", i)
+			assert.NotEqual(t, uuid.Nil, got.ID, "zero id of entry %d", i)
+			assert.NotEqual(t, uuid.Nil, got.OperationID, "zero operation id of entry %d", i)
+		}
+	})
+
+	s.T().Run("cursor pagination", func(t *testing.T) {
+		var all []model.HistoryEntry
+		filter := model.HistoryFilter{Limit: 3}
+		for {
+			page, err := s.Repo.TransactionHistory(ctx, userID, filter)
+			require.NoError(t, err, "an error occurred while getting transaction history")
+			all = append(all, page.Entries...)
+			if page.Next == 0 {
+				break
+			}
+			require.Len(t, page.Entries, filter.Limit, "a non-final page must be full")
+			filter.Before = page.Next
+		}
+
+		require.Len(t, all, 4, "pages must cover the whole history")
+		for i := 1; i < len(all); i++ {
+			assert.Greater(t, all[i-1].Seq, all[i].Seq, "entries must be ordered newest first")
+		}
+	})
+
+	s.T().Run("filters", func(t *testing.T) {
+		page, err := s.Repo.TransactionHistory(ctx, userID, model.HistoryFilter{Limit: 10, Direction: model.DirectionOut})
+		require.NoError(t, err)
+		assert.Len(t, page.Entries, 2, "unexpected number of outgoing entries")
+
+		page, err = s.Repo.TransactionHistory(ctx, userID, model.HistoryFilter{Limit: 10, Counterpart: friend})
+		require.NoError(t, err)
+		assert.Len(t, page.Entries, 2, "unexpected number of entries with a counterpart")
+
+		page, err = s.Repo.TransactionHistory(ctx, friendID, model.HistoryFilter{Limit: 10, Counterpart: stranger})
+		require.NoError(t, err)
+		require.Len(t, page.Entries, 1, "unexpected number of entries with a counterpart")
+		assert.Equal(t, InitialAmount+100-1, page.Entries[0].BalanceAfter, "balance after must not depend on filters")
+
+		page, err = s.Repo.TransactionHistory(ctx, userID, model.HistoryFilter{Limit: 10, From: time.Now().Add(time.Hour)})
+		require.NoError(t, err)
+		assert.Empty(t, page.Entries, "no entries expected in the future")
+
+		page, err = s.Repo.TransactionHistory(ctx, userID, model.HistoryFilter{Limit: 10, To: time.Now().Add(-time.Hour)})
+		require.NoError(t, err)
+		assert.Empty(t, page.Entries, "no entries expected in the past")
+	})
+
+	s.T().Run("account not found", func(t *testing.T) {
+		_, err := s.Repo.TransactionHistory(ctx, uuid.Must(uuid.NewV4()), model.HistoryFilter{Limit: 10})
+		assert.ErrorIs(t, err, apperr.ErrAccountNotFound, "unexpected error for a non-existent user")
+	})
+}
+
+func (s *RepositorySuite) TestGrantCoins() {
+	ctx := context.Background()
+
+	userID, login := s.newUser()
+	otherID, otherLogin := s.newUser()
+
+	s.T().Run("listed users get coins", func(t *testing.T) {
+		granted, err := s.Repo.GrantCoins(ctx, model.GrantRequest{
+			Users:  []string{login, otherLogin, login},
+			Amount: 50,
+			Reason: "hackathon winner",
+		})
+		require.NoError(t, err, "an error occurred while granting coins")
+		assert.Equal(t, 2, granted, "unexpected number of recipients")
+		assert.Equal(t, InitialAmount+50, s.coins(userID), "unexpected balance after grant")
+		assert.Equal(t, InitialAmount+50, s.coins(otherID), "unexpected balance after grant")
+	})
+
+	s.T().Run("grant is shown in the history", func(t *testing.T) {
+		page, err := s.Repo.TransactionHistory(ctx, userID, model.HistoryFilter{Limit: 1})
+		require.NoError(t, err, "an error occurred while getting transaction history")
+		require.Len(t, page.Entries, 1, "unexpected number of history entries")
+		assert.Equal(t, model.OperationGrant, page.Entries[0].Type, "unexpected operation type")
+		assert.Equal(t, model.DirectionIn, page.Entries[0].Direction, "unexpected direction")
+		assert.Equal(t, int64(50), page.Entries[0].Amount, "unexpected amount")
+		assert.Equal(t, "hackathon winner", page.Entries[0].Description, "unexpected description")
+	})
+
+	s.T().Run("grant is idempotent per period", func(t *testing.T) {
+		period := "period-" + uuid.Must(uuid.NewV4()).String()
+		req := model.GrantRequest{All: true, Amount: 10, Reason: "allowance", Period: period}
+
+		granted, err := s.Repo.GrantCoins(ctx, req)
+		require.NoError(t, err, "an error occurred while granting coins")
+		assert.GreaterOrEqual(t, granted, 2, "all users should get coins")
+
+		granted, err = s.Repo.GrantCoins(ctx, req)
+		require.NoError(t, err, "an error occurred while repeating a grant")
+		assert.Equal(t, 0, granted, "coins were granted twice for the same period")
+		assert.Equal(t, InitialAmount+60, s.coins(userID), "unexpected balance after repeated grant")
+
+		newID, newLogin := s.newUser()
+		granted, err = s.Repo.GrantCoins(ctx, model.GrantRequest{Users: []string{newLogin, login}, Amount: 10, Reason: "allowance", Period: period})
+		require.NoError(t, err, "an error occurred while granting coins to a new user")
+		assert.Equal(t, 1, granted, "only the new user should get coins")
+		assert.Equal(t, InitialAmount+10, s.coins(newID), "unexpected balance of the new user")
+	})
+
+	s.T().Run("unknown recipient", func(t *testing.T) {
+		_, err := s.Repo.GrantCoins(ctx, model.GrantRequest{Users: []string{login, "nobody-" + login}, Amount: 10, Reason: "bonus"})
+		assert.ErrorIs(t, err, apperr.ErrRecipientNotFound, "unexpected error when granting coins to a non-existent user")
+		assert.Equal(t, InitialAmount+60, s.coins(userID), "balance changed after a failed grant")
+	})
+}
+
+func (s *RepositorySuite) TestLeaderboard() {
+	ctx := context.Background()
+
+	senderID, sender := s.newUser()
+	receiverID, receiver := s.newUser()
+	_, other := s.newUser()
+
+	require.NoError(s.T(), s.Repo.SendCoin(ctx, senderID, model.SendCoinRequest{ToUser: receiver, Amount: 300}))
+	require.NoError(s.T(), s.Repo.SendCoin(ctx, senderID, model.SendCoinRequest{ToUser: other, Amount: 100}))
+	require.NoError(s.T(), s.Repo.BuyItem(ctx, receiverID, DearItem, ""))
+	require.NoError(s.T(), s.Repo.BuyItem(ctx, receiverID, CheapItem, ""))
+
+	// рейтинг общий для всех тестов, поэтому ищем в нем только своих пользователей
+	amounts := func(entries []model.LeaderboardEntry) map[string]int64 {
+		m := make(map[string]int64)
+		for i, e := range entries {
+			if i > 0 {
+				assert.GreaterOrEqual(s.T(), entries[i-1].Amount, e.Amount, "leaderboard must be sorted by amount")
+			}
+			m[e.Login] = e.Amount
+		}
+		return m
+	}
+
+	leaderboard := func(t *testing.T, since time.Time) model.Leaderboard {
+		board, err := s.Repo.Leaderboard(ctx, model.LeaderboardFilter{Since: since, Limit: 1000})
+		require.NoError(t, err, "an error occurred while getting the leaderboard")
+		return board
+	}
+
+	s.T().Run("totals by user", func(t *testing.T) {
+		board := leaderboard(t, time.Now().Add(-time.Hour))
+
+		receivers := amounts(board.Receivers)
+		assert.Equal(t, int64(300), receivers[receiver], "unexpected amount received")
+		assert.Equal(t, int64(100), receivers[other], "unexpected amount received")
+		assert.NotContains(t, receivers, sender, "the sender has not received coins")
+
+		assert.Equal(t, int64(400), amounts(board.Senders)[sender], "unexpected amount sent")
+		assert.Equal(t, DearItemPrice+CheapItemPrice, amounts(board.Purchasers)[receiver], "unexpected amount spent")
+	})
+
+	s.T().Run("refunded purchases are not counted", func(t *testing.T) {
+		purchaseID := s.lastOperation(receiverID, model.OperationPurchase)
+		require.NoError(t, s.Repo.RefundPurchase(ctx, receiverID, purchaseID, time.Hour), "an error occurred while refunding a purchase")
+
+		assert.Equal(t, DearItemPrice, amounts(leaderboard(t, time.Time{}).Purchasers)[receiver], "unexpected amount spent")
+	})
+
+	s.T().Run("operations before the window are not counted", func(t *testing.T) {
+		board := leaderboard(t, time.Now().Add(time.Hour))
+		assert.NotContains(t, amounts(board.Receivers), receiver, "operations before the window must be excluded")
+		assert.NotContains(t, amounts(board.Senders), sender, "operations before the window must be excluded")
+	})
+
+	s.T().Run("limit", func(t *testing.T) {
+		board, err := s.Repo.Leaderboard(ctx, model.LeaderboardFilter{Limit: 1})
+		require.NoError(t, err, "an error occurred while getting the leaderboard")
+		assert.Len(t, board.Receivers, 1, "unexpected leaderboard size")
+	})
+
+	s.T().Run("hidden users are excluded", func(t *testing.T) {
+		require.NoError(t, s.Repo.SetLeaderboardHidden(ctx, receiverID, true), "an error occurred while hiding a user")
+
+		board := leaderboard(t, time.Time{})
+		assert.NotContains(t, amounts(board.Receivers), receiver, "hidden user must be excluded")
+		assert.NotContains(t, amounts(board.Purchasers), receiver, "hidden user must be excluded")
+		assert.Contains(t, amounts(board.Receivers), other, "other users must stay in the leaderboard")
+
+		require.NoError(t, s.Repo.SetLeaderboardHidden(ctx, receiverID, false), "an error occurred while showing a user")
+		assert.Contains(t, amounts(leaderboard(t, time.Time{}).Receivers), receiver, "user must return to the leaderboard")
+	})
+
+	s.T().Run("unknown user", func(t *testing.T) {
+		err := s.Repo.SetLeaderboardHidden(ctx, uuid.Must(uuid.NewV4()), true)
+		assert.ErrorIs(t, err, apperr.ErrUserNotFound, "unexpected error when hiding a non-existent user")
+	})
+}
+
+func (s *RepositorySuite) TestIdempotencyKey() {
+	ctx := context.Background()
+
+	userID, _ := s.newUser()
+	const ttl = time.Hour
+
+	s.T().Run("key acquired", func(t *testing.T) {
+		saved, err := s.Repo.AcquireIdempotencyKey(ctx, userID, "key-1", "POST /api/sendCoin", ttl)
+		assert.NoError(t, err, "an error occurred while acquiring a new key")
+		assert.Nil(t, saved, "a new key must not have a saved response")
+	})
+
+	s.T().Run("key in progress", func(t *testing.T) {
+		_, err := s.Repo.AcquireIdempotencyKey(ctx, userID, "key-1", "POST /api/sendCoin", ttl)
+		assert.ErrorIs(t, err, apperr.ErrIdempotencyKeyInProgress, "unexpected error for a key in progress")
+	})
+
+	s.T().Run("key reused with another request", func(t *testing.T) {
+		_, err := s.Repo.AcquireIdempotencyKey(ctx, userID, "key-1", "GET /api/buy/pen", ttl)
+		assert.ErrorIs(t, err, apperr.ErrIdempotencyKeyReused, "unexpected error for a reused key")
+	})
+
+	s.T().Run("saved response replayed", func(t *testing.T) {
+		resp := model.IdempotentResponse{
+			Status:      http.StatusOK,
+			ContentType: "application/json",
+			Body:        []byte(`{"ok":true}`),
+		}
+		require.NoError(t, s.Repo.SaveIdempotentResponse(ctx, userID, "key-1", resp))
+		require.NoError(t, s.Repo.ReleaseIdempotencyKey(ctx, userID, "key-1"), "releasing a completed key must be a no-op")
+
+		saved, err := s.Repo.AcquireIdempotencyKey(ctx, userID, "key-1", "POST /api/sendCoin", ttl)
+		assert.NoError(t, err, "an error occurred while acquiring a completed key")
+		assert.Equal(t, &resp, saved, "unexpected saved response")
+	})
+
+	s.T().Run("released key acquired again", func(t *testing.T) {
+		_, err := s.Repo.AcquireIdempotencyKey(ctx, userID, "key-2", "POST /api/sendCoin", ttl)
+		require.NoError(t, err)
+		require.NoError(t, s.Repo.ReleaseIdempotencyKey(ctx, userID, "key-2"))
+
+		saved, err := s.Repo.AcquireIdempotencyKey(ctx, userID, "key-2", "POST /api/sendCoin", ttl)
+		assert.NoError(t, err, "an error occurred while acquiring a released key")
+		assert.Nil(t, saved, "a released key must not have a saved response")
+	})
+
+	s.T().Run("expired key acquired again", func(t *testing.T) {
+		saved, err := s.Repo.AcquireIdempotencyKey(ctx, userID, "key-1", "GET /api/buy/pen", -time.Second)
+		assert.NoError(t, err, "an error occurred while acquiring an expired key")
+		assert.Nil(t, saved, "an expired key must not have a saved response")
+	})
+}
+
+// BonusPolicy - начисления новому пользователю, с которыми запускается RunBonusPolicy
+var BonusPolicy = model.BonusPolicy{
+	StartingBalance: 300,
+	Rules: []model.BonusRule{
+		{Name: "launch week", Amount: 50, From: time.Now().Add(-time.Hour), To: time.Now().Add(time.Hour)},
+		{Name: "spring", Amount: 20, From: time.Now().Add(-time.Hour)},
+		{Name: "first year", Amount: 70, To: time.Now().Add(-time.Hour)},
+		{Name: "next year", Amount: 80, From: time.Now().Add(time.Hour)},
+	},
+}
+
+// RunBonusPolicy проверяет начисления при регистрации, хранилище должно быть настроено с BonusPolicy
+func RunBonusPolicy(t *testing.T, repo storage.Repository) {
+	ctx := context.Background()
+
+	history := func(t *testing.T, userID uuid.UUID) []model.HistoryEntry {
+		page, err := repo.TransactionHistory(ctx, userID, model.HistoryFilter{Limit: 100})
+		require.NoError(t, err, "an error occurred while getting transaction history")
+		return page.Entries
+	}
+
+	t.Run("rules of the registration period are applied", func(t *testing.T) {
+		login := "user-" + uuid.Must(uuid.NewV4()).String()
+		userID, err := repo.UserAuth(ctx, model.AuthRequest{UserName: login, Password: login})
+		require.NoError(t, err, "an error occurred during user registration")
+
+		info, err := repo.Info(ctx, userID)
+		require.NoError(t, err, "an error occurred while getting information about a user")
+		assert.Equal(t, int64(300+50+20), info.Coins, "unexpected balance of a new user")
+
+		entries := history(t, userID)
+		require.Len(t, entries, 3, "unexpected number of history entries")
+		assert.Equal(t, model.OperationBonus, entries[len(entries)-1].Type, "starting balance must be credited first")
+		assert.Equal(t, int64(300), entries[len(entries)-1].Amount, "unexpected starting balance")
+
+		welcome := map[string]int64{}
+		for _, e := range entries[:len(entries)-1] {
+			assert.Equal(t, model.OperationWelcome, e.Type, "unexpected operation type")
+			welcome[e.Description] = e.Amount
+		}
+		assert.Equal(t, map[string]int64{"launch week": 50, "spring": 20}, welcome, "unexpected welcome bonuses")
+	})
+
+	t.Run("bonuses are credited only at registration", func(t *testing.T) {
+		login := "user-" + uuid.Must(uuid.NewV4()).String()
+		userID, err := repo.UserAuth(ctx, model.AuthRequest{UserName: login, Password: login})
+		require.NoError(t, err, "an error occurred during user registration")
+
+		_, err = repo.UserAuth(ctx, model.AuthRequest{UserName: login, Password: login})
+		require.NoError(t, err, "an error occurred during user authorization")
+
+		assert.Len(t, history(t, userID), 3, "bonuses must not be credited on login")
+	})
+}